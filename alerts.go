@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+)
+
+// alertChecker evaluates the rules from -alert-config against each decoded METAR and
+// keeps the messages for the rules that fired
+type alertChecker struct {
+	mu    sync.Mutex
+	rules []wx.Rule
+	fired []string
+}
+
+// activeAlerts is the checker set up by -alert-config, or nil when no rules are loaded
+var activeAlerts *alertChecker
+
+// loadAlertRules reads a JSON array of alert rules from the file at path
+func loadAlertRules(path string) (*alertChecker, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading alert config: %w", err)
+	}
+
+	rules, err := wx.ParseRules(data)
+	if err != nil {
+		return nil, err
+	}
+	return &alertChecker{rules: rules}, nil
+}
+
+// check evaluates the rules against a decoded METAR. It does nothing on a nil checker.
+func (a *alertChecker) check(m wx.METAR) {
+	if a == nil {
+		return
+	}
+
+	fired := wx.EvaluateAlerts(m, a.rules)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.fired = append(a.fired, fired...)
+}
+
+// report prints a line for each rule that fired and reports whether any did.
+// It does nothing on a nil checker.
+func (a *alertChecker) report(w io.Writer) bool {
+	if a == nil {
+		return false
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, message := range a.fired {
+		errorColor.Fprintf(w, "Alert: %s\n", message)
+	}
+	return len(a.fired) > 0
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertChecker(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"name":"gusty","wind_gust_above":30}]`), 0o644))
+
+	alerts, err := loadAlertRules(path)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	alerts.check(wx.DecodeMETAR("KJFK 081551Z 27020KT 10SM SKC 21/09 A3012"))
+	assert.False(t, alerts.report(&out))
+	assert.Empty(t, out.String())
+
+	alerts.check(wx.DecodeMETAR("KLGA 081551Z 27020G35KT 10SM SKC 21/09 A3012"))
+	assert.True(t, alerts.report(&out))
+	assert.Equal(t, "Alert: KLGA: rule \"gusty\": gusts 35 kt above 30 kt\n", out.String())
+
+	// A nil checker, without -alert-config, never fires
+	var none *alertChecker
+	none.check(wx.DecodeMETAR("KLGA 081551Z 27020G35KT 10SM SKC 21/09 A3012"))
+	assert.False(t, none.report(&out))
+
+	_, err = loadAlertRules(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorContains(t, err, "error reading alert config")
+}
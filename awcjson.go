@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+)
+
+// AWCReport is a METAR or TAF object as returned by the Aviation Weather Center
+// data API with format=json. Only the fields WxCraft uses are mapped; the decoded
+// report is built from the raw text so remarks are decoded too.
+type AWCReport struct {
+	IcaoID  string  `json:"icaoId"`
+	Name    string  `json:"name"`    // e.g. "Chicago/O'Hare Intl, IL, US"
+	RawOb   string  `json:"rawOb"`   // Raw METAR text
+	RawTAF  string  `json:"rawTAF"`  // Raw TAF text
+	ObsTime int64   `json:"obsTime"` // Observation time in Unix seconds
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+	Elev    int     `json:"elev"`
+}
+
+// parseAWCJSON parses AWC JSON input, either a single report object or an
+// array of them. For arrays the first report is used.
+func parseAWCJSON(input string) (AWCReport, error) {
+	input = strings.TrimSpace(input)
+
+	var reports []AWCReport
+	if strings.HasPrefix(input, "[") {
+		if err := json.Unmarshal([]byte(input), &reports); err != nil {
+			return AWCReport{}, fmt.Errorf("error parsing AWC JSON: %w", err)
+		}
+	} else {
+		var report AWCReport
+		if err := json.Unmarshal([]byte(input), &report); err != nil {
+			return AWCReport{}, fmt.Errorf("error parsing AWC JSON: %w", err)
+		}
+		reports = append(reports, report)
+	}
+
+	if len(reports) == 0 {
+		return AWCReport{}, fmt.Errorf("no reports found in AWC JSON")
+	}
+
+	report := reports[0]
+	if report.RawOb == "" && report.RawTAF == "" {
+		return AWCReport{}, fmt.Errorf("AWC JSON report has neither rawOb nor rawTAF")
+	}
+
+	return report, nil
+}
+
+// IsTAF reports whether the object holds a TAF rather than a METAR
+func (r AWCReport) IsTAF() bool {
+	return r.RawOb == "" && r.RawTAF != ""
+}
+
+// RawReport returns the raw METAR or TAF text of the report
+func (r AWCReport) RawReport() string {
+	if r.IsTAF() {
+		return strings.TrimSpace(r.RawTAF)
+	}
+	return strings.TrimSpace(r.RawOb)
+}
+
+// SiteInfo maps the report's "name" field ("Site, ST, CC" or "Site, CC") to a SiteInfo
+func (r AWCReport) SiteInfo() wx.SiteInfo {
+	info := wx.SiteInfo{Name: r.IcaoID, Latitude: r.Lat, Longitude: r.Lon}
+	if r.Name == "" {
+		return info
+	}
+
+	parts := strings.Split(r.Name, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	info.Name = parts[0]
+	switch len(parts) {
+	case 2:
+		info.Country = GetCountryName(parts[1])
+	case 3:
+		info.State = parts[1]
+		info.Country = GetCountryName(parts[2])
+	}
+
+	return info
+}
+
+// METAR decodes the report's raw METAR and attaches the site information from the JSON
+func (r AWCReport) METAR() wx.METAR {
+	metar := wx.DecodeMETAR(r.RawReport())
+	metar.SiteInfo = r.SiteInfo()
+	return metar
+}
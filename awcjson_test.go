@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAWCJSON_metar(t *testing.T) {
+	t.Parallel()
+
+	input := `[{"icaoId":"KORD","receiptTime":"2025-03-08 15:54:22","obsTime":1741449060,"temp":5,"dewp":-3,
+		"wdir":270,"wspd":18,"wgst":28,"visib":"10+","altim":1003.1,
+		"rawOb":"KORD 081551Z 27018G28KT 10SM BKN035 05/M03 A2962 RMK AO2 PK WND 27030/1532 SLP034",
+		"lat":41.9602,"lon":-87.9316,"elev":202,"name":"Chicago/O'Hare Intl, IL, US",
+		"clouds":[{"cover":"BKN","base":3500}]}]`
+
+	report, err := parseAWCJSON(input)
+	require.NoError(t, err)
+	assert.False(t, report.IsTAF())
+	assert.Equal(t, "KORD", report.IcaoID)
+
+	metar := report.METAR()
+	assert.Equal(t, "KORD", metar.Station)
+	assert.Equal(t, wx.SiteInfo{Name: "Chicago/O'Hare Intl", State: "IL", Country: "United States", Latitude: 41.9602, Longitude: -87.9316}, metar.SiteInfo)
+	assert.Equal(t, 18, *metar.Wind.Speed)
+	assert.Equal(t, "peak wind 270° at 30 knots at 15:32", metar.Remarks[1].Description)
+}
+
+func TestParseAWCJSON_taf(t *testing.T) {
+	t.Parallel()
+
+	input := `{"icaoId":"EGLL","rawTAF":"TAF EGLL 081100Z 0812/0918 24012KT 9999 SCT030","name":"London/Heathrow Intl, GB"}`
+
+	report, err := parseAWCJSON(input)
+	require.NoError(t, err)
+	assert.True(t, report.IsTAF())
+	assert.Equal(t, "TAF EGLL 081100Z 0812/0918 24012KT 9999 SCT030", report.RawReport())
+	assert.Equal(t, wx.SiteInfo{Name: "London/Heathrow Intl", Country: "United Kingdom"}, report.SiteInfo())
+}
+
+func TestParseAWCJSON_invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, input := range []string{"", "[]", "KORD 081551Z", `{"icaoId":"KORD"}`} {
+		_, err := parseAWCJSON(input)
+		assert.Error(t, err, input)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+)
+
+// reportColumn is a named column of tabular METAR output, with the function that
+// extracts its value from a decoded report
+type reportColumn struct {
+	Name  string
+	Value func(m wx.METAR) string
+}
+
+// reportColumns lists the columns available to -fields, in their default order
+var reportColumns = []reportColumn{
+	{Name: "station", Value: func(m wx.METAR) string { return m.Station }},
+	{Name: "time", Value: func(m wx.METAR) string { return formatColumnTime(m.Time) }},
+	{Name: "category", Value: func(m wx.METAR) string { return m.FlightCategory() }},
+	{Name: "wind", Value: formatWindColumn},
+	{Name: "wind_dir", Value: func(m wx.METAR) string { return m.Wind.Direction }},
+	{Name: "wind_speed", Value: func(m wx.METAR) string { return formatIntColumn(m.Wind.Speed) }},
+	{Name: "wind_gust", Value: func(m wx.METAR) string {
+		if m.Wind.Gust == 0 {
+			return ""
+		}
+		return strconv.Itoa(m.Wind.Gust)
+	}},
+	{Name: "visibility_sm", Value: func(m wx.METAR) string {
+		if m.Visibility.Unit == "" {
+			return ""
+		}
+		return strconv.FormatFloat(m.Visibility.StatuteMiles, 'f', -1, 64)
+	}},
+	{Name: "visibility_m", Value: func(m wx.METAR) string {
+		if m.Visibility.Unit == "" {
+			return ""
+		}
+		return strconv.Itoa(m.Visibility.Meters)
+	}},
+	{Name: "weather", Value: func(m wx.METAR) string { return strings.Join(m.Weather, " ") }},
+	{Name: "clouds", Value: formatCloudsColumn},
+	{Name: "ceiling", Value: func(m wx.METAR) string {
+		if ceiling, ok := m.Ceiling(); ok {
+			return strconv.Itoa(ceiling)
+		}
+		return ""
+	}},
+	{Name: "temp", Value: func(m wx.METAR) string { return formatIntColumn(m.Temperature) }},
+	{Name: "dewpoint", Value: func(m wx.METAR) string { return formatIntColumn(m.DewPoint) }},
+	{Name: "pressure_inhg", Value: func(m wx.METAR) string {
+		if m.Pressure == 0 {
+			return ""
+		}
+		if m.PressureUnit == "hPa" {
+			return fmt.Sprintf("%.2f", wx.MillibarsToInHg(m.Pressure))
+		}
+		return fmt.Sprintf("%.2f", m.Pressure)
+	}},
+	{Name: "pressure_hpa", Value: func(m wx.METAR) string {
+		if m.Pressure == 0 {
+			return ""
+		}
+		if m.PressureUnit == "hPa" {
+			return fmt.Sprintf("%.0f", m.Pressure)
+		}
+		return fmt.Sprintf("%.1f", wx.InHgToMillibars(m.Pressure))
+	}},
+	{Name: "raw", Value: func(m wx.METAR) string { return m.Raw }},
+}
+
+// columnNames returns the names of columns, in order
+func columnNames(columns []reportColumn) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+// parseColumns resolves a comma-separated list of column names such as
+// "station,category,wind,temp", keeping the given order. An empty list selects every column.
+func parseColumns(spec string) ([]reportColumn, error) {
+	if strings.TrimSpace(spec) == "" {
+		return reportColumns, nil
+	}
+
+	var columns []reportColumn
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		found := false
+		for _, column := range reportColumns {
+			if column.Name == name {
+				columns = append(columns, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown field %q (expected one of %s)", name, strings.Join(columnNames(reportColumns), ", "))
+		}
+	}
+	return columns, nil
+}
+
+// formatColumnTime formats an observation time for tabular output, or "" if it's unknown
+func formatColumnTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// formatIntColumn formats an optional value, leaving missing ones empty
+func formatIntColumn(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+// formatWindColumn formats the wind in its compact report form (e.g. "27010G20KT")
+func formatWindColumn(m wx.METAR) string {
+	if m.Wind.Speed == nil {
+		return ""
+	}
+	wind := fmt.Sprintf("%s%02d", m.Wind.Direction, *m.Wind.Speed)
+	if m.Wind.Gust > 0 {
+		wind += fmt.Sprintf("G%02d", m.Wind.Gust)
+	}
+	return wind + m.Wind.Unit
+}
+
+// formatCloudsColumn formats the cloud layers in their compact report form
+// (e.g. "FEW008 BKN035CB"), with any vertical visibility first
+func formatCloudsColumn(m wx.METAR) string {
+	var layers []string
+	if m.VertVis > 0 {
+		layers = append(layers, fmt.Sprintf("VV%03d", m.VertVis))
+	}
+	for _, cloud := range m.Clouds {
+		switch cloud.Coverage {
+		case "SKC", "CLR", "NSC", "NCD":
+			layers = append(layers, cloud.Coverage)
+		default:
+			layers = append(layers, fmt.Sprintf("%s%03d%s", cloud.Coverage, cloud.Height/100, cloud.Type))
+		}
+	}
+	return strings.Join(layers, " ")
+}
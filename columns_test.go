@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseColumns(t *testing.T) {
+	t.Parallel()
+
+	columns, err := parseColumns("station, Category,wind,temp")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"station", "category", "wind", "temp"}, columnNames(columns))
+
+	columns, err = parseColumns("")
+	require.NoError(t, err)
+	assert.Equal(t, columnNames(reportColumns), columnNames(columns))
+
+	_, err = parseColumns("station,humidty")
+	assert.ErrorContains(t, err, `unknown field "humidty"`)
+}
+
+func TestCSVOutput(t *testing.T) {
+	t.Parallel()
+
+	columns, err := parseColumns("station,time,category,wind,visibility_sm,clouds,ceiling,temp,dewpoint,pressure_hpa")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	out := newCSVOutput(&buf, columns)
+	require.NoError(t, out.addMETAR(wx.DecodeMETAR("KBOS 110054Z 12015G27KT 3SM -RA BR OVC007 08/07 A2978")))
+	require.NoError(t, out.addMETAR(wx.DecodeMETAR("EGLL 110050Z 24008KT 9999 FEW030 M01/M03 Q1022")))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 3)
+	assert.Equal(t, "station,time,category,wind,visibility_sm,clouds,ceiling,temp,dewpoint,pressure_hpa", string(lines[0]))
+	assert.Regexp(t, `^KBOS,\d{4}-\d{2}-11T00:54:00Z,IFR,12015G27KT,3,OVC007,700,8,7,1008\.5$`, string(lines[1]))
+	assert.Regexp(t, `^EGLL,\d{4}-\d{2}-11T00:50:00Z,VFR,24008KT,6\.2\d*,FEW030,,-1,-3,1022$`, string(lines[2]))
+}
@@ -2,17 +2,22 @@ package main
 
 import (
 	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// CelsiusToFahrenheit converts temperature from Celsius to Fahrenheit
-func CelsiusToFahrenheit(celsius int) int {
-	return (celsius * 9 / 5) + 32
-}
-
-// InHgToMillibars converts pressure from inches of mercury to millibars (hPa)
-func InHgToMillibars(inHg float64) float64 {
-	return inHg * 33.8639
+// approxTimezone returns a fixed-offset zone for a longitude, one hour per 15°.
+// It ignores political boundaries and daylight saving, so it is only an approximation.
+func approxTimezone(lon float64) *time.Location {
+	offset := int(math.Round(lon / 15))
+	name := "UTC"
+	if offset != 0 {
+		name = fmt.Sprintf("UTC%+d", offset)
+	}
+	return time.FixedZone(name, offset*3600)
 }
 
 // Calculate the relative time string
@@ -46,3 +51,21 @@ func relativeTimeString(t time.Time) string {
 		return fmt.Sprintf("(%d days, %d hours ago)", days, hours)
 	}
 }
+
+// runwayDesignatorRegex matches a runway designator such as "9", "27" or "09L"
+var runwayDesignatorRegex = regexp.MustCompile(`^(\d{1,2})[LCR]?$`)
+
+// runwayHeading converts a runway designator such as "27" or "09L" to its heading in
+// degrees (the runway number times ten)
+func runwayHeading(runway string) (int, error) {
+	matches := runwayDesignatorRegex.FindStringSubmatch(strings.ToUpper(runway))
+	if matches == nil {
+		return 0, fmt.Errorf("invalid runway %q (expected a runway number from 01 to 36, e.g. 27 or 09L)", runway)
+	}
+
+	number, _ := strconv.Atoi(matches[1])
+	if number < 1 || number > 36 {
+		return 0, fmt.Errorf("invalid runway %q (expected a runway number from 01 to 36, e.g. 27 or 09L)", runway)
+	}
+	return number * 10, nil
+}
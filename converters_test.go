@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApproxTimezone(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		lon        float64
+		wantName   string
+		wantOffset int
+	}{
+		{lon: -87.93, wantName: "UTC-6", wantOffset: -6 * 3600},
+		{lon: -73.78, wantName: "UTC-5", wantOffset: -5 * 3600},
+		{lon: -0.46, wantName: "UTC", wantOffset: 0},
+		{lon: 139.78, wantName: "UTC+9", wantOffset: 9 * 3600},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantName, func(t *testing.T) {
+			name, offset := time.Date(2025, 3, 8, 15, 51, 0, 0, time.UTC).In(approxTimezone(tt.lon)).Zone()
+			assert.Equal(t, tt.wantName, name)
+			assert.Equal(t, tt.wantOffset, offset)
+		})
+	}
+}
+
+func TestRunwayHeading(t *testing.T) {
+	t.Parallel()
+
+	for runway, want := range map[string]int{"27": 270, "09L": 90, "9": 90, "36r": 360, "01C": 10} {
+		heading, err := runwayHeading(runway)
+		assert.NoError(t, err, runway)
+		assert.Equal(t, want, heading, runway)
+	}
+
+	for _, runway := range []string{"", "00", "37", "27X", "ILS27"} {
+		_, err := runwayHeading(runway)
+		assert.Error(t, err, runway)
+	}
+}
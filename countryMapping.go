@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sync"
 )
 
 // CountryCode represents a mapping between country code and name
@@ -18,14 +19,20 @@ type CountryCode struct {
 var embeddedCountries embed.FS
 
 var countryCodeMap map[string]string
-var countryCodeMapInitialized bool = false
+var countryCodeMapOnce sync.Once
+var countryCodeMapErr error
 
-// InitCountryCodeMap initializes the country code to full name mapping
+// InitCountryCodeMap initializes the country code to full name mapping.
+// It is safe to call concurrently; the embedded file is only parsed once.
 func InitCountryCodeMap() error {
-	if countryCodeMapInitialized {
-		return nil // Already initialized
-	}
+	countryCodeMapOnce.Do(func() {
+		countryCodeMapErr = parseCountryCodeMap()
+	})
+	return countryCodeMapErr
+}
 
+// parseCountryCodeMap reads the embedded countries.json file into countryCodeMap
+func parseCountryCodeMap() error {
 	// Read the embedded countries.json file
 	fileContent, err := embeddedCountries.ReadFile("assets/countries.json")
 	if err != nil {
@@ -55,18 +62,15 @@ func InitCountryCodeMap() error {
 		log.Printf("WARNING: US country code not found in mapping")
 	}
 
-	countryCodeMapInitialized = true
 	return nil
 }
 
 // GetCountryName returns the full country name for a given country code
 func GetCountryName(code string) string {
 	// If the map isn't initialized yet, initialize it
-	if !countryCodeMapInitialized {
-		if err := InitCountryCodeMap(); err != nil {
-			log.Printf("Warning: Failed to initialize country code map: %v", err)
-			return code
-		}
+	if err := InitCountryCodeMap(); err != nil {
+		log.Printf("Warning: Failed to initialize country code map: %v", err)
+		return code
 	}
 
 	// Look up the country name in the map
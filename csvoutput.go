@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+)
+
+// csvOutput writes decoded METARs as CSV rows, one per report, under a header row of
+// the selected column names
+type csvOutput struct {
+	mu            sync.Mutex
+	w             *csv.Writer
+	columns       []reportColumn
+	headerWritten bool
+}
+
+// activeCSVOutput is the writer set up by -csv, or nil when reports are formatted as text
+var activeCSVOutput *csvOutput
+
+// newCSVOutput returns a CSV writer for the given columns
+func newCSVOutput(w io.Writer, columns []reportColumn) *csvOutput {
+	return &csvOutput{w: csv.NewWriter(w), columns: columns}
+}
+
+// addMETAR writes a row for a decoded METAR, preceded by the header row for the first one
+func (o *csvOutput) addMETAR(m wx.METAR) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if !o.headerWritten {
+		if err := o.w.Write(columnNames(o.columns)); err != nil {
+			return fmt.Errorf("error writing CSV output: %w", err)
+		}
+		o.headerWritten = true
+	}
+
+	row := make([]string, len(o.columns))
+	for i, column := range o.columns {
+		row[i] = column.Value(m)
+	}
+	if err := o.w.Write(row); err != nil {
+		return fmt.Errorf("error writing CSV output: %w", err)
+	}
+
+	// Flush each row so a long-running pipeline sees reports as they're decoded
+	o.w.Flush()
+	if err := o.w.Error(); err != nil {
+		return fmt.Errorf("error writing CSV output: %w", err)
+	}
+	return nil
+}
@@ -1,10 +1,22 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rmitchellscott/WxCraft/wx"
 )
 
 //go:embed assets/stations.json
@@ -26,42 +38,323 @@ type StationData struct {
 }
 
 // LoadEmbeddedStationInfo loads station information from the embedded stations.json file
-func LoadEmbeddedStationInfo(stationCode string) (SiteInfo, error) {
+func LoadEmbeddedStationInfo(stationCode string) (wx.SiteInfo, error) {
 	// Default site info in case of error
-	defaultSiteInfo := SiteInfo{
+	defaultSiteInfo := wx.SiteInfo{
 		Name:    stationCode,
 		State:   "",
 		Country: "",
 	}
 
+	station, err := findEmbeddedStation(stationCode)
+	if err != nil {
+		return defaultSiteInfo, err
+	}
+
+	countryCode := station.Country
+	countryName := GetCountryName(countryCode)
+
+	log.Printf("Country code for %s: %s -> %s", stationCode, countryCode, countryName)
+
+	return wx.SiteInfo{
+		Name:      station.Site,
+		State:     station.State,
+		Country:   countryName, // Use the full country name
+		Latitude:  station.Lat,
+		Longitude: station.Lon,
+	}, nil
+}
+
+// findEmbeddedStation looks up a station by its ICAO code in the station database
+func findEmbeddedStation(stationCode string) (StationData, error) {
+	// Load the station database, preferring an updated copy over the embedded one
+	stations, err := loadStationIndex()
+	if err != nil {
+		return StationData{}, err
+	}
+
+	if station, ok := stations[stationCode]; ok {
+		return station, nil
+	}
+
+	return StationData{}, fmt.Errorf("station %s not found in embedded database", stationCode)
+}
+
+// ResolveStation looks up the ICAO code for a 3-letter IATA code (e.g. JFK is KJFK) in the
+// station database. It reports false for other codes and IATA codes it doesn't know.
+func ResolveStation(code string) (string, bool) {
+	code = strings.ToUpper(code)
+	if len(code) != 3 {
+		return "", false
+	}
+	if _, err := loadStations(); err != nil {
+		return "", false
+	}
+
+	icao, ok := icaoByIATA[code]
+	return icao, ok
+}
+
+// stationsCacheURL is the AWC station list the embedded database is built from
+const stationsCacheURL = "https://aviationweather.gov/data/cache/stations.cache.json.gz"
+
+// cacheDirOverride is the directory set with -cache-dir, or "" for the default
+var cacheDirOverride string
+
+// cacheDir returns the directory downloaded data such as the updated station database is
+// stored in: -cache-dir, or wxcraft in the user config directory
+func cacheDir() (string, error) {
+	if cacheDirOverride != "" {
+		return cacheDirOverride, nil
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("error locating user config directory: %w", err)
+	}
+	return filepath.Join(configDir, "wxcraft"), nil
+}
+
+// updatedStationsPath returns where a refreshed copy of the station database is stored
+func updatedStationsPath() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "stations.json"), nil
+}
+
+// ClearCache deletes the cached files, such as the updated station database, so the
+// embedded copies are used again. Only files wxcraft writes are removed, since the cache
+// directory may be shared. It returns the paths it removed.
+func ClearCache() ([]string, error) {
+	path, err := updatedStationsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, p := range []string{path, path + ".tmp"} {
+		err := os.Remove(p)
+		switch {
+		case err == nil:
+			removed = append(removed, p)
+		case !errors.Is(err, fs.ErrNotExist):
+			return removed, fmt.Errorf("error clearing cache: %w", err)
+		}
+	}
+	return removed, nil
+}
+
+// Parsed station database, shared by all lookups in this process
+var (
+	stationsOnce   sync.Once
+	cachedStations []StationData
+	stationsByICAO map[string]StationData
+	icaoByIATA     map[string]string
+	stationsErr    error
+)
+
+// loadStations returns the station database, parsing it on first use.
+// Concurrent callers wait for the single parse in progress.
+func loadStations() ([]StationData, error) {
+	stationsOnce.Do(func() {
+		cachedStations, stationsErr = parseStations()
+		stationsByICAO = indexStations(cachedStations)
+		icaoByIATA = indexIATACodes(cachedStations)
+	})
+	return cachedStations, stationsErr
+}
+
+// loadStationIndex returns the station database keyed by ICAO code, parsing it on first use
+func loadStationIndex() (map[string]StationData, error) {
+	if _, err := loadStations(); err != nil {
+		return nil, err
+	}
+	return stationsByICAO, nil
+}
+
+// indexStations maps each station's ICAO code to the station, keeping the first entry
+// when a code is listed more than once. Stations without an ICAO code are left out.
+func indexStations(stations []StationData) map[string]StationData {
+	index := make(map[string]StationData, len(stations))
+	for _, station := range stations {
+		if _, ok := index[station.ICAOId]; station.ICAOId != "" && !ok {
+			index[station.ICAOId] = station
+		}
+	}
+	return index
+}
+
+// indexIATACodes maps each IATA code in the station database to the ICAO code of the first
+// station with it. Stations without both codes are left out.
+func indexIATACodes(stations []StationData) map[string]string {
+	index := make(map[string]string)
+	for _, station := range stations {
+		if len(station.IATAId) != 3 || len(station.ICAOId) != 4 {
+			continue
+		}
+		if _, ok := index[station.IATAId]; !ok {
+			index[station.IATAId] = station.ICAOId
+		}
+	}
+	return index
+}
+
+// prewarmStationData parses the station database and country names in the
+// background so the first offline lookup doesn't pay the parse cost. Lookups
+// that arrive while it is still running wait for it rather than parsing again,
+// and nothing waits for it on exit.
+func prewarmStationData() {
+	go func() {
+		_, _ = loadStations()
+		_ = InitCountryCodeMap()
+	}()
+}
+
+// parseStations parses the station database. An updated copy written by
+// UpdateStationDatabase takes precedence; the embedded file is used when
+// there is none or it can't be parsed.
+func parseStations() ([]StationData, error) {
+	var stations []StationData
+
+	if path, err := updatedStationsPath(); err == nil {
+		if fileContent, err := os.ReadFile(path); err == nil {
+			if err := json.Unmarshal(fileContent, &stations); err == nil && len(stations) > 0 {
+				return stations, nil
+			}
+			log.Printf("Ignoring unreadable station database at %s", path)
+		}
+	}
+
 	// Read the embedded stations.json file
 	fileContent, err := embeddedFiles.ReadFile("assets/stations.json")
 	if err != nil {
-		return defaultSiteInfo, fmt.Errorf("error reading embedded stations file: %w", err)
+		return nil, fmt.Errorf("error reading embedded stations file: %w", err)
 	}
 
 	// Parse the JSON
+	if err := json.Unmarshal(fileContent, &stations); err != nil {
+		return nil, fmt.Errorf("error parsing embedded stations file: %w", err)
+	}
+
+	return stations, nil
+}
+
+// UpdateStationDatabase downloads the current AWC station list and stores it in the
+// user config directory, where it takes precedence over the embedded database.
+// It returns the number of stations written and the path of the file.
+func UpdateStationDatabase() (int, string, error) {
+	resp, err := httpClient.Get(stationsCacheURL)
+	if err != nil {
+		return 0, "", fmt.Errorf("error fetching station list: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, "", fmt.Errorf("error reading response: %w", err)
+	}
+
+	// The cache file is gzipped, unless the transport already decompressed it
+	if bytes.HasPrefix(body, []byte{0x1f, 0x8b}) {
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return 0, "", fmt.Errorf("error decompressing station list: %w", err)
+		}
+		body, err = io.ReadAll(reader)
+		if err != nil {
+			return 0, "", fmt.Errorf("error decompressing station list: %w", err)
+		}
+	}
+
+	// Make sure the download parses before replacing anything
 	var stations []StationData
-	err = json.Unmarshal(fileContent, &stations)
+	if err := json.Unmarshal(body, &stations); err != nil {
+		return 0, "", fmt.Errorf("error parsing station list: %w", err)
+	}
+	if len(stations) == 0 {
+		return 0, "", fmt.Errorf("downloaded station list is empty")
+	}
+
+	path, err := updatedStationsPath()
 	if err != nil {
-		return defaultSiteInfo, fmt.Errorf("error parsing embedded stations file: %w", err)
+		return 0, "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, "", fmt.Errorf("error creating cache directory: %w", err)
 	}
 
-	// Look up the station by its ICAO code
-	for _, station := range stations {
-		if station.ICAOId == stationCode {
-			countryCode := station.Country
-			countryName := GetCountryName(countryCode)
+	// Write to a temporary file first so a failed write doesn't leave a truncated database
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, body, 0644); err != nil {
+		return 0, "", fmt.Errorf("error writing station database: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return 0, "", fmt.Errorf("error writing station database: %w", err)
+	}
+
+	return len(stations), path, nil
+}
 
-			log.Printf("Country code for %s: %s -> %s", stationCode, countryCode, countryName)
+// maxSuggestionDistance is how many single-character edits a mistyped ICAO code may be
+// from a known station for that station to be suggested
+const maxSuggestionDistance = 2
 
-			return SiteInfo{
-				Name:    station.Site,
-				State:   station.State,
-				Country: countryName, // Use the full country name
-			}, nil
+// suggestStation returns the station the user most likely meant when stationCode isn't in
+// the station database: the station whose IATA or FAA code matches exactly, or else the
+// ICAO code fewest edits away (at most maxSuggestionDistance), preferring higher priority
+// stations on a tie. It reports false when nothing is close enough.
+func suggestStation(stationCode string) (string, bool) {
+	stations, err := loadStations()
+	if err != nil || stationCode == "" {
+		return "", false
+	}
+
+	if len(stationCode) == 3 {
+		for _, station := range stations {
+			if station.ICAOId != "" && (station.IATAId == stationCode || station.FAAId == stationCode) {
+				return station.ICAOId, true
+			}
+		}
+	}
+
+	best, bestDistance, bestPriority := "", maxSuggestionDistance+1, 0
+	for _, station := range stations {
+		if station.ICAOId == "" {
+			continue
 		}
+		distance := levenshtein(stationCode, station.ICAOId)
+		if distance < bestDistance || (distance == bestDistance && station.Priority < bestPriority) {
+			best, bestDistance, bestPriority = station.ICAOId, distance, station.Priority
+		}
+	}
+	return best, best != ""
+}
+
+// levenshtein returns the number of single-character insertions, deletions and
+// substitutions needed to turn a into b
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
 	}
 
-	return defaultSiteInfo, fmt.Errorf("station %s not found in embedded database", stationCode)
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
 }
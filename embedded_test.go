@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadStations_concurrent(t *testing.T) {
+	// Parse the embedded database rather than one refreshed on this machine
+	defer func(saved string) { cacheDirOverride = saved }(cacheDirOverride)
+	cacheDirOverride = t.TempDir()
+	stationsOnce = sync.Once{}
+
+	prewarmStationData()
+
+	var wg sync.WaitGroup
+	results := make([][]StationData, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			stations, err := loadStations()
+			assert.NoError(t, err)
+			results[i] = stations
+		}(i)
+	}
+	wg.Wait()
+
+	require.NotEmpty(t, results[0])
+	for _, stations := range results[1:] {
+		// Every caller shares the single parsed copy
+		assert.Same(t, &results[0][0], &stations[0])
+	}
+}
+
+func TestIndexStations(t *testing.T) {
+	t.Parallel()
+
+	index := indexStations([]StationData{
+		{ICAOId: "KJFK", Site: "New York/JFK"},
+		{ICAOId: "", Site: "No ICAO code"},
+		{ICAOId: "KJFK", Site: "Duplicate"},
+	})
+	assert.Len(t, index, 1)
+	assert.Equal(t, "New York/JFK", index["KJFK"].Site)
+}
+
+func TestFindEmbeddedStation(t *testing.T) {
+	station, err := findEmbeddedStation("KJFK")
+	require.NoError(t, err)
+	assert.Equal(t, "KJFK", station.ICAOId)
+
+	_, err = findEmbeddedStation("ZZZZ")
+	assert.EqualError(t, err, "station ZZZZ not found in embedded database")
+}
+
+func TestResolveStation(t *testing.T) {
+	icao, ok := ResolveStation("JFK")
+	assert.True(t, ok)
+	assert.Equal(t, "KJFK", icao)
+
+	icao, ok = ResolveStation("lhr")
+	assert.True(t, ok)
+	assert.Equal(t, "EGLL", icao)
+
+	// Unknown IATA codes and codes of other lengths aren't resolved
+	_, ok = ResolveStation("ZZQ")
+	assert.False(t, ok)
+	_, ok = ResolveStation("KJFK")
+	assert.False(t, ok)
+}
+
+func TestClearCache(t *testing.T) {
+	defer func(saved string) { cacheDirOverride = saved }(cacheDirOverride)
+	cacheDirOverride = t.TempDir()
+
+	path, err := updatedStationsPath()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(cacheDirOverride, "stations.json"), path)
+
+	other := filepath.Join(cacheDirOverride, "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("[]"), 0644))
+	require.NoError(t, os.WriteFile(other, []byte("keep"), 0644))
+
+	removed, err := ClearCache()
+	require.NoError(t, err)
+	assert.Equal(t, []string{path}, removed)
+	assert.NoFileExists(t, path)
+	assert.FileExists(t, other)
+
+	// Clearing an empty cache isn't an error
+	removed, err = ClearCache()
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+}
+
+func TestIndexIATACodes(t *testing.T) {
+	t.Parallel()
+
+	index := indexIATACodes([]StationData{
+		{ICAOId: "KJFK", IATAId: "JFK"},
+		{ICAOId: "KJFX", IATAId: "JFK"},
+		{ICAOId: "41001", IATAId: "BUO"},
+		{ICAOId: "KLGA", IATAId: "-"},
+	})
+	assert.Equal(t, map[string]string{"JFK": "KJFK"}, index)
+}
+
+// BenchmarkParseStations measures the parse each lookup paid before the database was
+// cached, for comparison with BenchmarkFindEmbeddedStation
+func BenchmarkParseStations(b *testing.B) {
+	for b.Loop() {
+		if _, err := parseStations(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFindEmbeddedStation(b *testing.B) {
+	if _, err := loadStations(); err != nil {
+		b.Fatal(err)
+	}
+	for b.Loop() {
+		if _, err := findEmbeddedStation("KJFK"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
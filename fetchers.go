@@ -1,24 +1,73 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+)
+
+// httpClient makes every request to the weather, station and geolocation services, so
+// they share connections and a timeout (set by -timeout)
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Retry settings for requests to aviationweather.gov, which occasionally answers with a
+// server error or times out. Tests set them to zero.
+var (
+	fetchMaxRetries = 3                      // Retries after the first attempt
+	fetchRetryDelay = 250 * time.Millisecond // Wait before the first retry, doubled for each one after
 )
 
+// getWithRetry GETs url with client, retrying network errors and 5xx responses with
+// exponential backoff. Any other response, such as a 404, is returned straight away.
+// Cancelling ctx abandons the request and any retries still to come.
+func getWithRetry(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	delay := fetchRetryDelay
+	for attempt := 0; ; attempt++ {
+		resp, err := client.Do(req)
+		if (err == nil && resp.StatusCode < http.StatusInternalServerError) || attempt >= fetchMaxRetries {
+			return resp, err
+		}
+		if err == nil {
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
 // fetchData fetches data from a URL for a given station code
-func fetchData(urlTemplate string, stationCode string, dataType string) (string, error) {
+func fetchData(ctx context.Context, urlTemplate string, stationCode string, dataType string) (string, error) {
 	url := fmt.Sprintf(urlTemplate, stationCode)
 
-	resp, err := http.Get(url)
+	resp, err := getWithRetry(ctx, httpClient, url)
 	if err != nil {
 		return "", fmt.Errorf("error fetching %s: %w", dataType, err)
 	}
 	defer resp.Body.Close()
 
+	// The API answers 204 No Content for a station without a report
+	if resp.StatusCode == http.StatusNoContent {
+		return "", noDataError{dataType: dataType, stationCode: stationCode}
+	}
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
@@ -30,26 +79,91 @@ func fetchData(urlTemplate string, stationCode string, dataType string) (string,
 
 	data := strings.TrimSpace(string(body))
 	if data == "" {
-		return "", fmt.Errorf("no %s data found for station %s", dataType, stationCode)
+		return "", noDataError{dataType: dataType, stationCode: stationCode}
 	}
 
 	return data, nil
 }
 
+// noDataError is returned when aviationweather.gov has no report of the type for a
+// station, as opposed to failing to answer
+type noDataError struct {
+	dataType    string
+	stationCode string
+}
+
+func (e noDataError) Error() string {
+	return fmt.Sprintf("no %s data found for station %s", e.dataType, e.stationCode)
+}
+
+// URL templates for the METAR and TAF endpoints, which take one station code or several
+// separated by commas
+const (
+	metarURLTemplate = "https://aviationweather.gov/api/data/metar?ids=%s"
+	tafURLTemplate   = "https://aviationweather.gov/api/data/taf?ids=%s"
+)
+
 // FetchMETAR fetches the raw METAR for a given station code
 func FetchMETAR(stationCode string) (string, error) {
-	return fetchData("https://aviationweather.gov/api/data/metar?ids=%s", stationCode, "METAR")
+	return FetchMETARContext(context.Background(), stationCode)
+}
+
+// FetchMETARContext is FetchMETAR with a context for cancellation and deadlines
+func FetchMETARContext(ctx context.Context, stationCode string) (string, error) {
+	return fetchData(ctx, metarURLTemplate, stationCode, "METAR")
 }
 
 // FetchTAF fetches the raw TAF for a given station code
 func FetchTAF(stationCode string) (string, error) {
-	return fetchData("https://aviationweather.gov/api/data/taf?ids=%s", stationCode, "TAF")
+	return FetchTAFContext(context.Background(), stationCode)
+}
+
+// FetchTAFContext is FetchTAF with a context for cancellation and deadlines
+func FetchTAFContext(ctx context.Context, stationCode string) (string, error) {
+	return fetchData(ctx, tafURLTemplate, stationCode, "TAF")
+}
+
+// FetchMETARs fetches the raw METARs for several stations in one request, keyed by station
+// code. Stations without a METAR are left out.
+func FetchMETARs(stationCodes []string) (map[string]string, error) {
+	return FetchMETARsContext(context.Background(), stationCodes)
+}
+
+// FetchMETARsContext is FetchMETARs with a context for cancellation and deadlines
+func FetchMETARsContext(ctx context.Context, stationCodes []string) (map[string]string, error) {
+	return fetchStationReports(ctx, metarURLTemplate, stationCodes, "METAR")
+}
+
+// FetchTAFs fetches the raw TAFs for several stations in one request, keyed by station
+// code. Stations without a TAF are left out.
+func FetchTAFs(stationCodes []string) (map[string]string, error) {
+	return FetchTAFsContext(context.Background(), stationCodes)
+}
+
+// FetchTAFsContext is FetchTAFs with a context for cancellation and deadlines
+func FetchTAFsContext(ctx context.Context, stationCodes []string) (map[string]string, error) {
+	return fetchStationReports(ctx, tafURLTemplate, stationCodes, "TAF")
+}
+
+// fetchStationReports fetches the reports for several stations in one request and splits
+// the response by station
+func fetchStationReports(ctx context.Context, urlTemplate string, stationCodes []string, dataType string) (map[string]string, error) {
+	data, err := fetchData(ctx, urlTemplate, strings.Join(stationCodes, ","), dataType)
+	if err != nil {
+		return nil, err
+	}
+	return splitReportsByStation(data), nil
 }
 
 // FetchSiteInfo fetches site information for a station from the Aviation Weather API
-func FetchSiteInfo(stationCode string) (SiteInfo, error) {
+func FetchSiteInfo(stationCode string) (wx.SiteInfo, error) {
+	return FetchSiteInfoContext(context.Background(), stationCode)
+}
+
+// FetchSiteInfoContext is FetchSiteInfo with a context for cancellation and deadlines
+func FetchSiteInfoContext(ctx context.Context, stationCode string) (wx.SiteInfo, error) {
 	// Default site info in case of error
-	defaultSiteInfo := SiteInfo{
+	defaultSiteInfo := wx.SiteInfo{
 		Name:    stationCode,
 		State:   "",
 		Country: "",
@@ -58,13 +172,8 @@ func FetchSiteInfo(stationCode string) (SiteInfo, error) {
 	// API endpoint for station information
 	url := fmt.Sprintf("https://aviationweather.gov/api/data/stationinfo?ids=%s", stationCode)
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
 	// Make the request
-	resp, err := client.Get(url)
+	resp, err := getWithRetry(ctx, httpClient, url)
 	if err != nil {
 		return defaultSiteInfo, fmt.Errorf("error fetching site data: %w", err)
 	}
@@ -117,9 +226,59 @@ func FetchSiteInfo(stationCode string) (SiteInfo, error) {
 		return defaultSiteInfo, fmt.Errorf("could not extract site name from response")
 	}
 
-	return SiteInfo{
+	return wx.SiteInfo{
 		Name:    siteName,
 		State:   state,
 		Country: country,
 	}, nil
 }
+
+// siteInfoLookup resolves station site information concurrently with the weather fetch
+type siteInfoLookup struct {
+	stationCode string
+	wait        time.Duration // How long to wait once the report is ready
+	done        chan struct{}
+	info        wx.SiteInfo
+	err         error
+	warnOnce    sync.Once
+}
+
+// startSiteInfoLookup starts resolving site information for a station in the background
+func startSiteInfoLookup(stationCode string, wait time.Duration, resolve func(string) (wx.SiteInfo, error)) *siteInfoLookup {
+	lookup := &siteInfoLookup{
+		stationCode: stationCode,
+		wait:        wait,
+		done:        make(chan struct{}),
+	}
+
+	go func() {
+		defer close(lookup.done)
+		lookup.info, lookup.err = resolve(stationCode)
+	}()
+
+	return lookup
+}
+
+// get returns the resolved site information, waiting up to the lookup's wait duration.
+// If the lookup is still pending, only the station code is returned so the report
+// can be shown right away; a later call picks up the result once it arrives.
+// A failed lookup is reported once, to w.
+func (l *siteInfoLookup) get(w io.Writer) wx.SiteInfo {
+	if l == nil {
+		return wx.SiteInfo{}
+	}
+
+	select {
+	case <-l.done:
+	case <-time.After(l.wait):
+		return wx.SiteInfo{Name: l.stationCode}
+	}
+
+	if l.err != nil {
+		l.warnOnce.Do(func() {
+			fmt.Fprintf(w, "Warning: Could not fetch site info for %s: %v\n", l.stationCode, l.err)
+		})
+	}
+
+	return l.info
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withoutRetryDelay disables the backoff between retries for the rest of the test
+func withoutRetryDelay(t *testing.T) {
+	saved := fetchRetryDelay
+	fetchRetryDelay = 0
+	t.Cleanup(func() { fetchRetryDelay = saved })
+}
+
+func TestFetchData_retriesServerErrors(t *testing.T) {
+	withoutRetryDelay(t)
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, "%s 081551Z 27010KT 10SM SKC 21/09 A3012\n", r.URL.Query().Get("ids"))
+	}))
+	defer server.Close()
+
+	data, err := fetchData(context.Background(), server.URL+"?ids=%s", "KORD", "METAR")
+	require.NoError(t, err)
+	assert.Equal(t, "KORD 081551Z 27010KT 10SM SKC 21/09 A3012", data)
+	assert.EqualValues(t, 3, requests.Load())
+}
+
+func TestFetchData_givesUpAfterMaxRetries(t *testing.T) {
+	withoutRetryDelay(t)
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := fetchData(context.Background(), server.URL+"?ids=%s", "KORD", "METAR")
+	assert.EqualError(t, err, "unexpected status code: 502")
+	assert.EqualValues(t, fetchMaxRetries+1, requests.Load())
+}
+
+func TestFetchData_doesNotRetryClientErrorsOrEmptyBodies(t *testing.T) {
+	withoutRetryDelay(t)
+
+	for _, status := range []int{http.StatusNotFound, http.StatusOK} {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.WriteHeader(status)
+		}))
+
+		_, err := fetchData(context.Background(), server.URL+"?ids=%s", "KORD", "METAR")
+		assert.Error(t, err)
+		assert.EqualValues(t, 1, requests.Load(), "status %d", status)
+		server.Close()
+	}
+}
+
+func TestFetchData_noData(t *testing.T) {
+	withoutRetryDelay(t)
+
+	for _, status := range []int{http.StatusNoContent, http.StatusOK} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+
+		_, err := fetchData(context.Background(), server.URL+"?ids=%s", "KORD", "METAR")
+		var noData noDataError
+		assert.ErrorAs(t, err, &noData, "status %d", status)
+		assert.EqualError(t, err, "no METAR data found for station KORD")
+		server.Close()
+	}
+}
+
+func TestFetchData_cancelled(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := fetchData(ctx, server.URL+"?ids=%s", "KORD", "METAR")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestFetchData_timeout(t *testing.T) {
+	withoutRetryDelay(t)
+	savedRetries, savedTimeout := fetchMaxRetries, httpClient.Timeout
+	fetchMaxRetries, httpClient.Timeout = 0, 50*time.Millisecond
+	t.Cleanup(func() { fetchMaxRetries, httpClient.Timeout = savedRetries, savedTimeout })
+
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	start := time.Now()
+	_, err := fetchData(context.Background(), server.URL+"?ids=%s", "KORD", "METAR")
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "Client.Timeout exceeded")
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestFetchStationReports(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "KJFK,KBOS,KLGA", r.URL.Query().Get("ids"))
+		// KLGA has no report
+		fmt.Fprint(w, "KJFK 081551Z 27010KT 10SM FEW250 21/09 A3012\nKBOS 081554Z 25008KT 10SM SCT250 18/07 A3015\n")
+	}))
+	defer server.Close()
+
+	reports, err := fetchStationReports(context.Background(), server.URL+"?ids=%s", []string{"KJFK", "KBOS", "KLGA"}, "METAR")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"KJFK": "KJFK 081551Z 27010KT 10SM FEW250 21/09 A3012",
+		"KBOS": "KBOS 081554Z 25008KT 10SM SCT250 18/07 A3015",
+	}, reports)
+}
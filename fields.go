@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+)
+
+// FieldInfo describes a field path available on a decoded report
+type FieldInfo struct {
+	Path string // Dotted path to the field (e.g. "Wind.Speed", "Clouds[].Height")
+	Type string // Go type of the field
+}
+
+// listFields returns the field paths of a struct type. Embedded structs are
+// flattened, nested structs are expanded with dotted paths and slices of
+// structs are expanded with a "[]" suffix.
+func listFields(t reflect.Type, prefix string) []FieldInfo {
+	var fields []FieldInfo
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		// Embedded structs contribute their fields directly
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, listFields(field.Type, prefix)...)
+			continue
+		}
+
+		path := prefix + field.Name
+		fields = append(fields, FieldInfo{Path: path, Type: field.Type.String()})
+
+		switch {
+		case isExpandableStruct(field.Type):
+			fields = append(fields, listFields(field.Type, path+".")...)
+		case field.Type.Kind() == reflect.Slice && isExpandableStruct(field.Type.Elem()):
+			fields = append(fields, listFields(field.Type.Elem(), path+"[].")...)
+		}
+	}
+
+	return fields
+}
+
+// isExpandableStruct reports whether a type is a struct whose fields should be listed.
+// Times are treated as single values.
+func isExpandableStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{})
+}
+
+// printFieldList writes the available fields of the decoded METAR and TAF structs
+func printFieldList(w io.Writer) {
+	reports := []struct {
+		name string
+		typ  reflect.Type
+	}{
+		{"METAR", reflect.TypeOf(wx.METAR{})},
+		{"TAF", reflect.TypeOf(wx.TAF{})},
+	}
+
+	for i, report := range reports {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		sectionColor.Fprintf(w, "%s fields:\n", report.name)
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, field := range listFields(report.typ, "") {
+			fmt.Fprintf(tw, "  %s\t%s\n", field.Path, field.Type)
+		}
+		tw.Flush()
+	}
+}
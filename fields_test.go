@@ -0,0 +1,33 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListFields(t *testing.T) {
+	t.Parallel()
+
+	fields := listFields(reflect.TypeOf(wx.METAR{}), "")
+
+	// Embedded WeatherData fields are flattened
+	assert.Contains(t, fields, FieldInfo{Path: "Station", Type: "string"})
+	assert.Contains(t, fields, FieldInfo{Path: "Time", Type: "time.Time"})
+
+	// Nested structs and slices of structs are expanded
+	assert.Contains(t, fields, FieldInfo{Path: "Wind.Speed", Type: "*int"})
+	assert.Contains(t, fields, FieldInfo{Path: "Clouds[].Height", Type: "int"})
+	assert.Contains(t, fields, FieldInfo{Path: "WindShear[].Wind.Gust", Type: "int"})
+
+	// Times are not expanded into their internals
+	for _, field := range fields {
+		assert.NotContains(t, field.Path, "Time.")
+	}
+
+	tafFields := listFields(reflect.TypeOf(wx.TAF{}), "")
+	assert.Contains(t, tafFields, FieldInfo{Path: "Forecasts[].Visibility", Type: "wx.Visibility"})
+	assert.Contains(t, tafFields, FieldInfo{Path: "Forecasts[].Visibility.Meters", Type: "int"})
+}
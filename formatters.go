@@ -2,12 +2,16 @@ package main
 
 import (
 	"fmt"
-	"sort"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/fatih/color"
+	"github.com/rmitchellscott/WxCraft/wx"
+	"k8s.io/utils/ptr"
 )
 
 // Color definitions using fatih/color
@@ -26,96 +30,248 @@ var (
 	expiredColor = color.New(color.FgRed)
 )
 
-// formatVisibility converts raw visibility string to human-readable format
-func formatVisibility(visibility string) string {
-	if visibility == "" {
-		return ""
+// DisplayOptions controls how decoded values are rendered
+type DisplayOptions struct {
+	PressureUnits     string // "inhg", "hpa", "mmhg", "kpa", "all", or "" for the reported unit plus inHg/hPa
+	ApproxLocalTime   bool   // Show times in the station's approximate local time derived from its longitude
+	VisFraction       string // How fractional statute miles are shown: "fraction" (or ""), "unicode" or "decimal"
+	Military          bool   // Show the NATO color state
+	ColorState        bool   // Show the NATO color state derived from cloud base and visibility, even when one is reported
+	Verbose           bool   // Show cross-checks between the report body and its remarks
+	ASCII             bool   // Replace degree signs, bullets and other non-ASCII glyphs with ASCII equivalents
+	DistanceUnits     string // Units for nearest-airport distances and the search radius: "mi" (or "") or "km"
+	Units             string // "aviation" (or "") for reported units with conversions, "metric" or "imperial"
+	NoForecastNumbers bool   // Leave out the TAF forecast period numbers ("1. ", "2. ", ...), labelling periods by type and time range alone
+	PreferRemarkTemp  bool   // Take the temperature and dew point from the precise T group in remarks over the body values
+	Runway            string // Runway designator (e.g. "27" or "09L") to show headwind and crosswind components for, or ""
+	SourceTimestamp   bool   // Show when the report was fetched, alongside its observation or issue time
+	WindArrow         bool   // Start wind lines with an arrow pointing where the wind is blowing toward
+	ExplainCategory   bool   // Show the flight category with the ceiling or visibility that decided it
+}
+
+// displayOptions holds the display settings chosen on the command line
+var displayOptions DisplayOptions
+
+// visFractionOptions lists the accepted values of DisplayOptions.VisFraction
+var visFractionOptions = []string{"fraction", "unicode", "decimal"}
+
+// distanceUnitOptions lists the accepted values of DisplayOptions.DistanceUnits
+var distanceUnitOptions = []string{"mi", "km"}
+
+// unitOptions lists the accepted values of DisplayOptions.Units
+var unitOptions = []string{"aviation", "metric", "imperial"}
+
+// pressureUnitOptions lists the accepted values of DisplayOptions.PressureUnits
+var pressureUnitOptions = []string{"inhg", "hpa", "mmhg", "kpa", "all"}
+
+// asciiReplacer maps the glyphs used in decoded output to ASCII equivalents
+var asciiReplacer = strings.NewReplacer(
+	"°C", " C",
+	"°F", " F",
+	"°", " deg",
+	"•", "*",
+	"½", "1/2",
+	"¼", "1/4",
+	"¾", "3/4",
+	"⅛", "1/8",
+	"⅜", "3/8",
+	"⅝", "5/8",
+	"⅞", "7/8",
+	"–", "-",
+	"—", "-",
+	"…", "...",
+	// Wind arrows become the compass point they point toward
+	"↑", "N",
+	"↗", "NE",
+	"→", "E",
+	"↘", "SE",
+	"↓", "S",
+	"↙", "SW",
+	"←", "W",
+	"↖", "NW",
+	"○", "o",
+)
+
+// toASCII replaces known glyphs with ASCII equivalents and any other non-ASCII
+// character (e.g. in station names) with '?'
+func toASCII(s string) string {
+	s = asciiReplacer.Replace(s)
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII {
+			return '?'
+		}
+		return r
+	}, s)
+}
+
+// formatFetchedAt renders the line giving when a report was fetched, to the second, or
+// notes that it wasn't fetched (e.g. it was piped in)
+func formatFetchedAt(fetchedAt time.Time) string {
+	var sb strings.Builder
+	labelColor.Fprint(&sb, "Fetched: ")
+	if fetchedAt.IsZero() {
+		sb.WriteString("Not fetched (supplied as input)\n")
+		return sb.String()
 	}
+	dateColor.Fprint(&sb, fetchedAt.UTC().Format("2006-01-02 15:04:05 UTC"))
+	sb.WriteString(" " + relativeTimeString(fetchedAt) + "\n")
+	return sb.String()
+}
 
-	// CAVOK (Ceiling And Visibility OK)
-	if visibility == "CAVOK" {
-		return "Greater than 10 km"
+// formatGroupHeading renders the heading above a group of reports, e.g. "=== IFR (2 reports) ==="
+func formatGroupHeading(group reportGroup) string {
+	noun := "reports"
+	if len(group.Reports) == 1 {
+		noun = "report"
 	}
+	return sectionColor.Sprintf("=== %s (%d %s) ===", group.Heading, len(group.Reports), noun) + "\n\n"
+}
 
-	// Decode common visibility formats
-	if visibility == "P6SM" {
-		return "Greater than 6 statute miles"
-	} else if strings.HasSuffix(visibility, "SM") {
-		// Check for fractions
-		if strings.Contains(visibility, "/") {
-			// Handle fractional values like "1/2SM"
-			return visibility[:len(visibility)-2] + " statute miles"
-		} else if strings.HasPrefix(visibility, "M") {
-			// M prefix means "less than"
-			value := visibility[1 : len(visibility)-2]
-			return "Less than " + value + " statute miles"
-		} else {
-			// Regular integer values like "1SM" or "6SM"
-			value := visibility[:len(visibility)-2]
-			return value + " statute miles"
+// finishOutput applies display options that act on the whole rendered report
+func finishOutput(s string) string {
+	if displayOptions.ASCII {
+		return toASCII(s)
+	}
+	return s
+}
+
+// formatVisibility converts a visibility value to a human-readable format
+func formatVisibility(vis wx.Visibility) string {
+	if vis.Raw == "" {
+		return ""
+	}
+
+	// CAVOK (Ceiling And Visibility OK)
+	if vis.Raw == "CAVOK" {
+		if displayOptions.Units == "imperial" {
+			return "Greater than 6 statute miles"
 		}
+		return "Greater than 10 km"
 	}
 
-	// Handle meter-based visibility
-	if strings.HasSuffix(visibility, "M") {
-		// Added M suffix to indicate meters
-		meters := visibility[:len(visibility)-1]
-		return meters + " meters"
+	// Convert to the other system for -units
+	switch {
+	case displayOptions.Units == "metric" && vis.Unit == "SM":
+		return formatVisibilityKilometers(vis)
+	case displayOptions.Units == "imperial" && vis.Unit == "M":
+		return formatVisibilityMiles(vis)
 	}
 
-	// Handle standard 4-digit meter visibility format (e.g. "5000" for 5000 meters)
-	if visRegexNum.MatchString(visibility) {
-		meters, _ := strconv.Atoi(visibility)
-		// Special case for visibility less than 50m reported as "0000"
-		if meters == 0 {
-			return "Less than 50 meters"
+	switch vis.Unit {
+	case "SM":
+		miles := formatStatuteMiles(vis.StatuteMiles, displayOptions.VisFraction)
+		switch {
+		case vis.MoreThan:
+			return "Greater than " + miles + " statute miles"
+		case vis.LessThan:
+			return "Less than " + miles + " statute miles"
+		default:
+			return miles + " statute miles"
 		}
-		// Special case for 9999 which means unlimited visibility
-		if meters == 9999 {
+
+	case "M":
+		where := visibilityDirection(vis)
+		switch {
+		case vis.LessThan:
+			// 0000 means less than 50 meters
+			return "Less than 50 meters" + where
+		case vis.Unlimited && where == "":
 			return "Unlimited visibility (greater than 10 kilometers)"
+		case vis.Unlimited:
+			return "Unlimited visibility" + where
+		default:
+			return wx.FormatNumberWithCommas(vis.Meters) + " meters" + where
 		}
-		return formatNumberWithCommas(meters) + " meters"
 	}
 
-	// Handle visibility with direction (e.g. "4000NE")
-	matches := visRegexDir.FindStringSubmatch(visibility)
-	if matches != nil {
-		meters := matches[1]
-		direction := matches[2]
+	// Not a recognized visibility format
+	return vis.Raw
+}
 
-		// Special case for 9999 which means unlimited visibility
-		if meters == "9999" {
-			return "Unlimited visibility in the " + direction + " direction"
-		}
+// visibilityDirection describes where a visibility in meters applies: a single direction
+// or all directions (NDV), or "" when it isn't qualified
+func visibilityDirection(vis wx.Visibility) string {
+	switch vis.Direction {
+	case "":
+		return ""
+	case "NDV":
+		return " in all directions"
+	default:
+		return " in the " + vis.Direction + " direction"
+	}
+}
 
-		return meters + " meters in the " + direction + " direction"
+// formatVisibilityKilometers renders a visibility reported in statute miles in kilometers
+func formatVisibilityKilometers(vis wx.Visibility) string {
+	km := wx.MilesToKM(vis.StatuteMiles)
+	kmStr := strconv.FormatFloat(math.Round(km*10)/10, 'f', -1, 64)
+	if km >= 10 {
+		kmStr = strconv.FormatFloat(math.Round(km), 'f', -1, 64)
 	}
-	// Handle visibility with NDV (No Directional Variation)
-	if ndvRegex.MatchString(visibility) {
-		matches := ndvRegex.FindStringSubmatch(visibility)
-		if len(matches) > 1 {
-			visValue := matches[1]
-			meters, _ := strconv.Atoi(visValue)
 
-			// Special case for 0000 - less than 50 meters
-			if meters == 0 {
-				return "Less than 50 meters in all directions"
-			}
+	switch {
+	case vis.MoreThan:
+		return "Greater than " + kmStr + " kilometers"
+	case vis.LessThan:
+		return "Less than " + kmStr + " kilometers"
+	default:
+		return kmStr + " kilometers"
+	}
+}
 
-			// Special case for 9999 - unlimited visibility
-			if meters == 9999 {
-				return "Unlimited visibility in all directions"
-			}
+// formatVisibilityMiles renders a visibility reported in meters in statute miles
+func formatVisibilityMiles(vis wx.Visibility) string {
+	where := visibilityDirection(vis)
+	miles := strconv.FormatFloat(math.Round(vis.StatuteMiles*10)/10, 'f', -1, 64)
+
+	switch {
+	case vis.LessThan:
+		// 0000 means less than 50 meters
+		return "Less than 0.03 statute miles" + where
+	case vis.Unlimited && where == "":
+		return "Unlimited visibility (greater than 6 statute miles)"
+	case vis.Unlimited:
+		return "Unlimited visibility" + where
+	default:
+		return miles + " statute miles" + where
+	}
+}
 
-			return fmt.Sprintf("%s meters in all directions",
-				formatNumberWithCommas(meters))
+// formatStatuteMiles renders a statute mile value in the given style: a whole number and
+// fraction ("1 1/2"), a fraction glyph ("1½") or a decimal ("1.5")
+func formatStatuteMiles(miles float64, style string) string {
+	whole := int(miles)
+	frac := miles - float64(whole)
+	if frac < 0.001 {
+		return strconv.Itoa(whole)
+	}
+	if style == "decimal" {
+		return strconv.FormatFloat(miles, 'f', -1, 64)
+	}
+
+	// Find the smallest denominator that represents the fraction exactly
+	for _, den := range []int{2, 4, 8, 16} {
+		num := frac * float64(den)
+		if math.Abs(num-math.Round(num)) < 0.001 {
+			fraction := fmt.Sprintf("%d/%d", int(math.Round(num)), den)
+			if glyph, ok := wx.UnicodeFractions[fraction]; ok && style == "unicode" {
+				if whole == 0 {
+					return glyph
+				}
+				return strconv.Itoa(whole) + glyph
+			}
+			if whole == 0 {
+				return fraction
+			}
+			return fmt.Sprintf("%d %s", whole, fraction)
 		}
 	}
-	return visibility
+
+	return strconv.FormatFloat(miles, 'f', -1, 64)
 }
 
 // formatWind converts a Wind struct to a human-readable string
-func formatWind(wind Wind) string {
+func formatWind(wind wx.Wind) string {
 	// if wind.Speed == 0 && wind.Direction == "" {
 	// 	return ""
 	// }
@@ -131,224 +287,304 @@ func formatWind(wind Wind) string {
 		windStr += fmt.Sprintf("From %s°", wind.Direction)
 	}
 
-	unitLabel := "knots"
-	if wind.Unit == "MPS" {
-		unitLabel = "meters per second"
-	}
-
 	if wind.Speed != nil && *wind.Speed > 0 {
-		windStr += fmt.Sprintf(" at %d %s", *wind.Speed, unitLabel)
+		windStr += " at " + formatWindSpeed(*wind.Speed, wind.Unit)
 		if wind.Gust > 0 {
-			windStr += fmt.Sprintf(", gusting to %d %s", wind.Gust, unitLabel)
+			windStr += ", gusting to " + formatWindSpeed(wind.Gust, wind.Unit)
 		}
 	} else {
-		windStr += fmt.Sprintf(" %d %s", *wind.Speed, unitLabel)
+		windStr += " " + formatWindSpeed(*wind.Speed, wind.Unit)
 		if wind.Gust > 0 {
-			windStr += fmt.Sprintf(", gusting to %d %s", wind.Gust, unitLabel)
+			windStr += ", gusting to " + formatWindSpeed(wind.Gust, wind.Unit)
 		}
 	}
 
 	return windStr
 }
 
-// formatClouds converts a slice of Cloud structs to a human-readable string
-func formatClouds(clouds []Cloud) string {
-	if len(clouds) == 0 {
-		return ""
-	}
+// windArrows point where the wind blows toward, clockwise from north in 45° steps
+var windArrows = []string{"↑", "↗", "→", "↘", "↓", "↙", "←", "↖"}
 
-	var cloudStrs []string
-	for _, cloud := range clouds {
-		coverStr := cloud.Coverage
-		if c, ok := cloudCoverage[cloud.Coverage]; ok {
-			coverStr = c
-		}
+// windArrow returns an arrow pointing where the wind is blowing toward (the opposite of the
+// direction it comes from), or a circle for variable or calm wind
+func windArrow(wind wx.Wind) string {
+	from, err := strconv.Atoi(wind.Direction)
+	if err != nil || wind.Speed == nil || *wind.Speed == 0 {
+		return "○"
+	}
+	toward := (from + 180) % 360
+	return windArrows[int(math.Round(float64(toward)/45))%len(windArrows)]
+}
 
-		cloudDesc := coverStr
-		if cloud.Height > 0 {
-			cloudDesc = fmt.Sprintf("%s at %s feet", coverStr, formatNumberWithCommas(cloud.Height))
-		}
+// formatWindLine renders the wind for a wind line, preceded by its arrow with -wind-arrow
+func formatWindLine(wind wx.Wind) string {
+	windStr := formatWind(wind)
+	if windStr != "" && displayOptions.WindArrow {
+		windStr = windArrow(wind) + " " + windStr
+	}
+	return windStr
+}
 
-		if cloud.Type != "" {
-			typeDesc := cloud.Type
-			if t, ok := cloudTypes[cloud.Type]; ok {
-				typeDesc = t
-			}
-			cloudDesc = fmt.Sprintf("%s (%s)", cloudDesc, typeDesc)
-		}
+// formatWindSpeed renders a wind speed reported in unit ("KT", "MPS" or "KMH"): as
+// reported by default, in kilometers per hour with -units metric or miles per hour with
+// -units imperial
+func formatWindSpeed(speed int, unit string) string {
+	labels := map[string]string{"KT": "knots", "MPS": "meters per second", "KMH": "kilometers per hour"}
+
+	knots := float64(speed)
+	switch unit {
+	case "MPS":
+		knots *= 1.94384
+	case "KMH":
+		knots /= 1.852
+	}
 
-		cloudStrs = append(cloudStrs, cloudDesc)
+	switch {
+	case displayOptions.Units == "metric" && unit != "KMH":
+		return fmt.Sprintf("%.0f %s", wx.KnotsToKmh(knots), labels["KMH"])
+	case displayOptions.Units == "imperial":
+		return fmt.Sprintf("%.0f miles per hour", wx.KnotsToMph(knots))
 	}
 
-	return strings.Join(cloudStrs, ", ")
+	label, ok := labels[unit]
+	if !ok {
+		label = labels["KT"]
+	}
+	return fmt.Sprintf("%d %s", speed, label)
 }
 
-// // formatWeather converts a slice of weather strings to a human-readable format
-// func formatWeather(weather []string) string {
-// 	if len(weather) == 0 {
-// 		return ""
-// 	}
-
-// 	var weatherStrs []string
-// 	for _, wx := range weather {
-// 		if desc, ok := weatherDescriptions[wx]; ok {
-// 			weatherStrs = append(weatherStrs, desc)
-// 		} else {
-// 			weatherStrs = append(weatherStrs, wx)
-// 		}
-// 	}
-
-//		return strings.Join(weatherStrs, ", ")
-//	}
-//
-// WeatherCode represents a weather code and its properties
-type WeatherCode struct {
-	Description string
-	Position    int
-}
-
-// formatWeather converts weather code strings into human-readable descriptions
-func formatWeather(weather []string) string {
-	if len(weather) == 0 {
-		return ""
+// formatHeight renders a height in feet, or in meters with -units metric
+func formatHeight(feet int) string {
+	if displayOptions.Units == "metric" {
+		return wx.FormatNumberWithCommas(int(math.Round(wx.FeetToMeters(float64(feet))))) + " meters"
 	}
+	return wx.FormatNumberWithCommas(feet) + " feet"
+}
 
-	var formattedWeather []string
+// formatTemperature renders a temperature in Celsius along with its Fahrenheit
+// equivalent, or in just one of them with -units metric or imperial
+func formatTemperature(celsius int) string {
+	switch displayOptions.Units {
+	case "metric":
+		return fmt.Sprintf("%d°C", celsius)
+	case "imperial":
+		return fmt.Sprintf("%d°F", wx.CelsiusToFahrenheit(celsius))
+	}
+	return fmt.Sprintf("%d°C | %d°F", celsius, wx.CelsiusToFahrenheit(celsius))
+}
 
-	for _, wxCode := range weather {
-		// Split the weather code by spaces to handle multiple elements
-		elements := strings.Fields(wxCode)
+// pressureDisplayUnits returns the pressure units to show: those chosen with
+// -pressure-units, or else hPa with -units metric and inHg with -units imperial
+func pressureDisplayUnits() string {
+	switch {
+	case displayOptions.PressureUnits != "":
+		return displayOptions.PressureUnits
+	case displayOptions.Units == "metric":
+		return "hpa"
+	case displayOptions.Units == "imperial":
+		return "inhg"
+	}
+	return ""
+}
 
-		if len(elements) == 1 {
-			// Single code with no spaces (like "VCHZ")
-			formattedWeather = append(formattedWeather, formatWeatherElement(wxCode))
-		} else {
-			// Multiple codes separated by spaces
-			var elementDescriptions []string
+// formatRunwayWind describes the headwind (or tailwind) and crosswind components of the
+// wind for a runway, e.g. "headwind 8 kt, crosswind 12 kt from the right, 18 kt in gusts".
+// It returns "" when the wind or runway is unknown.
+func formatRunwayWind(wind wx.Wind, runway string) string {
+	heading, err := runwayHeading(runway)
+	if err != nil || wind.Speed == nil {
+		return ""
+	}
+	if *wind.Speed == 0 {
+		return "calm"
+	}
+	if wind.Direction == "VRB" {
+		return "variable – cannot compute"
+	}
 
-			for _, element := range elements {
-				elementDescriptions = append(elementDescriptions, formatWeatherElement(element))
-			}
+	unitLabel := "kt"
+	switch wind.Unit {
+	case "MPS":
+		unitLabel = "m/s"
+	case "KMH":
+		unitLabel = "km/h"
+	}
 
-			formattedWeather = append(formattedWeather, strings.Join(elementDescriptions, ", "))
-		}
+	head, cross := wx.WindComponents(wind, heading)
+	along := "headwind"
+	if math.Round(head) < 0 {
+		along = "tailwind"
 	}
+	desc := fmt.Sprintf("%s %.0f %s, crosswind %s", along, math.Abs(head), unitLabel, formatCrosswind(cross, unitLabel))
 
-	return strings.Join(formattedWeather, ", ")
+	if wind.Gust > 0 {
+		gust := wind
+		gust.Speed = &wind.Gust
+		_, gustCross := wx.WindComponents(gust, heading)
+		desc += fmt.Sprintf(", %.0f %s in gusts", math.Abs(gustCross), unitLabel)
+	}
+	return desc
 }
 
-// formatWeatherElement handles a single weather element, with or without combined codes
-func formatWeatherElement(code string) string {
-	// First check if this is a simple code we already know
-	if wc, ok := weatherCodes[code]; ok {
-		return wc.Description
+// formatCrosswind describes a crosswind component and the side it comes from
+func formatCrosswind(cross float64, unitLabel string) string {
+	if math.Round(cross) == 0 {
+		return fmt.Sprintf("0 %s", unitLabel)
 	}
 
-	// If not a simple code, try to break it down into components
-	type ParsedPart struct {
-		Description string
-		Position    int
+	side := "right"
+	if cross < 0 {
+		side = "left"
 	}
+	return fmt.Sprintf("%.0f %s from the %s", math.Abs(cross), unitLabel, side)
+}
 
-	var parts []ParsedPart
-	remainingCode := code
+// formatRunwayState describes a runway state group, e.g. "Wet snow, 11–25% covered,
+// depth 5 mm, braking action medium", naming each part that isn't reported
+func formatRunwayState(state wx.RunwayState) string {
+	describe := func(value, format, label string) string {
+		if value == "" {
+			return label + " not reported"
+		}
+		return fmt.Sprintf(format, value)
+	}
 
-	// Process the code by looking for known two-letter and one-letter codes
-	for len(remainingCode) > 0 {
-		found := false
+	desc := strings.Join([]string{
+		describe(state.Deposit, "%s", "deposit"),
+		describe(state.Extent, "%s covered", "extent"),
+		describe(state.Depth, "depth %s", "depth"),
+		describe(state.Friction, "%s", "friction"),
+	}, ", ")
+	return strings.ToUpper(desc[:1]) + desc[1:]
+}
 
-		// Try to match 2-letter codes first
-		if len(remainingCode) >= 2 {
-			twoLetters := remainingCode[:2]
-			if wc, ok := weatherCodes[twoLetters]; ok {
-				found = true
-				parts = append(parts, ParsedPart{
-					Description: wc.Description,
-					Position:    wc.Position,
-				})
-				remainingCode = remainingCode[2:]
-				continue
-			}
-		}
+// formatWindVariation describes a wind direction variation (e.g., "360V040")
+func formatWindVariation(variation string) string {
+	// Split the variation at the 'V' character
+	parts := strings.Split(variation, "V")
+	if len(parts) == 2 {
+		return fmt.Sprintf(" (varying between %s° and %s°)", parts[0], parts[1])
+	}
+	// Fallback in case the format is unexpected
+	return " (varying between " + variation + ")"
+}
 
-		// If no 2-letter code matched, try 1-letter codes (like "+" or "-")
-		if len(remainingCode) >= 1 {
-			oneLetter := remainingCode[:1]
-			if wc, ok := weatherCodes[oneLetter]; ok {
-				found = true
-				parts = append(parts, ParsedPart{
-					Description: wc.Description,
-					Position:    wc.Position,
-				})
-				remainingCode = remainingCode[1:]
-				continue
-			}
-		}
+// withCloudLayerOpacity returns the clouds with the oktas of the matching cloud-layer remark
+// entries, pairing layers in order. The remark lists cloud layers (and sometimes obscuring
+// weather) bottom-up like the body, so the clouds are returned unchanged when the counts don't match.
+func withCloudLayerOpacity(clouds []wx.Cloud, layers []wx.CloudLayerRemark) []wx.Cloud {
+	if len(layers) == 0 {
+		return clouds
+	}
 
-		// If we didn't find a match, we can't completely parse this code
-		if !found {
-			// If we parsed at least part of the code, add the remaining as a main phenomenon
-			if len(parts) > 0 {
-				parts = append(parts, ParsedPart{
-					Description: remainingCode,
-					Position:    1, // Treat unparsed remainder as main phenomenon
-				})
-			} else {
-				// If we couldn't parse anything, return the original code
-				return code
-			}
-			break
+	var cloudLayers []wx.CloudLayerRemark
+	for _, layer := range layers {
+		if !layer.Obscuration {
+			cloudLayers = append(cloudLayers, layer)
 		}
 	}
 
-	// If we parsed the whole code but didn't find any main phenomenon (position 1),
-	// promote the first modifier to main phenomenon if available
-	hasMainPhenomenon := false
-	for _, part := range parts {
-		if part.Position == 1 {
-			hasMainPhenomenon = true
-			break
-		}
+	switch len(clouds) {
+	case len(layers):
+	case len(cloudLayers):
+		layers = cloudLayers
+	default:
+		return clouds
 	}
 
-	if !hasMainPhenomenon && len(parts) > 0 {
-		// Look for modifiers to promote
-		for i, part := range parts {
-			if part.Position == 2 { // Modifier
-				parts[i].Position = 1 // Promote to main phenomenon
-				hasMainPhenomenon = true
-				break
-			}
-		}
+	annotated := make([]wx.Cloud, len(clouds))
+	for i, cloud := range clouds {
+		cloud.Opacity = layers[i].Oktas
+		annotated[i] = cloud
+	}
+	return annotated
+}
 
-		// If still no main phenomenon, promote the last prefix
-		if !hasMainPhenomenon {
-			for i := len(parts) - 1; i >= 0; i-- {
-				if parts[i].Position == 0 { // Prefix
-					parts[i].Position = 1 // Promote to main phenomenon
+// withConvectiveCloudLocations returns the clouds with the CB and TCU layers annotated with
+// the location and movement from the first remark on that type of cloud (a CBMAM remark
+// counts as CB), e.g. "distant NW, moving E"
+func withConvectiveCloudLocations(clouds []wx.Cloud, remarks []wx.ConvectiveCloudRemark) []wx.Cloud {
+	if len(remarks) == 0 {
+		return clouds
+	}
+
+	annotated := make([]wx.Cloud, len(clouds))
+	for i, cloud := range clouds {
+		if cloud.Type != "" {
+			for _, remark := range remarks {
+				if strings.TrimSuffix(remark.Type, "MAM") == cloud.Type {
+					cloud.Location = formatConvectiveCloudLocation(remark)
 					break
 				}
 			}
 		}
+		annotated[i] = cloud
 	}
+	return annotated
+}
 
-	// Sort parts by position
-	sort.Slice(parts, func(i, j int) bool {
-		return parts[i].Position < parts[j].Position
-	})
+// formatConvectiveCloudLocation summarizes where a convective cloud remark places the
+// clouds, e.g. "distant NW, moving E" or "overhead, stationary"
+func formatConvectiveCloudLocation(remark wx.ConvectiveCloudRemark) string {
+	var where []string
+	if remark.Qualifier != "" {
+		where = append(where, wx.CloudRemarkQualifiers[remark.Qualifier])
+	}
+	if len(remark.Location) > 0 {
+		where = append(where, strings.Join(remark.Location, " and "))
+	}
 
-	// Build the final description
-	var descriptions []string
-	for _, part := range parts {
-		descriptions = append(descriptions, part.Description)
+	location := strings.Join(where, " ")
+	switch remark.Movement {
+	case "":
+		return location
+	case "STNRY":
+		return strings.TrimPrefix(location+", stationary", ", ")
+	default:
+		return strings.TrimPrefix(location+", moving "+remark.Movement, ", ")
 	}
+}
 
-	// If we couldn't parse anything meaningful, return the original code
-	if len(descriptions) == 0 {
-		return code
+// formatClouds converts a slice of Cloud structs to a human-readable string
+func formatClouds(clouds []wx.Cloud) string {
+	if len(clouds) == 0 {
+		return ""
+	}
+
+	var cloudStrs []string
+	for _, cloud := range clouds {
+		coverStr := cloud.Coverage
+		if c, ok := wx.CloudCoverage[cloud.Coverage]; ok {
+			coverStr = c
+		}
+
+		cloudDesc := coverStr
+		if cloud.Height > 0 {
+			cloudDesc = fmt.Sprintf("%s at %s", coverStr, formatHeight(cloud.Height))
+		}
+
+		var notes []string
+		if cloud.Type != "" {
+			typeDesc := cloud.Type
+			if t, ok := wx.CloudTypes[cloud.Type]; ok {
+				typeDesc = t
+			}
+			notes = append(notes, typeDesc)
+		}
+		if cloud.Opacity > 0 {
+			notes = append(notes, fmt.Sprintf("%d/8 opacity", cloud.Opacity))
+		}
+		if cloud.Location != "" {
+			notes = append(notes, cloud.Location)
+		}
+		if len(notes) > 0 {
+			cloudDesc = fmt.Sprintf("%s (%s)", cloudDesc, strings.Join(notes, ", "))
+		}
+
+		cloudStrs = append(cloudStrs, cloudDesc)
 	}
 
-	return strings.Join(descriptions, " ")
+	return strings.Join(cloudStrs, ", ")
 }
 
 // formatSpecialCodes converts special codes to human-readable format
@@ -359,7 +595,7 @@ func formatSpecialCodes(codes []string) string {
 
 	var descriptions []string
 	for _, code := range codes {
-		if desc, ok := specialConditions[code]; ok {
+		if desc, ok := wx.SpecialConditions[code]; ok {
 			descriptions = append(descriptions, desc)
 		} else {
 			descriptions = append(descriptions, code)
@@ -392,7 +628,7 @@ func getTafAgeColor(t time.Time) *color.Color {
 }
 
 // FormatMETAR formats a METAR struct for display with colors
-func FormatMETAR(m METAR) string {
+func FormatMETAR(m wx.METAR) string {
 	var sb strings.Builder
 
 	// Station
@@ -420,26 +656,37 @@ func FormatMETAR(m METAR) string {
 		sb.WriteString("\n")
 	}
 
+	// When the report was fetched
+	if displayOptions.SourceTimestamp {
+		sb.WriteString(formatFetchedAt(m.FetchedAt))
+	}
+
+	// Approximate station local time
+	if displayOptions.ApproxLocalTime {
+		sb.WriteString(formatApproxLocalTime(m.Time, m.SiteInfo))
+	}
+
 	// Wind
-	windStr := formatWind(m.Wind)
+	windStr := formatWindLine(m.Wind)
 	if windStr != "" {
 		labelColor.Fprint(&sb, "Wind: ")
 		sb.WriteString(windStr)
 
 		// Add wind variation if available
 		if m.WindVariation != "" {
-			// Split the variation at the 'V' character
-			parts := strings.Split(m.WindVariation, "V")
-			if len(parts) == 2 {
-				sb.WriteString(fmt.Sprintf(" (varying between %s° and %s°)", parts[0], parts[1]))
-			} else {
-				// Fallback in case the format is unexpected
-				sb.WriteString(" (varying between " + m.WindVariation + ")")
-			}
+			sb.WriteString(formatWindVariation(m.WindVariation))
 		}
 		sb.WriteString("\n")
 	}
 
+	// Headwind and crosswind for the runway chosen with -runway
+	if displayOptions.Runway != "" {
+		if components := formatRunwayWind(m.Wind, displayOptions.Runway); components != "" {
+			labelColor.Fprintf(&sb, "Runway %s: ", displayOptions.Runway)
+			sb.WriteString(components + "\n")
+		}
+	}
+
 	// Visibility
 	visibilityDesc := formatVisibility(m.Visibility)
 	if visibilityDesc != "" {
@@ -447,16 +694,26 @@ func FormatMETAR(m METAR) string {
 		sb.WriteString(visibilityDesc + "\n")
 	}
 
+	// Tower and surface visibility can be operationally limiting when they differ from prevailing
+	if m.TowerVisibility.Raw != "" && m.TowerVisibility.Raw != m.Visibility.Raw {
+		labelColor.Fprint(&sb, "Tower Visibility: ")
+		warningColor.Fprintln(&sb, formatVisibility(m.TowerVisibility))
+	}
+	if m.SurfaceVisibility.Raw != "" && m.SurfaceVisibility.Raw != m.Visibility.Raw {
+		labelColor.Fprint(&sb, "Surface Visibility: ")
+		warningColor.Fprintln(&sb, formatVisibility(m.SurfaceVisibility))
+	}
+
 	// Vertical visibility - show if available
 	if m.VertVis > 0 {
 		labelColor.Fprint(&sb, "Vertical Visibility: ")
-		sb.WriteString(fmt.Sprintf("%s feet\n", formatNumberWithCommas(m.VertVis*100)))
+		sb.WriteString(formatHeight(m.VertVis*100) + "\n")
 	}
 
 	// Check if we have only CLR clouds
 	hasClear := false
 	hasOnlyClear := true
-	var cloudsWithHeight []Cloud
+	var cloudsWithHeight []wx.Cloud
 
 	for _, cloud := range m.Clouds {
 		if cloud.Coverage == "CLR" || cloud.Coverage == "SKC" {
@@ -473,7 +730,7 @@ func FormatMETAR(m METAR) string {
 
 	// Weather
 	if len(m.Weather) > 0 {
-		weatherStr := formatWeather(m.Weather)
+		weatherStr := wx.FormatWeather(m.Weather)
 		labelColor.Fprint(&sb, "Weather: ")
 		sb.WriteString(capitalizeFirst(weatherStr) + "\n")
 	} else if hasClear {
@@ -486,7 +743,7 @@ func FormatMETAR(m METAR) string {
 	// Or if we have clouds with height information
 	if !hasOnlyClear || len(cloudsWithHeight) > 0 {
 		// Filter out CLR/SKC from display if we already showed it in Weather
-		var cloudsToDisplay []Cloud
+		var cloudsToDisplay []wx.Cloud
 		if hasClear && len(m.Weather) == 0 {
 			// We're showing "Clear" in Weather, so only show non-CLR/SKC clouds
 			for _, cloud := range m.Clouds {
@@ -498,6 +755,13 @@ func FormatMETAR(m METAR) string {
 			cloudsToDisplay = m.Clouds
 		}
 
+		// Annotate the layers with their oktas from a Canadian cloud-layer remark, and
+		// CB and TCU layers with where a remark places them
+		if displayOptions.Verbose {
+			cloudsToDisplay = withCloudLayerOpacity(cloudsToDisplay, m.CloudLayers)
+			cloudsToDisplay = withConvectiveCloudLocations(cloudsToDisplay, m.ConvectiveClouds)
+		}
+
 		if len(cloudsToDisplay) > 0 {
 			cloudStr := formatClouds(cloudsToDisplay)
 			labelColor.Fprint(&sb, "Clouds: ")
@@ -505,15 +769,36 @@ func FormatMETAR(m METAR) string {
 		}
 	}
 
+	// Ceiling: the lowest broken or overcast layer, or the vertical visibility
+	if ceiling, ok := m.Ceiling(); ok {
+		labelColor.Fprint(&sb, "Ceiling: ")
+		sb.WriteString(formatHeight(ceiling) + "\n")
+	}
+
+	// Flight category with its deciding factor
+	if displayOptions.ExplainCategory {
+		if category := formatFlightCategory(m); category != "" {
+			labelColor.Fprint(&sb, "Flight Category: ")
+			sb.WriteString(category + "\n")
+		}
+	}
+
+	// NATO color state, as reported or derived from the cloud base and visibility
+	if displayOptions.Military || displayOptions.ColorState {
+		if state := formatColorState(m, displayOptions.ColorState); state != "" {
+			labelColor.Fprint(&sb, "Color State: ")
+			sb.WriteString(state + "\n")
+		}
+	}
+
 	// Temperature with Fahrenheit conversion
 	if m.Temperature == nil {
 		// Case for missing temperature
 		labelColor.Fprint(&sb, "Temperature: ")
 		sb.WriteString("Not available\n")
 	} else {
-		tempF := CelsiusToFahrenheit(*m.Temperature)
 		labelColor.Fprint(&sb, "Temperature: ")
-		sb.WriteString(fmt.Sprintf("%d°C | %d°F\n", *m.Temperature, tempF))
+		sb.WriteString(formatTemperature(*m.Temperature) + "\n")
 	}
 
 	// Dew point with Fahrenheit conversion
@@ -522,27 +807,42 @@ func FormatMETAR(m METAR) string {
 		labelColor.Fprint(&sb, "Dew Point: ")
 		sb.WriteString("Not available\n")
 	} else {
-		dewPointF := CelsiusToFahrenheit(*m.DewPoint)
 		labelColor.Fprint(&sb, "Dew Point: ")
-		sb.WriteString(fmt.Sprintf("%d°C | %d°F\n", *m.DewPoint, dewPointF))
+		sb.WriteString(formatTemperature(*m.DewPoint) + "\n")
+	}
+
+	if m.Temperature != nil && m.DewPoint != nil {
+		labelColor.Fprint(&sb, "Humidity: ")
+		sb.WriteString(fmt.Sprintf("%d%%\n", wx.RelativeHumidity(*m.Temperature, *m.DewPoint)))
+	}
+
+	if apparent, ok := m.ApparentTemperature(); ok {
+		labelColor.Fprint(&sb, "Apparent temperature: ")
+		sb.WriteString(formatTemperature(int(math.Round(apparent))) + "\n")
 	}
 
 	// Pressure with conversion to opposite unit
 	if m.Pressure > 0 {
 		labelColor.Fprint(&sb, "Pressure: ")
-		if m.PressureUnit == "inHg" {
-			// Convert inHg to hPa/millibars
-			pressureHpa := InHgToMillibars(m.Pressure)
-			sb.WriteString(fmt.Sprintf("%.2f inHg | %.1f hPa\n", m.Pressure, pressureHpa))
-		} else if m.PressureUnit == "hPa" {
-			// Convert hPa/millibars to inHg
-			pressureInHg := m.Pressure / 33.8639
-			sb.WriteString(fmt.Sprintf("%.1f hPa | %.2f inHg\n", m.Pressure, pressureInHg))
+		sb.WriteString(formatPressure(m.Pressure, m.PressureUnit, pressureDisplayUnits()) + "\n")
+	}
+
+	// Cross-check an altimeter setting repeated in remarks against the reported pressure
+	if displayOptions.Verbose && m.RemarkPressure > 0 && m.Pressure > 0 {
+		labelColor.Fprint(&sb, "Remark Pressure: ")
+		sb.WriteString(formatPressure(m.RemarkPressure, m.RemarkPressureUnit, pressureDisplayUnits()))
+		if diff := m.PressureDiscrepancy(); diff != 0 {
+			warningColor.Fprintf(&sb, " (differs from reported pressure by %.2f inHg)", diff)
 		} else {
-			// If no unit is specified, default to inHg with hPa conversion
-			pressureHpa := InHgToMillibars(m.Pressure)
-			sb.WriteString(fmt.Sprintf("%.2f inHg | %.1f hPa\n", m.Pressure, pressureHpa))
+			sb.WriteString(" (matches reported pressure)")
 		}
+		sb.WriteString("\n")
+	}
+
+	// Pressure trend combined from the PRESFR/PRESRR and 3-hour pressure change remarks
+	if trend := pressureTrendSummary(m.Remarks); trend != "" {
+		labelColor.Fprint(&sb, "Pressure Trend: ")
+		sb.WriteString(capitalizeFirst(trend) + "\n")
 	}
 
 	// Wind Shear
@@ -570,11 +870,10 @@ func FormatMETAR(m METAR) string {
 					directionStr = fmt.Sprintf("From %s°", ws.Wind.Direction)
 				}
 
-				sb.WriteString(fmt.Sprintf("  At %d feet: %s at %d %s\n",
-					ws.Altitude*100,
+				sb.WriteString(fmt.Sprintf("  At %s: %s at %s\n",
+					formatHeight(ws.Altitude*100),
 					directionStr,
-					ws.Wind.Speed,
-					ws.Wind.Unit))
+					formatWindSpeed(ptr.Deref(ws.Wind.Speed, 0), ws.Wind.Unit)))
 			}
 		}
 	}
@@ -589,7 +888,18 @@ func FormatMETAR(m METAR) string {
 
 			// Handle cleared runways
 			if cond.Cleared {
-				sb.WriteString(fmt.Sprintf("Cleared of deposits %d minutes ago", cond.ClearedTime))
+				friction := "friction not reported"
+				if cond.State != nil && cond.State.Friction != "" {
+					friction = cond.State.Friction
+				}
+				sb.WriteString("Cleared of deposits, " + friction)
+				sb.WriteString("\n")
+				continue
+			}
+
+			// Handle runway state groups
+			if cond.State != nil {
+				sb.WriteString(formatRunwayState(*cond.State))
 				sb.WriteString("\n")
 				continue
 			}
@@ -606,6 +916,11 @@ func FormatMETAR(m METAR) string {
 
 				// Handle max value prefix (if any)
 				maxPrefix := ""
+				if cond.MaxPrefix == "M" {
+					maxPrefix = "less than "
+				} else if cond.MaxPrefix == "P" {
+					maxPrefix = "more than "
+				}
 
 				// Format unit
 				unit := "meters"
@@ -654,7 +969,7 @@ func FormatMETAR(m METAR) string {
 		sb.WriteString("\n")
 		sectionColor.Fprintln(&sb, "Runway Visual Range:")
 		for _, rvr := range m.RVR {
-			matches := rvrRegex.FindStringSubmatch(rvr)
+			matches := wx.RVRRegex.FindStringSubmatch(rvr)
 			if matches != nil {
 				runway := matches[1]
 				visibility := matches[2]
@@ -692,13 +1007,22 @@ func FormatMETAR(m METAR) string {
 		}
 	}
 
+	// Trend forecasts
+	if len(m.Trends) > 0 {
+		sb.WriteString("\n")
+		sectionColor.Fprintln(&sb, "Trend:")
+		for _, trend := range m.Trends {
+			sb.WriteString("  • " + formatTrend(trend) + "\n")
+		}
+	}
+
 	// Special codes
 	if len(m.SpecialCodes) > 0 {
 		sb.WriteString("\n")
 		sectionColor.Fprintln(&sb, "Special Conditions:")
 		for _, code := range m.SpecialCodes {
 			desc := code
-			if val, ok := specialConditions[code]; ok {
+			if val, ok := wx.SpecialConditions[code]; ok {
 				desc = val
 			}
 
@@ -708,22 +1032,181 @@ func FormatMETAR(m METAR) string {
 		}
 	}
 
+	// Maintenance and sensor status remarks are summed up in one advisory; the
+	// individual remarks are only listed with -verbose
+	remarks := m.Remarks
+	advisories := sensorStatusAdvisory(remarks)
+	if advisories != "" && !displayOptions.Verbose {
+		remarks = slices.DeleteFunc(slices.Clone(remarks), func(r wx.Remark) bool {
+			_, ok := wx.SensorStatusAdvisories[r.Raw]
+			return ok
+		})
+	}
+
+	// Likewise the remarks folded into the pressure trend aren't repeated unless -verbose
+	if pressureTrendSummary(m.Remarks) != "" && !displayOptions.Verbose {
+		remarks = slices.DeleteFunc(slices.Clone(remarks), isPressureTrendRemark)
+	}
+
 	// Remarks
-	if len(m.Remarks) > 0 {
+	if len(remarks) > 0 {
 		sb.WriteString("\n")
 		sectionColor.Fprintln(&sb, "Remarks:")
-		for _, remark := range m.Remarks {
+		for _, remark := range remarks {
 			sb.WriteString("  ")
 			remarkCodeColor.Fprint(&sb, remark.Raw+": ")
 			sb.WriteString(capitalizeFirst(remark.Description) + "\n")
 		}
 	}
 
-	return sb.String()
+	if advisories != "" {
+		sb.WriteString("\n")
+		warningColor.Fprint(&sb, "Advisory: ")
+		sb.WriteString(advisories + "\n")
+	}
+
+	return finishOutput(sb.String())
+}
+
+// formatPressure renders a pressure reported in unit ("inHg" or "hPa", inHg if empty)
+// in the requested display units. With no units requested the reported value is shown
+// alongside its inHg/hPa counterpart.
+func formatPressure(pressure float64, unit string, units string) string {
+	var inHg, hpa float64
+	if unit == "hPa" {
+		hpa = pressure
+		inHg = wx.MillibarsToInHg(pressure)
+	} else {
+		inHg = pressure
+		hpa = wx.InHgToMillibars(pressure)
+	}
+
+	inHgStr := fmt.Sprintf("%.2f inHg", inHg)
+	hpaStr := fmt.Sprintf("%.1f hPa", hpa)
+	mmHgStr := fmt.Sprintf("%.1f mmHg", wx.HpaToMmHg(hpa))
+	kpaStr := fmt.Sprintf("%.2f kPa", wx.HpaToKpa(hpa))
+
+	switch units {
+	case "inhg":
+		return inHgStr
+	case "hpa":
+		return hpaStr
+	case "mmhg":
+		return mmHgStr
+	case "kpa":
+		return kpaStr
+	case "all":
+		if unit == "hPa" {
+			return strings.Join([]string{hpaStr, inHgStr, mmHgStr, kpaStr}, " | ")
+		}
+		return strings.Join([]string{inHgStr, hpaStr, mmHgStr, kpaStr}, " | ")
+	default:
+		if unit == "hPa" {
+			return hpaStr + " | " + inHgStr
+		}
+		return inHgStr + " | " + hpaStr
+	}
+}
+
+// formatFlightCategory renders the flight category with the factor that decided it, e.g.
+// "MVFR (ceiling 2500 ft)", or returns "" when the category can't be determined
+func formatFlightCategory(m wx.METAR) string {
+	category, reason := m.FlightCategoryDetail()
+	if category == "" || reason == "" {
+		return category
+	}
+	return fmt.Sprintf("%s (%s)", category, reason)
+}
+
+// formatColorState describes the reported NATO color state, or the one derived from the
+// cloud base and visibility when none was reported or derived is set
+func formatColorState(m wx.METAR, derived bool) string {
+	state := m.ColorStateCode
+	if state == "" || derived {
+		state = m.DerivedColorState()
+	}
+	if state == "" {
+		return ""
+	}
+
+	desc := state + " (" + wx.DescribeColorState(state)
+	if minimums := wx.ColorStateMinimums(state); minimums != "" {
+		desc += ": " + minimums
+	}
+	desc += ")"
+	if state != m.ColorStateCode {
+		desc += ", derived from cloud base and visibility"
+		if m.ColorStateCode != "" {
+			desc += "; reported " + m.ColorStateCode
+		}
+	} else if m.RecentColorState != "" {
+		desc += ", recently " + m.RecentColorState
+	}
+	return desc
+}
+
+// sensorStatusAdvisory joins the maintenance and sensor status remarks into one advisory
+// (e.g. "equipment maintenance required; RVR not available"), or "" if there are none
+func sensorStatusAdvisory(remarks []wx.Remark) string {
+	var advisories []string
+	for _, remark := range remarks {
+		advisory, ok := wx.SensorStatusAdvisories[remark.Raw]
+		if !ok || slices.Contains(advisories, advisory) {
+			continue
+		}
+
+		// Maintenance comes first; it is usually why the sensors are out
+		if remark.Raw == "$" {
+			advisories = slices.Insert(advisories, 0, advisory)
+		} else {
+			advisories = append(advisories, advisory)
+		}
+	}
+	return strings.Join(advisories, "; ")
+}
+
+// pressureTrendSummary combines a rapid pressure change remark (PRESFR/PRESRR) with
+// the 3-hour pressure change group (3PPPP) into a single statement.
+// Returns an empty string unless both remarks are present.
+func pressureTrendSummary(remarks []wx.Remark) string {
+	var trend string
+	var change float64
+	hasChange := false
+
+	for _, remark := range remarks {
+		switch {
+		case remark.Raw == "PRESFR" || remark.Raw == "PRESRR":
+			trend = remark.Description
+		case isThreeHourPressureChange(remark):
+			press, _ := strconv.Atoi(remark.Raw[1:])
+			change = float64(press) / 10.0
+			hasChange = true
+		}
+	}
+
+	if trend == "" || !hasChange {
+		return ""
+	}
+
+	return fmt.Sprintf("%s (%.1f hPa in 3 hours)", trend, change)
+}
+
+// isThreeHourPressureChange reports whether a remark is a 3-hour pressure change group (3PPPP)
+func isThreeHourPressureChange(remark wx.Remark) bool {
+	if len(remark.Raw) != 5 || remark.Raw[0] != '3' {
+		return false
+	}
+	_, err := strconv.Atoi(remark.Raw[1:])
+	return err == nil
+}
+
+// isPressureTrendRemark reports whether a remark is one of those pressureTrendSummary combines
+func isPressureTrendRemark(remark wx.Remark) bool {
+	return remark.Raw == "PRESFR" || remark.Raw == "PRESRR" || isThreeHourPressureChange(remark)
 }
 
 // Helper function to format site information
-func formatSiteInfo(info SiteInfo) string {
+func formatSiteInfo(info wx.SiteInfo) string {
 	parts := []string{}
 
 	if info.Name != "" {
@@ -742,7 +1225,7 @@ func formatSiteInfo(info SiteInfo) string {
 }
 
 // FormatTAF formats a TAF struct for display with colors
-func FormatTAF(t TAF) string {
+func FormatTAF(t wx.TAF) string {
 	var sb strings.Builder
 
 	// Station
@@ -770,6 +1253,16 @@ func FormatTAF(t TAF) string {
 		sb.WriteString("\n")
 	}
 
+	// When the report was fetched
+	if displayOptions.SourceTimestamp {
+		sb.WriteString(formatFetchedAt(t.FetchedAt))
+	}
+
+	// Approximate station local time
+	if displayOptions.ApproxLocalTime {
+		sb.WriteString(formatApproxLocalTime(t.Time, t.SiteInfo))
+	}
+
 	// Valid period
 	if !t.ValidFrom.IsZero() && !t.ValidTo.IsZero() {
 		labelColor.Fprint(&sb, "Valid: ")
@@ -779,6 +1272,31 @@ func FormatTAF(t TAF) string {
 		sb.WriteString("\n")
 	}
 
+	// Amendments not scheduled (AMD NOT SKED), possibly only within a window
+	if t.AmendmentsNotScheduled {
+		labelColor.Fprint(&sb, "Amendments: ")
+		sb.WriteString("Not scheduled")
+		if !t.AmendmentsFrom.IsZero() {
+			sb.WriteString(" from ")
+			dateColor.Fprint(&sb, t.AmendmentsFrom.Format("2006-01-02 15:04 UTC"))
+		}
+		if !t.AmendmentsTo.IsZero() {
+			if t.AmendmentsFrom.IsZero() {
+				sb.WriteString(" until ")
+			} else {
+				sb.WriteString(" to ")
+			}
+			dateColor.Fprint(&sb, t.AmendmentsTo.Format("2006-01-02 15:04 UTC"))
+		}
+		sb.WriteString("\n")
+	}
+
+	// Strongest gust across all periods, including TEMPO and PROB groups
+	if gust, period := t.MaxGust(); gust > 0 {
+		labelColor.Fprint(&sb, "Peak gust in forecast: ")
+		sb.WriteString(fmt.Sprintf("%s (%s)\n", formatWindSpeed(period.Wind.Gust, period.Wind.Unit), formatPeriodLabel(period)))
+	}
+
 	// Forecast periods
 	sb.WriteString("\n")
 	sectionColor.Fprintln(&sb, "Forecast Periods:")
@@ -795,6 +1313,9 @@ func FormatTAF(t TAF) string {
 			periodType = "Temporary"
 		case forecast.Type == "BECMG":
 			periodType = "Becoming"
+		case strings.HasPrefix(forecast.Type, "PROB") && forecast.Probability == 0:
+			// The probability couldn't be read (e.g. PROBXX)
+			periodType = "Probability (unknown)"
 		case strings.HasPrefix(forecast.Type, "PROB"):
 			// Handle PROB forecasts with the probability value
 			periodType = fmt.Sprintf("%d%% Probability", forecast.Probability)
@@ -802,9 +1323,16 @@ func FormatTAF(t TAF) string {
 			periodType = forecast.Type
 		}
 
-		// Period header with number
+		// Note a probability that qualifies the conditions within a TEMPO or BECMG group
+		if forecast.Probability > 0 && !strings.HasPrefix(forecast.Type, "PROB") {
+			periodType += fmt.Sprintf(" (%d%% probability)", forecast.Probability)
+		}
+
+		// Period header, numbered unless -no-forecast-numbers leaves the type and time range to label it
 		sb.WriteString("\n")
-		numberColor.Fprintf(&sb, "%d. ", i+1)
+		if !displayOptions.NoForecastNumbers {
+			numberColor.Fprintf(&sb, "%d. ", i+1)
+		}
 		sb.WriteString(periodType)
 
 		// Time period
@@ -823,11 +1351,15 @@ func FormatTAF(t TAF) string {
 		sb.WriteString("\n")
 
 		// Wind
-		windStr := formatWind(forecast.Wind)
+		windStr := formatWindLine(forecast.Wind)
 		if windStr != "" {
 			sb.WriteString("   ")
 			labelColor.Fprint(&sb, "Wind: ")
-			sb.WriteString(windStr + "\n")
+			sb.WriteString(windStr)
+			if forecast.WindVariation != "" {
+				sb.WriteString(formatWindVariation(forecast.WindVariation))
+			}
+			sb.WriteString("\n")
 		}
 
 		// Visibility
@@ -842,11 +1374,11 @@ func FormatTAF(t TAF) string {
 		if forecast.VertVis > 0 {
 			sb.WriteString("   ")
 			labelColor.Fprint(&sb, "Vertical Visibility: ")
-			sb.WriteString(fmt.Sprintf("%s feet\n", formatNumberWithCommas(forecast.VertVis*100)))
+			sb.WriteString(formatHeight(forecast.VertVis*100) + "\n")
 		}
 
 		// Weather
-		weatherStr := formatWeather(forecast.Weather)
+		weatherStr := wx.FormatWeather(forecast.Weather)
 		if weatherStr != "" {
 			sb.WriteString("   ")
 			labelColor.Fprint(&sb, "Weather: ")
@@ -883,86 +1415,105 @@ func FormatTAF(t TAF) string {
 						directionStr = fmt.Sprintf("From %s°", ws.Wind.Direction)
 					}
 
-					sb.WriteString(fmt.Sprintf("At %d feet: %s at %d %s",
-						ws.Altitude*100,
+					sb.WriteString(fmt.Sprintf("At %s: %s at %s",
+						formatHeight(ws.Altitude*100),
 						directionStr,
-						ws.Wind.Speed,
-						ws.Wind.Unit))
+						formatWindSpeed(ptr.Deref(ws.Wind.Speed, 0), ws.Wind.Unit)))
 				}
 				sb.WriteString("\n")
 			}
 		}
 	}
 
-	return sb.String()
+	return finishOutput(sb.String())
 }
 
-// capitalizeFirst capitalizes the first letter of a string
-func capitalizeFirst(s string) string {
-	if s == "" {
+// formatApproxLocalTime renders a time in the station's approximate local time,
+// or nothing if the time or the station's longitude is unknown
+func formatApproxLocalTime(t time.Time, info wx.SiteInfo) string {
+	if t.IsZero() || !info.HasCoordinates() {
 		return ""
 	}
-	return strings.ToUpper(s[:1]) + s[1:]
-}
 
-// formatNumberWithCommas adds thousands separators to a number
-func formatNumberWithCommas(n int) string {
-	// Convert to string first
-	numStr := strconv.Itoa(n)
+	local := t.In(approxTimezone(info.Longitude))
+	zone, _ := local.Zone()
 
-	// Add commas for thousands
-	result := ""
-	for i, c := range numStr {
-		if i > 0 && (len(numStr)-i)%3 == 0 {
-			result += ","
-		}
-		result += string(c)
-	}
-
-	return result
+	var sb strings.Builder
+	labelColor.Fprint(&sb, "Local (approx): ")
+	dateColor.Fprint(&sb, local.Format("15:04"))
+	sb.WriteString(" (" + zone + ")\n")
+	return sb.String()
 }
 
-// FormatSiteInfo returns a formatted string with the site information
-func (m METAR) FormatSiteInfo() string {
-	parts := []string{}
-
-	if m.SiteInfo.Name != "" {
-		parts = append(parts, m.SiteInfo.Name)
+// formatTrend describes a METAR trend forecast on one line, e.g.
+// "Temporary from 11:00 UTC until 12:00 UTC: visibility 3,000 meters, rain, broken clouds at 800 feet"
+func formatTrend(f wx.Forecast) string {
+	var label string
+	switch f.Type {
+	case "NOSIG":
+		return "No significant change"
+	case "TEMPO":
+		label = "Temporary"
+	case "BECMG":
+		label = "Becoming"
+	case "INTER":
+		label = "Intermittent"
+	default:
+		label = f.Type
 	}
 
-	if m.SiteInfo.State != "" {
-		parts = append(parts, m.SiteInfo.State)
+	if !f.From.IsZero() {
+		label += " from " + f.From.Format("15:04 UTC")
 	}
-
-	if m.SiteInfo.Country != "" {
-		parts = append(parts, m.SiteInfo.Country)
+	if !f.To.IsZero() {
+		label += " until " + f.To.Format("15:04 UTC")
 	}
 
-	if len(parts) == 0 {
-		return m.Station // Fallback to station code if no site info
+	var conditions []string
+	if wind := formatWindLine(f.Wind); wind != "" {
+		conditions = append(conditions, "wind "+strings.ToLower(wind[:1])+wind[1:])
 	}
-
-	return strings.Join(parts, ", ")
-}
-
-func (t TAF) FormatSiteInfo() string {
-	parts := []string{}
-
-	if t.SiteInfo.Name != "" {
-		parts = append(parts, t.SiteInfo.Name)
+	if visibility := formatVisibility(f.Visibility); visibility != "" {
+		conditions = append(conditions, "visibility "+visibility)
+	}
+	if f.VertVis > 0 {
+		conditions = append(conditions, "vertical visibility "+formatHeight(f.VertVis*100))
+	}
+	if weather := wx.FormatWeather(f.Weather); weather != "" {
+		conditions = append(conditions, weather)
+	}
+	if clouds := formatClouds(f.Clouds); clouds != "" {
+		conditions = append(conditions, clouds)
 	}
 
-	if t.SiteInfo.State != "" {
-		parts = append(parts, t.SiteInfo.State)
+	if len(conditions) == 0 {
+		return label
 	}
+	return label + ": " + strings.Join(conditions, ", ")
+}
 
-	if t.SiteInfo.Country != "" {
-		parts = append(parts, t.SiteInfo.Country)
+// formatPeriodLabel gives a short label for a forecast period with its times in the TAF's
+// own DDHH/DDHH and DDHHMM forms, e.g. "TEMPO 0812/0814" or "FM 081800Z"
+func formatPeriodLabel(f wx.Forecast) string {
+	label := f.Type
+	if f.Probability > 0 && !strings.HasPrefix(f.Type, "PROB") {
+		label = fmt.Sprintf("PROB%d %s", f.Probability, f.Type)
 	}
 
-	if len(parts) == 0 {
-		return t.Station // Fallback to station code if no site info
+	switch {
+	case f.From.IsZero():
+		return label
+	case f.To.IsZero():
+		return label + " " + f.From.Format("021504") + "Z"
+	default:
+		return label + " " + f.From.Format("0215") + "/" + f.To.Format("0215")
 	}
+}
 
-	return strings.Join(parts, ", ")
+// capitalizeFirst capitalizes the first letter of a string
+func capitalizeFirst(s string) string {
+	if s == "" {
+		return ""
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
 }
@@ -0,0 +1,543 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+
+	"github.com/fatih/color"
+	"github.com/rmitchellscott/WxCraft/wx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	// Keep formatted output free of ANSI codes so it can be compared directly
+	color.NoColor = true
+	os.Exit(m.Run())
+}
+
+func TestFormatMETAR_pressureTrend(t *testing.T) {
+	t.Parallel()
+
+	metar := wx.DecodeMETAR("KORD 081551Z 27018G28KT 10SM BKN035 05/M03 A2962 RMK AO2 PK WND 27030/1532 PRESFR SLP034 30023 T00501028")
+	output := FormatMETAR(metar)
+
+	assert.Contains(t, output, "Pressure Trend: Pressure falling rapidly (2.3 hPa in 3 hours)\n")
+
+	// The combined line replaces the individual remarks
+	assert.Equal(t, 1, strings.Count(output, "falling rapidly"))
+	assert.NotContains(t, output, "PRESFR:")
+	assert.NotContains(t, output, "30023:")
+	assert.Contains(t, output, "SLP034:")
+}
+
+func TestFormatMETAR_pressureTrendVerbose(t *testing.T) {
+	defer func(saved DisplayOptions) { displayOptions = saved }(displayOptions)
+	displayOptions.Verbose = true
+
+	// The individual remarks stay available with -verbose
+	output := FormatMETAR(wx.DecodeMETAR("KORD 081551Z 27018G28KT 10SM BKN035 05/M03 A2962 RMK AO2 PK WND 27030/1532 PRESFR SLP034 30023 T00501028"))
+	assert.Contains(t, output, "Pressure Trend: Pressure falling rapidly (2.3 hPa in 3 hours)\n")
+	assert.Contains(t, output, "PRESFR: Pressure falling rapidly\n")
+	assert.Contains(t, output, "30023: 3-hour pressure change: 2.3 hPa\n")
+}
+
+func TestWindArrow(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"36010KT":  "↓",
+		"03012KT":  "↙",
+		"09015KT":  "←",
+		"27020KT":  "→",
+		"18005KT":  "↑",
+		"31015KT":  "↘",
+		"VRB03KT":  "○",
+		"00000KT":  "○",
+		"12008MPS": "↖",
+	}
+	for group, want := range tests {
+		assert.Equal(t, want, windArrow(wx.ParseWind(group)), group)
+	}
+}
+
+func TestFormatMETAR_windArrow(t *testing.T) {
+	defer func(saved DisplayOptions) { displayOptions = saved }(displayOptions)
+
+	metar := wx.DecodeMETAR("KORD 081551Z 03012KT 10SM FEW250 21/09 A3012")
+	taf := wx.DecodeTAF("TAF KORD 081720Z 0818/0924 27010KT P6SM SCT050")
+	assert.Contains(t, FormatMETAR(metar), "Wind: From 030°")
+
+	displayOptions.WindArrow = true
+	assert.Contains(t, FormatMETAR(metar), "Wind: ↙ From 030° at 12 knots\n")
+	assert.Contains(t, FormatTAF(taf), "Wind: → From 270° at 10 knots\n")
+
+	displayOptions.ASCII = true
+	assert.Contains(t, FormatMETAR(metar), "Wind: SW From 030 deg at 12 knots\n")
+	assert.Contains(t, FormatTAF(taf), "Wind: E From 270 deg at 10 knots\n")
+}
+
+func TestFormatMETAR_explainCategory(t *testing.T) {
+	defer func(saved DisplayOptions) { displayOptions = saved }(displayOptions)
+
+	metar := wx.DecodeMETAR("KORD 081551Z 27010KT 10SM BKN025 21/09 A3012")
+	assert.NotContains(t, FormatMETAR(metar), "Flight Category:")
+
+	displayOptions.ExplainCategory = true
+	assert.Contains(t, FormatMETAR(metar), "Flight Category: MVFR (ceiling 2500 ft)\n")
+	assert.Equal(t, "IFR (visibility 2 sm)", formatFlightCategory(wx.DecodeMETAR("KORD 081551Z 27010KT 2SM BR BKN040 21/09 A3012")))
+	assert.Equal(t, "", formatFlightCategory(wx.DecodeMETAR("KORD 081551Z 27010KT BKN030 21/09 A3012")))
+}
+
+func TestFormatMETAR_trends(t *testing.T) {
+	t.Parallel()
+
+	metar := wx.DecodeMETAR("EGLL 081550Z 24010KT 9999 FEW020 15/10 Q1013 TEMPO 3000 RA BKN008")
+	assert.Contains(t, FormatMETAR(metar), "Trend:\n  • Temporary: visibility 3,000 meters, rain, broken clouds at 800 feet\n")
+
+	metar = wx.DecodeMETAR("EGLL 081550Z 24010KT 9999 FEW020 15/10 Q1013 NOSIG")
+	assert.Contains(t, FormatMETAR(metar), "Trend:\n  • No significant change\n")
+}
+
+func TestFormatMETAR_sourceTimestamp(t *testing.T) {
+	defer func(saved DisplayOptions) { displayOptions = saved }(displayOptions)
+
+	metar := wx.DecodeMETAR("KORD 081551Z 27010KT 10SM FEW250 21/09 A3012")
+	metar.FetchedAt = time.Date(2025, 3, 8, 16, 2, 37, 0, time.UTC)
+	taf := wx.DecodeTAF("TAF KORD 081720Z 0818/0924 27010KT P6SM SCT050")
+
+	assert.NotContains(t, FormatMETAR(metar), "Fetched:")
+
+	displayOptions.SourceTimestamp = true
+	assert.Contains(t, FormatMETAR(metar), "Fetched: 2025-03-08 16:02:37 UTC (")
+	assert.Contains(t, FormatTAF(taf), "Fetched: Not fetched (supplied as input)\n")
+}
+
+func TestFormatMETAR_pressureTrendRequiresBothRemarks(t *testing.T) {
+	t.Parallel()
+
+	metar := wx.DecodeMETAR("KORD 081551Z 27018KT 10SM BKN035 05/M03 A2962 RMK AO2 PRESRR SLP034")
+	output := FormatMETAR(metar)
+	assert.NotContains(t, output, "Pressure Trend:")
+	assert.Contains(t, output, "PRESRR: Pressure rising rapidly\n")
+}
+
+func TestFormatMETAR_runwayVisualRangeMaxPrefix(t *testing.T) {
+	t.Parallel()
+
+	metar := wx.DecodeMETAR("KSEA 081553Z 18008KT 1/2SM R16L/6000VP6000FT/U FG OVC002 08/08 A3001")
+	assert.Contains(t, FormatMETAR(metar), "Runway 16L: Visibility between 6000 and more than 6000 feet (increasing)\n")
+}
+
+func TestFormatMETAR_runwayState(t *testing.T) {
+	t.Parallel()
+
+	metar := wx.DecodeMETAR("UUEE 081530Z 18004MPS 9999 -SN BKN010 M02/M04 Q1012 R06L/520593 R24/5/////")
+	output := FormatMETAR(metar)
+	assert.Contains(t, output, "Runway 06L: Wet snow, 11–25% covered, depth 5 mm, braking action medium\n")
+	assert.Contains(t, output, "Runway 24: Wet snow, extent not reported, depth not reported, friction not reported\n")
+
+	output = FormatMETAR(wx.DecodeMETAR("UUEE 081530Z 18004MPS 9999 BKN010 M02/M04 Q1012 R24/CLRD70 R88/CLRD//"))
+	assert.Contains(t, output, "Runway 24: Cleared of deposits, friction coefficient 0.70\n")
+	assert.Contains(t, output, "Runway 88: Cleared of deposits, friction not reported\n")
+}
+
+func TestFormatPressure(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pressure float64
+		unit     string
+		units    string
+		want     string
+	}{
+		{pressure: 29.92, unit: "inHg", units: "", want: "29.92 inHg | 1013.2 hPa"},
+		{pressure: 1013, unit: "hPa", units: "", want: "1013.0 hPa | 29.91 inHg"},
+		{pressure: 29.92, unit: "", units: "", want: "29.92 inHg | 1013.2 hPa"},
+		{pressure: 29.92, unit: "inHg", units: "hpa", want: "1013.2 hPa"},
+		{pressure: 1013, unit: "hPa", units: "inhg", want: "29.91 inHg"},
+		{pressure: 29.92, unit: "inHg", units: "mmhg", want: "760.0 mmHg"},
+		{pressure: 1013, unit: "hPa", units: "kpa", want: "101.30 kPa"},
+		{pressure: 1013, unit: "hPa", units: "all", want: "1013.0 hPa | 29.91 inHg | 759.8 mmHg | 101.30 kPa"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.unit+"/"+tt.units, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatPressure(tt.pressure, tt.unit, tt.units))
+		})
+	}
+}
+
+func TestFormatApproxLocalTime(t *testing.T) {
+	t.Parallel()
+
+	obs := time.Date(2025, 3, 8, 15, 51, 0, 0, time.UTC)
+	assert.Equal(t, "Local (approx): 09:51 (UTC-6)\n", formatApproxLocalTime(obs, wx.SiteInfo{Name: "KORD", Latitude: 41.96, Longitude: -87.93}))
+
+	// Nothing to show without coordinates or a time
+	assert.Empty(t, formatApproxLocalTime(obs, wx.SiteInfo{Name: "KORD"}))
+	assert.Empty(t, formatApproxLocalTime(time.Time{}, wx.SiteInfo{Name: "KORD", Longitude: -87.93}))
+}
+
+func TestFormatMETAR_towerSurfaceVisibility(t *testing.T) {
+	t.Parallel()
+
+	metar := wx.DecodeMETAR("KJFK 081551Z 27010KT 2SM BR OVC005 05/04 A2990 RMK AO2 TWR VIS 1 1/2 SFC VIS 2")
+	assert.Equal(t, "1 1/2SM", metar.TowerVisibility.Raw)
+	assert.Equal(t, "2SM", metar.SurfaceVisibility.Raw)
+
+	// Only the value that differs from prevailing visibility is called out
+	output := FormatMETAR(metar)
+	assert.Contains(t, output, "Tower Visibility: 1 1/2 statute miles\n")
+	assert.NotContains(t, output, "Surface Visibility:")
+}
+
+func TestFormatVisibility(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"":        "",
+		"10SM":    "10 statute miles",
+		"1 1/2SM": "1 1/2 statute miles",
+		"1/4SM":   "1/4 statute miles",
+		"2 3/4SM": "2 3/4 statute miles",
+		"3/4SM":   "3/4 statute miles",
+		"M1/4SM":  "Less than 1/4 statute miles",
+		"P6SM":    "Greater than 6 statute miles",
+		"9999":    "Unlimited visibility (greater than 10 kilometers)",
+		"0000":    "Less than 50 meters",
+		"1200":    "1,200 meters",
+		"2000NE":  "2,000 meters in the NE direction",
+		"9999NDV": "Unlimited visibility in all directions",
+		"CAVOK":   "Greater than 10 km",
+	}
+
+	for raw, want := range tests {
+		t.Run(raw, func(t *testing.T) {
+			assert.Equal(t, want, formatVisibility(wx.ParseVisibility(raw)))
+		})
+	}
+}
+
+func TestFormatStatuteMiles(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw      string
+		fraction string
+		unicode  string
+		decimal  string
+	}{
+		{raw: "1/4SM", fraction: "1/4", unicode: "¼", decimal: "0.25"},
+		{raw: "1 1/2SM", fraction: "1 1/2", unicode: "1½", decimal: "1.5"},
+		{raw: "M1/4SM", fraction: "1/4", unicode: "¼", decimal: "0.25"},
+		{raw: "2 3/4SM", fraction: "2 3/4", unicode: "2¾", decimal: "2.75"},
+		{raw: "1 5/16SM", fraction: "1 5/16", unicode: "1 5/16", decimal: "1.3125"},
+		{raw: "10SM", fraction: "10", unicode: "10", decimal: "10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			miles := wx.ParseVisibility(tt.raw).StatuteMiles
+			assert.Equal(t, tt.fraction, formatStatuteMiles(miles, "fraction"))
+			assert.Equal(t, tt.fraction, formatStatuteMiles(miles, ""))
+			assert.Equal(t, tt.unicode, formatStatuteMiles(miles, "unicode"))
+			assert.Equal(t, tt.decimal, formatStatuteMiles(miles, "decimal"))
+		})
+	}
+}
+
+func TestToASCII(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "Temperature: 21 C | 70 F", toASCII("Temperature: 21°C | 70°F"))
+	assert.Equal(t, "From 270 deg at 10 knots", toASCII("From 270° at 10 knots"))
+	assert.Equal(t, "  * peak wind", toASCII("  • peak wind"))
+	assert.Equal(t, "S?o Paulo", toASCII("São Paulo"))
+
+	metar := wx.DecodeMETAR("METAR KORD 081551Z 27010KT 240V300 10SM FEW250 21/09 A3012 RMK AO2 PK WND 28025/1520 T02110089")
+	out := toASCII(FormatMETAR(metar))
+	for _, r := range out {
+		assert.LessOrEqual(t, r, rune(unicode.MaxASCII), "non-ASCII %q in output", r)
+	}
+	assert.Contains(t, out, "21 C")
+}
+
+func TestWithCloudLayerOpacity(t *testing.T) {
+	t.Parallel()
+
+	metar := wx.DecodeMETAR("CYWG 080749Z 17005KT 15SM FEW000 BKN028 BKN044 M02/M04 A2970 RMK FG1SC5AC2 SLP072")
+	clouds := withCloudLayerOpacity(metar.Clouds, metar.CloudLayers)
+	assert.Equal(t, "few clouds (1/8 opacity), broken clouds at 2,800 feet (5/8 opacity), broken clouds at 4,400 feet (2/8 opacity)", formatClouds(clouds))
+
+	// Obscurations aren't paired with cloud layers when the body has no layer for them
+	metar = wx.DecodeMETAR("CYWG 080749Z 17005KT 3SM BR BKN028 BKN044 M02/M04 A2970 RMK BR2SC5AC2 SLP072")
+	clouds = withCloudLayerOpacity(metar.Clouds, metar.CloudLayers)
+	assert.Equal(t, "broken clouds at 2,800 feet (5/8 opacity), broken clouds at 4,400 feet (2/8 opacity)", formatClouds(clouds))
+
+	// Layers that can't be paired are left alone
+	metar = wx.DecodeMETAR("CYWG 080749Z 17005KT 15SM BKN028 M02/M04 A2970 RMK SC5AC2 SLP072")
+	assert.Equal(t, metar.Clouds, withCloudLayerOpacity(metar.Clouds, metar.CloudLayers))
+}
+
+func TestWithConvectiveCloudLocations(t *testing.T) {
+	t.Parallel()
+
+	metar := wx.DecodeMETAR("KMIA 081553Z 09012KT 10SM SCT030CB BKN100 27/22 A3001 RMK AO2 CB DSNT NW MOV E SLP162")
+	clouds := withConvectiveCloudLocations(metar.Clouds, metar.ConvectiveClouds)
+	assert.Equal(t, "scattered clouds at 3,000 feet (cumulonimbus, distant NW, moving E), broken clouds at 10,000 feet", formatClouds(clouds))
+
+	// A CBMAM remark locates the CB layer, and a TCU remark the TCU layer
+	metar = wx.DecodeMETAR("KMIA 081553Z 09012KT 10SM FEW025TCU SCT030CB 27/22 A3001 RMK AO2 CBMAM OHD STNRY TCU W AND NE")
+	clouds = withConvectiveCloudLocations(metar.Clouds, metar.ConvectiveClouds)
+	assert.Equal(t, "few clouds at 2,500 feet (towering cumulus, W and NE), scattered clouds at 3,000 feet (cumulonimbus, overhead, stationary)", formatClouds(clouds))
+
+	// Without a matching remark the layers are left alone
+	metar = wx.DecodeMETAR("KMIA 081553Z 09012KT 10SM SCT030CB 27/22 A3001 RMK AO2 TCU NE")
+	assert.Equal(t, metar.Clouds, withConvectiveCloudLocations(metar.Clouds, metar.ConvectiveClouds))
+}
+
+func TestFormatColorState(t *testing.T) {
+	t.Parallel()
+
+	reported := wx.DecodeMETAR("EGQL 081150Z 13006KT 1400 BR BKN001 08/07 Q1010 RMK AMB")
+	assert.Equal(t, "AMB (amber: ≥200 ft ceiling and ≥800 m visibility)", formatColorState(reported, false))
+	assert.Equal(t, "RED (red: <200 ft ceiling or <800 m visibility), derived from cloud base and visibility; reported AMB", formatColorState(reported, true))
+
+	derived := wx.DecodeMETAR("KORD 081551Z 27010KT 3SM BR BKN008 21/19 A3012")
+	assert.Equal(t, "GRN (green: ≥700 ft ceiling and ≥3.7 km visibility), derived from cloud base and visibility", formatColorState(derived, false))
+	assert.Equal(t, "GRN (green: ≥700 ft ceiling and ≥3.7 km visibility), derived from cloud base and visibility", formatColorState(derived, true))
+
+	recent := wx.DecodeMETAR("EGVN 081150Z 24010KT 9999 FEW030 15/08 Q1013 BLU+/WHT")
+	assert.Equal(t, "BLU+ (blue+: ≥2500 ft ceiling and ≥8 km visibility), recently WHT", formatColorState(recent, false))
+}
+
+func TestFormatMETAR_sensorStatusAdvisory(t *testing.T) {
+	t.Parallel()
+
+	metar := wx.DecodeMETAR("KPVD 081551Z 27010KT 10SM FEW250 21/09 A3012 RMK AO2 SLP199 RVRNO PWINO TSNO $")
+	out := FormatMETAR(metar)
+
+	assert.True(t, strings.HasSuffix(out, "\nAdvisory: equipment maintenance required; RVR not available; precipitation type sensor not available; lightning detection not available\n"), out)
+
+	// The individual status remarks are folded into the advisory
+	assert.Contains(t, out, "  SLP199: ")
+	assert.NotContains(t, out, "  RVRNO: ")
+	assert.NotContains(t, out, "  $: ")
+}
+
+func TestSensorStatusAdvisory(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, sensorStatusAdvisory(wx.DecodeMETAR("KPVD 081551Z 27010KT 10SM FEW250 21/09 A3012 RMK AO2 SLP199").Remarks))
+	assert.Equal(t, "RVR not available", sensorStatusAdvisory(wx.DecodeMETAR("KPVD 081551Z 27010KT 10SM FEW250 21/09 A3012 RMK AO2 RVRNO RVRNO").Remarks))
+	assert.Equal(t, "equipment maintenance required; sea level pressure not available",
+		sensorStatusAdvisory(wx.DecodeMETAR("KPVD 081551Z 27010KT 10SM FEW250 21/09 A3012 RMK AO2 SLPNO $").Remarks))
+}
+
+func TestFormatTAF_peakGust(t *testing.T) {
+	t.Parallel()
+
+	taf := wx.DecodeTAF("TAF KDEN 081120Z 0812/0912 27015G25KT P6SM SCT080 TEMPO 0812/0814 28020G35KT FM082000 30010KT P6SM SKC")
+	assert.Contains(t, FormatTAF(taf), "Peak gust in forecast: 35 knots (TEMPO 0812/0814)\n")
+
+	// A period running past midnight shows its days
+	overnight := wx.DecodeTAF("TAF KDEN 081720Z 0818/1000 27010KT P6SM SCT080 FM082200 28020G35KT P6SM SKC")
+	assert.Contains(t, FormatTAF(overnight), "Peak gust in forecast: 35 knots (FM 0822/1000)\n")
+
+	calm := wx.DecodeTAF("TAF KDEN 081120Z 0812/0912 27010KT P6SM SCT080")
+	assert.NotContains(t, FormatTAF(calm), "Peak gust")
+}
+
+func TestFormatTAF_windVariation(t *testing.T) {
+	t.Parallel()
+
+	taf := wx.DecodeTAF("TAF EGLL 081100Z 0812/0918 24012KT 200V280 9999 SCT030")
+	assert.Contains(t, FormatTAF(taf), "(varying between 200° and 280°)")
+}
+
+func TestFormatTAF_amendmentsNotScheduled(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw      string
+		wantLine string
+	}{
+		{
+			raw:      "TAF KPEQ 080532Z 0806/0906 28012KT P6SM SKC FM081500 27019G25KT P6SM BKN250 AMD NOT SKED",
+			wantLine: "Amendments: Not scheduled\n",
+		},
+		{
+			raw:      "TAF TJBQ 080723Z 0807/0906 04005KT P6SM VCSH SCT020 FM082200 07006KT P6SM FEW025 AMD NOT SKED TIL 081100",
+			wantLine: "Amendments: Not scheduled until ",
+		},
+		{
+			raw:      "TAF KXXX 080300Z 0803/0903 18005KT P6SM SKC AMD NOT SKED 0804/0809",
+			wantLine: "Amendments: Not scheduled from ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			assert.Contains(t, FormatTAF(wx.DecodeTAF(tt.raw)), tt.wantLine)
+		})
+	}
+}
+
+func TestFormatMETAR_ceiling(t *testing.T) {
+	t.Parallel()
+
+	assert.Contains(t, FormatMETAR(wx.DecodeMETAR("KORD 081551Z 27010KT 10SM FEW008 BKN035 OVC120 21/09 A3012")), "Ceiling: 3,500 feet\n")
+	assert.NotContains(t, FormatMETAR(wx.DecodeMETAR("KORD 081551Z 27010KT 10SM FEW020 SCT250 21/09 A3012")), "Ceiling:")
+}
+
+func TestFormatMETAR_humidity(t *testing.T) {
+	t.Parallel()
+
+	assert.Contains(t, FormatMETAR(wx.DecodeMETAR("KORD 081551Z 27010KT 10SM FEW250 21/18 A3012")), "Dew Point: 18°C | 64°F\nHumidity: 83%\n")
+	assert.NotContains(t, FormatMETAR(wx.DecodeMETAR("KORD 081551Z 27010KT 10SM FEW250 21/ A3012")), "Humidity:")
+}
+
+func TestFormatMETAR_apparentTemperature(t *testing.T) {
+	t.Parallel()
+
+	assert.Contains(t, FormatMETAR(wx.DecodeMETAR("KORD 081551Z 27020KT 10SM FEW250 M10/M15 A3012")), "Apparent temperature: -20°C | -4°F\n")
+	assert.Contains(t, FormatMETAR(wx.DecodeMETAR("KORD 081551Z 27005KT 10SM FEW250 32/23 A3012")), "Apparent temperature: 37°C | 98°F\n")
+	assert.NotContains(t, FormatMETAR(wx.DecodeMETAR("KORD 081551Z 00000KT 10SM FEW250 M10/M15 A3012")), "Apparent temperature:")
+	assert.NotContains(t, FormatMETAR(wx.DecodeMETAR("KORD 081551Z 27020KT 10SM FEW250 21/09 A3012")), "Apparent temperature:")
+}
+
+func TestFormatTAF_malformedProbability(t *testing.T) {
+	t.Parallel()
+
+	output := FormatTAF(wx.DecodeTAF("TAF KDFW 081730Z 0818/0924 20010KT P6SM SCT040 PROBXX 0900/0904 TSRA BKN030CB"))
+	assert.Contains(t, output, "Probability (unknown)")
+	assert.NotContains(t, output, "0% Probability")
+}
+
+func TestFormatRunwayWind(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		metar  string
+		runway string
+		want   string
+	}{
+		{name: "headwind only", metar: "KORD 081551Z 27010KT 10SM SKC 21/09 A3012", runway: "27", want: "headwind 10 kt, crosswind 0 kt"},
+		{name: "pure crosswind", metar: "KORD 081551Z 36012KT 10SM SKC 21/09 A3012", runway: "27L", want: "headwind 0 kt, crosswind 12 kt from the right"},
+		{name: "tailwind", metar: "KORD 081551Z 12010KT 10SM SKC 21/09 A3012", runway: "27", want: "tailwind 9 kt, crosswind 5 kt from the left"},
+		{name: "gusts", metar: "KORD 081551Z 31015G25KT 10SM SKC 21/09 A3012", runway: "27", want: "headwind 11 kt, crosswind 10 kt from the right, 16 kt in gusts"},
+		{name: "meters per second", metar: "EGLL 081550Z 22006MPS 9999 SKC 12/05 Q1020", runway: "27", want: "headwind 4 m/s, crosswind 5 m/s from the left"},
+		{name: "variable", metar: "KORD 081551Z VRB03KT 10SM SKC 21/09 A3012", runway: "27", want: "variable – cannot compute"},
+		{name: "calm", metar: "KORD 081551Z 00000KT 10SM SKC 21/09 A3012", runway: "27", want: "calm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatRunwayWind(wx.DecodeMETAR(tt.metar).Wind, tt.runway))
+		})
+	}
+}
+
+func TestFormatWind_units(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "From 240° at 8 meters per second", formatWind(wx.ParseWind("24008MPS")))
+	assert.Equal(t, "From 270° at 15 kilometers per hour, gusting to 30 kilometers per hour", formatWind(wx.ParseWind("27015G30KMH")))
+}
+
+func TestFormatMETAR_units(t *testing.T) {
+	defer func(saved DisplayOptions) { displayOptions = saved }(displayOptions)
+
+	metar := wx.DecodeMETAR("KORD 081551Z 27015G25KT 1 1/2SM BR BKN035 21/09 A3012")
+
+	tests := []struct {
+		units string
+		want  []string
+	}{
+		{
+			units: "aviation",
+			want: []string{
+				"Wind: From 270° at 15 knots, gusting to 25 knots\n",
+				"Visibility: 1 1/2 statute miles\n",
+				"Ceiling: 3,500 feet\n",
+				"Temperature: 21°C | 69°F\n",
+				"Pressure: 30.12 inHg | 1020.0 hPa\n",
+			},
+		},
+		{
+			units: "metric",
+			want: []string{
+				"Wind: From 270° at 28 kilometers per hour, gusting to 46 kilometers per hour\n",
+				"Visibility: 2.4 kilometers\n",
+				"Clouds: Broken clouds at 1,067 meters\n",
+				"Ceiling: 1,067 meters\n",
+				"Temperature: 21°C\n",
+				"Pressure: 1020.0 hPa\n",
+			},
+		},
+		{
+			units: "imperial",
+			want: []string{
+				"Wind: From 270° at 17 miles per hour, gusting to 29 miles per hour\n",
+				"Visibility: 1 1/2 statute miles\n",
+				"Ceiling: 3,500 feet\n",
+				"Temperature: 69°F\n",
+				"Pressure: 30.12 inHg\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.units, func(t *testing.T) {
+			displayOptions.Units = tt.units
+			output := FormatMETAR(metar)
+			for _, want := range tt.want {
+				assert.Contains(t, output, want)
+			}
+		})
+	}
+}
+
+func TestFormatTAF_units(t *testing.T) {
+	defer func(saved DisplayOptions) { displayOptions = saved }(displayOptions)
+	displayOptions.Units = "metric"
+
+	output := FormatTAF(wx.DecodeTAF("TAF KORD 081720Z 0818/0924 27015G25KT P6SM SCT050 FM090200 30010KT 3SM BR OVC008"))
+	assert.Contains(t, output, "Wind: From 270° at 28 kilometers per hour, gusting to 46 kilometers per hour\n")
+	assert.Contains(t, output, "Peak gust in forecast: 46 kilometers per hour (")
+	assert.Contains(t, output, "Visibility: Greater than 9.7 kilometers\n")
+	assert.Contains(t, output, "Clouds: Scattered clouds at 1,524 meters\n")
+	assert.Contains(t, output, "Clouds: Overcast at 244 meters\n")
+
+	displayOptions.Units = "imperial"
+	assert.Contains(t, FormatTAF(wx.DecodeTAF("TAF KORD 081720Z 0818/0924 27015G25KT P6SM SCT050")), "Peak gust in forecast: 29 miles per hour (")
+}
+
+func TestFormatVisibility_imperial(t *testing.T) {
+	defer func(saved DisplayOptions) { displayOptions = saved }(displayOptions)
+	displayOptions.Units = "imperial"
+
+	assert.Equal(t, "2.5 statute miles", formatVisibility(wx.ParseVisibility("4000")))
+	assert.Equal(t, "Unlimited visibility (greater than 6 statute miles)", formatVisibility(wx.ParseVisibility("9999")))
+	assert.Equal(t, "Greater than 6 statute miles", formatVisibility(wx.ParseVisibility("CAVOK")))
+}
+
+func TestFormatTAF_forecastNumbers(t *testing.T) {
+	defer func(saved DisplayOptions) { displayOptions = saved }(displayOptions)
+
+	taf := wx.DecodeTAF("TAF KORD 081720Z 0818/0924 27015KT P6SM SCT050 FM090200 30010KT 3SM BR OVC008")
+
+	output := FormatTAF(taf)
+	assert.Regexp(t, `\n1\. Base Forecast `, output)
+	assert.Regexp(t, `\n2\. From `, output)
+
+	displayOptions.NoForecastNumbers = true
+	output = FormatTAF(taf)
+	assert.Regexp(t, `\nBase Forecast \d{4}-`, output)
+	assert.Regexp(t, `\nFrom \d{4}-`, output)
+	assert.NotRegexp(t, `\n\d\. `, output)
+}
@@ -22,7 +22,7 @@ type Location struct {
 // GetLocation uses a free IP geolocation service to get location information
 // Uses ipinfo.io which is free for non-commercial use
 func GetLocation() (*Location, error) {
-	resp, err := http.Get("https://ipinfo.io/json")
+	resp, err := httpClient.Get("https://ipinfo.io/json")
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -74,27 +74,31 @@ func GetLocation() (*Location, error) {
 	}, nil
 }
 
-// GetLocationByZipcode gets location information from a US zipcode
+// GetLocationByZipcode gets location information from a postal code in the country with
+// the given two-letter code (e.g. "us", "gb" or "de")
 // Uses the public API from zippopotam.us which is free to use
-func GetLocationByZipcode(zipcode string) (*Location, error) {
+func GetLocationByZipcode(country, zipcode string) (*Location, error) {
+	country = strings.ToLower(country)
+
 	// Validate zipcode format (basic check)
-	if len(zipcode) < 5 {
+	if country == "us" && len(zipcode) < 5 {
 		return nil, fmt.Errorf("invalid zipcode format: must be at least 5 characters")
 	}
 
-	// Build URL with the zipcode
-	baseURL := "https://api.zippopotam.us/us/"
-	apiURL := baseURL + url.PathEscape(zipcode)
+	// Build URL with the country and zipcode
+	baseURL := "https://api.zippopotam.us/"
+	apiURL := baseURL + url.PathEscape(country) + "/" + url.PathEscape(zipcode)
 
 	// Make the request
-	resp, err := http.Get(apiURL)
+	resp, err := httpClient.Get(apiURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("zipcode not found: %s", zipcode)
+		return nil, fmt.Errorf("postal code %s not found in %s (%s); zippopotam.us may not cover this country or code format",
+			zipcode, GetCountryName(strings.ToUpper(country)), strings.ToUpper(country))
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+)
+
+// htmlOutput writes each decoded report as a small HTML fragment for -html, to be
+// embedded in a dashboard and styled through its wx-* CSS classes
+type htmlOutput struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// activeHTMLOutput is the writer set up by -html, or nil when reports are formatted as text
+var activeHTMLOutput *htmlOutput
+
+// htmlField is one labeled value of a report, with the CSS class naming the field
+type htmlField struct {
+	Class string
+	Label string
+	Value string
+}
+
+// htmlPeriod is a row of a TAF's forecast table
+type htmlPeriod struct {
+	Class  string
+	Label  string
+	Fields []htmlField
+}
+
+// htmlReport is the data rendered into a report fragment
+type htmlReport struct {
+	Kind     string // "metar" or "taf"
+	Station  string
+	Site     string
+	Category string // Flight category of a METAR, empty when it can't be determined
+	Fields   []htmlField
+	Columns  []string // Column headings of the forecast table
+	Periods  []htmlPeriod
+	Raw      string
+}
+
+// htmlColumns lists the columns of a TAF's forecast table after the period itself
+var htmlColumns = []string{"Wind", "Visibility", "Weather", "Clouds"}
+
+// htmlTemplate renders a report. Values are escaped by html/template.
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{"lower": strings.ToLower}).Parse(`<section class="wx-report wx-{{.Kind}}{{with .Category}} wx-category-{{lower .}}{{end}}">
+  <h2 class="wx-station">{{.Station}}{{with .Site}} <span class="wx-site">{{.}}</span>{{end}}</h2>
+  <dl class="wx-fields">
+{{- range .Fields}}
+    <dt class="wx-{{.Class}}">{{.Label}}</dt><dd class="wx-{{.Class}}">{{.Value}}</dd>
+{{- end}}
+  </dl>
+{{- if .Periods}}
+  <table class="wx-forecast">
+    <thead><tr><th>Period</th>{{range .Columns}}<th>{{.}}</th>{{end}}</tr></thead>
+    <tbody>
+{{- range .Periods}}
+      <tr class="wx-period wx-period-{{.Class}}"><th>{{.Label}}</th>{{range .Fields}}<td class="wx-{{.Class}}">{{.Value}}</td>{{end}}</tr>
+{{- end}}
+    </tbody>
+  </table>
+{{- end}}
+  <pre class="wx-raw">{{.Raw}}</pre>
+</section>
+`))
+
+// newHTMLOutput returns an HTML writer to w
+func newHTMLOutput(w io.Writer) *htmlOutput {
+	return &htmlOutput{w: w}
+}
+
+// addMETAR writes the fragment for a decoded METAR
+func (o *htmlOutput) addMETAR(m wx.METAR) error {
+	return o.write(htmlMETAR(m))
+}
+
+// addTAF writes the fragment for a decoded TAF
+func (o *htmlOutput) addTAF(t wx.TAF) error {
+	return o.write(htmlTAF(t))
+}
+
+// write renders a report fragment to the output
+func (o *htmlOutput) write(report htmlReport) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := htmlTemplate.Execute(o.w, report); err != nil {
+		return fmt.Errorf("error writing HTML output: %w", err)
+	}
+	return nil
+}
+
+// htmlMETAR collects the fields of a decoded METAR shown in its fragment
+func htmlMETAR(m wx.METAR) htmlReport {
+	report := htmlReport{
+		Kind:     "metar",
+		Station:  m.Station,
+		Site:     htmlSite(m.SiteInfo, m.Station),
+		Category: m.FlightCategory(),
+		Raw:      m.Raw,
+	}
+
+	add := func(class, label, value string) {
+		if value != "" {
+			report.Fields = append(report.Fields, htmlField{Class: class, Label: label, Value: value})
+		}
+	}
+
+	if !m.Time.IsZero() {
+		add("time", "Time", m.Time.Format("2006-01-02 15:04 UTC"))
+	}
+	if displayOptions.ExplainCategory {
+		add("category", "Flight Category", formatFlightCategory(m))
+	} else {
+		add("category", "Flight Category", report.Category)
+	}
+	wind := formatWind(m.Wind)
+	if wind != "" && m.WindVariation != "" {
+		wind += formatWindVariation(m.WindVariation)
+	}
+	add("wind", "Wind", wind)
+	add("visibility", "Visibility", formatVisibility(m.Visibility))
+	if len(m.Weather) > 0 {
+		add("weather", "Weather", capitalizeFirst(wx.FormatWeather(m.Weather)))
+	}
+	add("clouds", "Clouds", capitalizeFirst(formatClouds(m.Clouds)))
+	if ceiling, ok := m.Ceiling(); ok {
+		add("ceiling", "Ceiling", formatHeight(ceiling))
+	}
+	if m.Temperature != nil {
+		add("temperature", "Temperature", formatTemperature(*m.Temperature))
+	}
+	if m.DewPoint != nil {
+		add("dewpoint", "Dew Point", formatTemperature(*m.DewPoint))
+	}
+	if m.Pressure > 0 {
+		add("pressure", "Pressure", formatPressure(m.Pressure, m.PressureUnit, pressureDisplayUnits()))
+	}
+
+	return report
+}
+
+// htmlTAF collects the fields and forecast periods of a decoded TAF shown in its fragment
+func htmlTAF(t wx.TAF) htmlReport {
+	report := htmlReport{
+		Kind:    "taf",
+		Station: t.Station,
+		Site:    htmlSite(t.SiteInfo, t.Station),
+		Columns: htmlColumns,
+		Raw:     t.Raw,
+	}
+
+	if !t.Time.IsZero() {
+		report.Fields = append(report.Fields, htmlField{Class: "time", Label: "Issued", Value: t.Time.Format("2006-01-02 15:04 UTC")})
+	}
+	if !t.ValidFrom.IsZero() && !t.ValidTo.IsZero() {
+		report.Fields = append(report.Fields, htmlField{Class: "valid", Label: "Valid",
+			Value: t.ValidFrom.Format("2006-01-02 15:04 UTC") + " to " + t.ValidTo.Format("2006-01-02 15:04 UTC")})
+	}
+
+	for _, forecast := range t.Forecasts {
+		report.Periods = append(report.Periods, htmlPeriod{
+			Class: strings.ToLower(forecast.Type),
+			Label: formatPeriodLabel(forecast),
+			Fields: []htmlField{
+				{Class: "wind", Value: formatWind(forecast.Wind)},
+				{Class: "visibility", Value: formatVisibility(forecast.Visibility)},
+				{Class: "weather", Value: capitalizeFirst(wx.FormatWeather(forecast.Weather))},
+				{Class: "clouds", Value: capitalizeFirst(formatClouds(forecast.Clouds))},
+			},
+		})
+	}
+
+	return report
+}
+
+// htmlSite describes the station's location for the heading, or returns "" when only the
+// station code is known
+func htmlSite(info wx.SiteInfo, station string) string {
+	if info.Name == "" || info.Name == station {
+		return ""
+	}
+	return formatSiteInfo(info)
+}
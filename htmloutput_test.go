@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLOutput(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	out := newHTMLOutput(&buf)
+
+	metar := wx.DecodeMETAR("KJFK 081551Z 27020G35KT 3SM -RA BR BKN008 OVC015 21/19 A2992 RMK AO2")
+	metar.SiteInfo = wx.SiteInfo{Name: "New York/JFK", State: "NY", Country: "United States"}
+	require.NoError(t, out.addMETAR(metar))
+
+	html := buf.String()
+	assert.Contains(t, html, `<section class="wx-report wx-metar wx-category-ifr">`)
+	assert.Contains(t, html, `<h2 class="wx-station">KJFK <span class="wx-site">New York/JFK, NY, United States</span></h2>`)
+	assert.Contains(t, html, `<dt class="wx-wind">Wind</dt><dd class="wx-wind">From 270° at 20 knots, gusting to 35 knots</dd>`)
+	assert.Contains(t, html, `<dt class="wx-ceiling">Ceiling</dt><dd class="wx-ceiling">800 feet</dd>`)
+	assert.Contains(t, html, `<pre class="wx-raw">KJFK 081551Z`)
+
+	// Values are escaped
+	buf.Reset()
+	require.NoError(t, out.addMETAR(wx.DecodeMETAR("KJFK 081551Z 27010KT 10SM CLR 21/09 A3012 RMK <b>")))
+	assert.Contains(t, buf.String(), "RMK &lt;b&gt;</pre>")
+	assert.NotContains(t, buf.String(), "<b>")
+
+	// A TAF gets a row per forecast period
+	buf.Reset()
+	now := time.Date(2025, time.March, 8, 17, 30, 0, 0, time.UTC)
+	taf := wx.DecodeTAFAt("TAF KORD 081720Z 0818/0924 27010KT P6SM SCT050 TEMPO 0820/0824 3SM -SHRA BKN020", now)
+	require.NoError(t, out.addTAF(taf))
+	html = buf.String()
+	assert.Contains(t, html, `<section class="wx-report wx-taf">`)
+	assert.Contains(t, html, `<dt class="wx-valid">Valid</dt><dd class="wx-valid">2025-03-08 18:00 UTC to 2025-03-10 00:00 UTC</dd>`)
+	assert.Contains(t, html, `<tr class="wx-period wx-period-tempo"><th>TEMPO 0820/0900</th>`)
+	assert.Contains(t, html, `<td class="wx-weather">Light rain showers</td>`)
+}
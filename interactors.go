@@ -2,10 +2,14 @@ package main
 
 import (
 	"bufio"
+	"cmp"
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
+
+	"github.com/rmitchellscott/WxCraft/wx"
 )
 
 // readFromStdin reads data from stdin if available
@@ -45,13 +49,7 @@ func readFromStdin(rawInput string) (string, string, bool, bool) {
 
 	if len(parts) > 0 {
 		// Determine if input is a TAF or METAR
-		// Look for TAF-specific keywords and patterns
-		isTAF := strings.HasPrefix(strings.TrimSpace(firstLine), "TAF") ||
-			strings.Contains(rawInput, "TEMPO") ||
-			strings.Contains(rawInput, "BECMG") ||
-			strings.Contains(rawInput, "PROB") ||
-			// The following regex matches a typical TAF valid period format (e.g., 1106/1212)
-			regexp.MustCompile(`\d{4}/\d{4}`).MatchString(rawInput)
+		isTAF := detectTAF(rawInput)
 
 		// If the first token is "TAF", use the second token as the station code
 		stationCode := parts[0]
@@ -65,8 +63,170 @@ func readFromStdin(rawInput string) (string, string, bool, bool) {
 	return "", "", false, false
 }
 
-// getStationCodeFromArgs gets station code from command-line args
-func getStationCodeFromArgs(args []string) (string, error) {
+// detectTAF guesses whether raw input is a TAF rather than a METAR from TAF-specific
+// keywords and patterns. Input starting with METAR or SPECI is always a METAR. Otherwise
+// it can be wrong (e.g. a METAR with a BECMG trend), so -metar and -taf override it; see
+// decodeAsTAF.
+func detectTAF(rawInput string) bool {
+	firstLine, _, _ := strings.Cut(rawInput, "\n")
+	firstLine = strings.TrimSpace(firstLine)
+	if strings.HasPrefix(firstLine, "METAR ") || strings.HasPrefix(firstLine, "SPECI ") {
+		return false
+	}
+	return strings.HasPrefix(firstLine, "TAF") ||
+		strings.Contains(rawInput, "TEMPO") ||
+		strings.Contains(rawInput, "BECMG") ||
+		strings.Contains(rawInput, "PROB") ||
+		// The following regex matches a typical TAF valid period format (e.g., 1106/1212)
+		regexp.MustCompile(`\d{4}/\d{4}`).MatchString(rawInput)
+}
+
+// decodeAsTAF reports whether piped input is decoded as a TAF: -taf and -metar force the
+// decoder, and otherwise the detected report type is used
+func decodeAsTAF(detectedTAF, forceMETAR, forceTAF bool) bool {
+	if forceMETAR || forceTAF {
+		return forceTAF
+	}
+	return detectedTAF
+}
+
+// splitMETARReports splits piped METAR input into one report per line. Lines that start
+// with whitespace continue the report above them.
+func splitMETARReports(rawInput string) []string {
+	var reports []string
+	for _, line := range strings.Split(rawInput, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if len(reports) > 0 && (line[0] == ' ' || line[0] == '\t') {
+			reports[len(reports)-1] += " " + strings.TrimSpace(line)
+			continue
+		}
+		reports = append(reports, strings.TrimSpace(line))
+	}
+	return reports
+}
+
+// splitReportsByStation splits a response holding reports for several stations into one
+// report per station, keyed by station code. A report's continuation lines (e.g. a TAF's
+// indented change groups) are kept with it, and only the first report for each station
+// (the latest) is kept.
+func splitReportsByStation(data string) map[string]string {
+	reports := make(map[string]string)
+	var station string
+	var lines []string
+	flush := func() {
+		if _, seen := reports[station]; station != "" && !seen {
+			reports[station] = strings.Join(lines, "\n")
+		}
+	}
+
+	for _, line := range strings.Split(data, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			lines = append(lines, line)
+			continue
+		}
+
+		flush()
+		station = reportStationCode(line)
+		lines = []string{line}
+	}
+	flush()
+	return reports
+}
+
+// reportStationCode returns the station code of a raw report, skipping any report type
+// and modifier prefix (e.g. "METAR COR KORD ...")
+func reportStationCode(report string) string {
+	for _, part := range strings.Fields(report) {
+		switch part {
+		case "METAR", "SPECI", "TAF", "AMD", "COR":
+			continue
+		}
+		return part
+	}
+	return ""
+}
+
+// newestReports orders METAR reports newest first by observation time and keeps at most
+// limit of them (all of them if limit is 0). Reports without a time sort last.
+func newestReports(reports []string, limit int) []string {
+	times := make(map[string]int64, len(reports))
+	for _, report := range reports {
+		if obs := wx.DecodeMETARCached(report).Time; !obs.IsZero() {
+			times[report] = obs.Unix()
+		}
+	}
+
+	sorted := slices.Clone(reports)
+	slices.SortStableFunc(sorted, func(a, b string) int {
+		return cmp.Compare(times[b], times[a])
+	})
+
+	if limit > 0 && len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
+
+// groupByOptions are the accepted -group-stations-by values
+var groupByOptions = []string{"category"}
+
+// reportGroup is a run of METAR reports, or of fetched stations' codes, shown under one heading
+type reportGroup struct {
+	Heading string // Empty for reports that aren't grouped
+	Reports []string
+}
+
+// groupReportsByCategory groups METAR reports by flight category, worst first, keeping
+// their order within each group. Reports without a category are grouped last.
+func groupReportsByCategory(reports []string) []reportGroup {
+	return groupByCategory(reports, func(report string) string {
+		return wx.DecodeMETARCached(report).FlightCategory()
+	})
+}
+
+// groupStationsByCategory groups station codes by the flight category of their METAR in
+// metars, worst first, keeping their order within each group. Stations without a METAR
+// or a category are grouped last.
+func groupStationsByCategory(stationCodes []string, metars map[string]string) []reportGroup {
+	return groupByCategory(stationCodes, func(code string) string {
+		raw, ok := metars[code]
+		if !ok {
+			return ""
+		}
+		return wx.DecodeMETARCached(raw).FlightCategory()
+	})
+}
+
+// groupByCategory groups items by the flight category category returns for each
+func groupByCategory(items []string, category func(string) string) []reportGroup {
+	byCategory := make(map[string][]string)
+	for _, item := range items {
+		c := category(item)
+		byCategory[c] = append(byCategory[c], item)
+	}
+
+	var groups []reportGroup
+	for _, c := range []string{"LIFR", "IFR", "MVFR", "VFR", ""} {
+		if len(byCategory[c]) == 0 {
+			continue
+		}
+		heading := c
+		if heading == "" {
+			heading = "Unknown category"
+		}
+		groups = append(groups, reportGroup{Heading: heading, Reports: byCategory[c]})
+	}
+	return groups
+}
+
+// getStationCodeFromArgs gets station code from command-line args. With strict set, the
+// code must also be a station in the station database.
+func getStationCodeFromArgs(args []string, strict bool) (string, error) {
 	if len(args) < 1 {
 		return "", fmt.Errorf("no station code provided")
 	}
@@ -84,18 +244,53 @@ func getStationCodeFromArgs(args []string) (string, error) {
 		return stationCode, nil // Return zipcode instead of handling it here
 	}
 
+	stationCode = resolveIATACode(stationCode)
+	if err := validateStationCode(stationCode, strict); err != nil {
+		return "", err
+	}
+
+	return stationCode, nil
+}
+
+// resolveIATACode returns the ICAO code for a 3-letter IATA code, noting the substitution on
+// stderr, or the code unchanged when it isn't a known IATA code
+func resolveIATACode(code string) string {
+	icao, ok := ResolveStation(code)
+	if !ok {
+		return code
+	}
+	fmt.Fprintf(os.Stderr, "Using %s (from IATA %s)\n", icao, code)
+	return icao
+}
+
+// validateStationCode checks that stationCode looks like an ICAO code and, with strict
+// set, that it is in the station database. Errors suggest the closest known station
+// when there is one.
+func validateStationCode(stationCode string, strict bool) error {
 	// Check for ICAO format
 	if len(stationCode) != 4 {
-		return "", fmt.Errorf("invalid station code: must be 4 characters")
+		if suggestion, ok := suggestStation(stationCode); ok {
+			return fmt.Errorf("invalid station code %s: must be 4 characters — did you mean %s?", stationCode, suggestion)
+		}
+		return fmt.Errorf("invalid station code: must be 4 characters")
 	}
 
-	return stationCode, nil
+	if !strict {
+		return nil
+	}
+	if _, err := findEmbeddedStation(stationCode); err == nil {
+		return nil
+	}
+	if suggestion, ok := suggestStation(stationCode); ok {
+		return fmt.Errorf("%s not found — did you mean %s?", stationCode, suggestion)
+	}
+	return fmt.Errorf("%s not found in the station database", stationCode)
 }
 
 // promptForStationCode prompts the user for a station code
 func promptForStationCode() (string, error) {
 	reader := bufio.NewReader(os.Stdin)
-	fmt.Print("Enter ICAO airport code (e.g., KJFK, EGLL), US zipcode, or 'AUTO' for nearest airport: ")
+	fmt.Print("Enter ICAO or IATA airport code (e.g., KJFK, EGLL, LHR), US zipcode, postal code with its country (e.g., GB:SW1A), or 'AUTO' for nearest airport: ")
 	input, err := reader.ReadString('\n')
 	if err != nil {
 		return "", fmt.Errorf("error reading input: %w", err)
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeAsTAF(t *testing.T) {
+	t.Parallel()
+
+	trendMETAR := "KJFK 081751Z 27010KT 10SM FEW050 25/12 A3001 BECMG 2715G25KT"
+	bareTAF := "KDFW 081730Z 20010KT P6SM SCT040 FM090200 18008KT P6SM SKC"
+
+	// Detection misclassifies both
+	assert.True(t, detectTAF(trendMETAR))
+	assert.False(t, detectTAF(bareTAF))
+	assert.True(t, detectTAF("TAF KDFW 081730Z 0818/0924 20010KT P6SM SCT040"))
+	assert.False(t, detectTAF("KORD 081551Z 27010KT 10SM FEW250 21/09 A3012"))
+	assert.False(t, detectTAF("METAR "+trendMETAR))
+
+	// -metar and -taf override it
+	assert.False(t, decodeAsTAF(detectTAF(trendMETAR), true, false))
+	assert.True(t, decodeAsTAF(detectTAF(bareTAF), false, true))
+
+	// Otherwise the detected type is used
+	assert.True(t, decodeAsTAF(true, false, false))
+	assert.False(t, decodeAsTAF(false, false, false))
+}
+
+func TestSplitMETARReports(t *testing.T) {
+	t.Parallel()
+
+	input := "KORD 081551Z 27010KT 10SM FEW250 21/09 A3012\n\nKORD 081451Z 27008KT 10SM\n  FEW250 20/09 A3011\nSPECI KMDW 081512Z 26012KT 10SM CLR 21/08 A3012\n"
+	assert.Equal(t, []string{
+		"KORD 081551Z 27010KT 10SM FEW250 21/09 A3012",
+		"KORD 081451Z 27008KT 10SM FEW250 20/09 A3011",
+		"SPECI KMDW 081512Z 26012KT 10SM CLR 21/08 A3012",
+	}, splitMETARReports(input))
+
+	assert.Equal(t, []string{"KORD 081551Z 27010KT 10SM FEW250 21/09 A3012"}, splitMETARReports("KORD 081551Z 27010KT 10SM FEW250 21/09 A3012"))
+}
+
+func TestReportStationCode(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "KORD", reportStationCode("KORD 081551Z 27010KT"))
+	assert.Equal(t, "KMDW", reportStationCode("SPECI KMDW 081512Z 26012KT"))
+	assert.Equal(t, "KORD", reportStationCode("METAR COR KORD 081551Z 27010KT"))
+	assert.Equal(t, "", reportStationCode(""))
+}
+
+func TestNewestReports(t *testing.T) {
+	t.Parallel()
+
+	reports := []string{
+		"KORD 081351Z 27008KT 10SM FEW250 19/09 A3010",
+		"KORD 081551Z 27010KT 10SM FEW250 21/09 A3012",
+		"not a report",
+		"KORD 081451Z 27008KT 10SM FEW250 20/09 A3011",
+	}
+
+	assert.Equal(t, []string{
+		"KORD 081551Z 27010KT 10SM FEW250 21/09 A3012",
+		"KORD 081451Z 27008KT 10SM FEW250 20/09 A3011",
+		"KORD 081351Z 27008KT 10SM FEW250 19/09 A3010",
+		"not a report",
+	}, newestReports(reports, 0))
+
+	assert.Equal(t, []string{
+		"KORD 081551Z 27010KT 10SM FEW250 21/09 A3012",
+		"KORD 081451Z 27008KT 10SM FEW250 20/09 A3011",
+	}, newestReports(reports, 2))
+}
+
+func TestLevenshtein(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0, levenshtein("KJFK", "KJFK"))
+	assert.Equal(t, 1, levenshtein("KJFKK", "KJFK"))
+	assert.Equal(t, 1, levenshtein("KJFQ", "KJFK"))
+	assert.Equal(t, 2, levenshtein("JKFK", "KJFK"))
+	assert.Equal(t, 4, levenshtein("", "KJFK"))
+}
+
+func TestGetStationCodeFromArgs_suggestion(t *testing.T) {
+	t.Parallel()
+
+	_, err := getStationCodeFromArgs([]string{"kjfkk"}, false)
+	assert.EqualError(t, err, "invalid station code KJFKK: must be 4 characters — did you mean KJFK?")
+
+	// IATA codes are resolved to the ICAO code
+	code, err := getStationCodeFromArgs([]string{"jfk"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "KJFK", code)
+
+	// Unknown 4-character codes are only rejected in strict mode
+	code, err = getStationCodeFromArgs([]string{"KJFQ"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "KJFQ", code)
+
+	_, err = getStationCodeFromArgs([]string{"KJFQ"}, true)
+	assert.ErrorContains(t, err, "KJFQ not found — did you mean")
+
+	code, err = getStationCodeFromArgs([]string{"KJFK"}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "KJFK", code)
+}
+
+func TestGroupReportsByCategory(t *testing.T) {
+	t.Parallel()
+
+	reports := []string{
+		"KORD 081551Z 27010KT 10SM FEW250 21/09 A3012",
+		"KSFO 081556Z 28012KT 1/2SM FG OVC002 12/12 A3001",
+		"not a report",
+		"KSEA 081553Z 18008KT 3SM BR OVC008 10/09 A3001",
+		"KDEN 081553Z 18008KT 10SM SCT080 15/02 A3001",
+	}
+
+	assert.Equal(t, []reportGroup{
+		{Heading: "LIFR", Reports: []string{"KSFO 081556Z 28012KT 1/2SM FG OVC002 12/12 A3001"}},
+		{Heading: "IFR", Reports: []string{"KSEA 081553Z 18008KT 3SM BR OVC008 10/09 A3001"}},
+		{Heading: "VFR", Reports: []string{
+			"KORD 081551Z 27010KT 10SM FEW250 21/09 A3012",
+			"KDEN 081553Z 18008KT 10SM SCT080 15/02 A3001",
+		}},
+		{Heading: "Unknown category", Reports: []string{"not a report"}},
+	}, groupReportsByCategory(reports))
+}
+
+func TestGroupStationsByCategory(t *testing.T) {
+	t.Parallel()
+
+	metars := map[string]string{
+		"KORD": "KORD 081551Z 27010KT 10SM FEW250 21/09 A3012",
+		"KSEA": "KSEA 081553Z 18008KT 3SM BR OVC008 10/09 A3001",
+	}
+
+	assert.Equal(t, []reportGroup{
+		{Heading: "IFR", Reports: []string{"KSEA"}},
+		{Heading: "VFR", Reports: []string{"KORD", "KORD"}},
+		{Heading: "Unknown category", Reports: []string{"KJFK"}},
+	}, groupStationsByCategory([]string{"KORD", "KJFK", "KSEA", "KORD"}, metars))
+}
+
+func TestSplitReportsByStation(t *testing.T) {
+	t.Parallel()
+
+	data := "TAF KJFK 081720Z 0818/0924 27010KT P6SM SCT050\n" +
+		"      FM082100 29012KT P6SM BKN040\n" +
+		"TAF KBOS 081720Z 0818/0924 25008KT P6SM FEW250\n" +
+		"\n" +
+		"TAF KBOS 081520Z 0815/0918 25008KT P6SM FEW250\n"
+
+	assert.Equal(t, map[string]string{
+		"KJFK": "TAF KJFK 081720Z 0818/0924 27010KT P6SM SCT050\n      FM082100 29012KT P6SM BKN040",
+		"KBOS": "TAF KBOS 081720Z 0818/0924 25008KT P6SM FEW250",
+	}, splitReportsByStation(data))
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+)
+
+// jsonOutput collects decoded reports for -json, which writes them to stdout as a
+// single JSON document once every report has been processed
+type jsonOutput struct {
+	mu     sync.Mutex
+	metars []wx.METAR
+	tafs   []wx.TAF
+
+	// What the run shows, which fixes the shape of the document whichever reports decode
+	showMETAR, showTAF, several bool
+}
+
+// activeJSONOutput is the collector set up by -json, or nil when reports are formatted as text
+var activeJSONOutput *jsonOutput
+
+// combinedReports is the JSON document for a run that shows a station's METAR and TAF,
+// with null for one that wasn't decoded
+type combinedReports struct {
+	METAR *wx.METAR `json:"metar"`
+	TAF   *wx.TAF   `json:"taf"`
+}
+
+// severalReports is the JSON document for a run that shows several stations' METARs and TAFs
+type severalReports struct {
+	METARs []wx.METAR `json:"metars"`
+	TAFs   []wx.TAF   `json:"tafs"`
+}
+
+// expect sets what the run shows: METARs, TAFs or both, for one station or report or for
+// several. It does nothing on a nil output.
+func (o *jsonOutput) expect(showMETAR, showTAF, several bool) {
+	if o == nil {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.showMETAR, o.showTAF, o.several = showMETAR, showTAF, several
+}
+
+// addMETAR adds a decoded METAR to the output
+func (o *jsonOutput) addMETAR(m wx.METAR) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.metars = append(o.metars, m)
+}
+
+// addTAF adds a decoded TAF to the output
+func (o *jsonOutput) addTAF(t wx.TAF) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.tafs = append(o.tafs, t)
+}
+
+// document returns the value to encode, shaped by what the run shows rather than by which
+// reports were decoded: a report (or null) for one METAR or TAF, an object with "metar" and
+// "taf" keys (either null) for both, an array for several stations' METARs or TAFs, and an
+// object with "metars" and "tafs" arrays for several stations' METARs and TAFs.
+func (o *jsonOutput) document() any {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	metars := append([]wx.METAR{}, o.metars...)
+	tafs := append([]wx.TAF{}, o.tafs...)
+	switch {
+	case o.several && o.showMETAR && o.showTAF:
+		return severalReports{METARs: metars, TAFs: tafs}
+	case o.several && o.showTAF:
+		return tafs
+	case o.several:
+		return metars
+	case o.showMETAR && o.showTAF:
+		return combinedReports{METAR: first(metars), TAF: first(tafs)}
+	case o.showTAF:
+		return first(tafs)
+	}
+	return first(metars)
+}
+
+// first returns the first of reports, or nil when there are none
+func first[T any](reports []T) *T {
+	if len(reports) == 0 {
+		return nil
+	}
+	return &reports[0]
+}
+
+// write encodes the collected reports to w as indented JSON. Nothing is written on a nil
+// output.
+func (o *jsonOutput) write(w io.Writer) error {
+	if o == nil {
+		return nil
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(o.document()); err != nil {
+		return fmt.Errorf("error encoding JSON output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONOutput(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.March, 8, 17, 30, 0, 0, time.UTC)
+	metar := wx.DecodeMETAR("KORD 081551Z 27010KT 10SM FEW250 M/M A3012")
+	taf := wx.DecodeTAFAt("TAF KORD 081720Z 0818/0924 27010KT P6SM SCT050", now)
+
+	decode := func(o *jsonOutput) map[string]any {
+		t.Helper()
+		var buf bytes.Buffer
+		require.NoError(t, o.write(&buf))
+		var doc map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+		return doc
+	}
+
+	// A single report is written on its own, with missing values as null
+	single := &jsonOutput{}
+	single.expect(true, false, false)
+	single.addMETAR(metar)
+	doc := decode(single)
+	assert.Equal(t, "KORD", doc["station"])
+	assert.Contains(t, doc, "temperature")
+	assert.Nil(t, doc["temperature"])
+	assert.Equal(t, 10.0, doc["wind"].(map[string]any)["speed"])
+	assert.NotContains(t, doc, "fetched_at", "only fetched reports have a fetch time")
+
+	// A fetched report carries when it was fetched
+	fetched := metar
+	fetched.FetchedAt = now
+	single = &jsonOutput{}
+	single.expect(true, false, false)
+	single.addMETAR(fetched)
+	assert.Equal(t, "2025-03-08T17:30:00Z", decode(single)["fetched_at"])
+
+	// A METAR and TAF are wrapped together
+	combined := &jsonOutput{}
+	combined.expect(true, true, false)
+	combined.addMETAR(metar)
+	combined.addTAF(taf)
+	doc = decode(combined)
+	require.Contains(t, doc, "metar")
+	require.Contains(t, doc, "taf")
+	assert.Equal(t, "2025-03-08T18:00:00Z", doc["taf"].(map[string]any)["valid_from"])
+
+	// A report that wasn't decoded is null, keeping the wrapper
+	combined = &jsonOutput{}
+	combined.expect(true, true, false)
+	combined.addMETAR(metar)
+	doc = decode(combined)
+	assert.Equal(t, "KORD", doc["metar"].(map[string]any)["station"])
+	require.Contains(t, doc, "taf")
+	assert.Nil(t, doc["taf"])
+
+	// Several stations' METARs are an array, even when only one decoded
+	several := &jsonOutput{}
+	several.expect(true, false, true)
+	several.addMETAR(metar)
+	var buf bytes.Buffer
+	require.NoError(t, several.write(&buf))
+	var list []map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &list))
+	assert.Len(t, list, 1)
+
+	// Several stations' METARs and TAFs are kept in separate arrays
+	several = &jsonOutput{}
+	several.expect(true, true, true)
+	several.addMETAR(metar)
+	several.addMETAR(metar)
+	several.addTAF(taf)
+	doc = decode(several)
+	assert.Len(t, doc["metars"], 2)
+	assert.Len(t, doc["tafs"], 1)
+
+	// Nothing decoded keeps the shape, with null or empty arrays
+	buf.Reset()
+	none := &jsonOutput{}
+	none.expect(true, false, false)
+	require.NoError(t, none.write(&buf))
+	assert.Equal(t, "null\n", buf.String())
+	none.expect(true, true, true)
+	assert.Equal(t, map[string]any{"metars": []any{}, "tafs": []any{}}, decode(none))
+
+	// A nil output writes nothing
+	buf.Reset()
+	var nilOutput *jsonOutput
+	nilOutput.expect(true, true, false)
+	require.NoError(t, nilOutput.write(&buf))
+	assert.Zero(t, buf.Len())
+}
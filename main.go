@@ -1,30 +1,351 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/fatih/color"
+	"github.com/rmitchellscott/WxCraft/wx"
+)
+
+// Exit codes
+const (
+	exitOK    = 0 // Report fetched and displayed
+	exitError = 1 // Fetching, decoding input or looking up the station failed
+	exitUsage = 2 // Invalid flag values
+	exitAlert = 3 // An -alert-config rule fired
 )
 
 func main() {
+	os.Exit(run())
+}
+
+// printError reports an error on stderr and returns the exit code for it
+func printError(code int, format string, args ...any) int {
+	errorColor.Fprintf(os.Stderr, "Error: "+format+"\n", args...)
+	return code
+}
+
+// run executes the command line and returns the process exit code
+func run() (exitCode int) {
 	// Define command-line flags
 	metarOnly := flag.Bool("metar", false, "Show only METAR")
 	tafOnly := flag.Bool("taf", false, "Show only TAF")
 	noRawFlag := flag.Bool("no-raw", false, "Hide raw data")
 	noDecodeFlag := flag.Bool("no-decode", false, "Show only raw data without decoding")
 	flagNoColor := flag.Bool("no-color", false, "Disable color output")
-	radiusFlag := flag.Float64("radius", 50.0, "Search radius in miles when finding nearest airport (default 50)")
+	jsonFlag := flag.Bool("json", false, "Write the decoded reports to stdout as JSON instead of formatted text")
+	csvFlag := flag.Bool("csv", false, "Write decoded METARs to stdout as CSV, one row per report")
+	htmlFlag := flag.Bool("html", false, "Write each decoded report to stdout as an HTML fragment for embedding in a web page")
+	intervalStatsFlag := flag.Bool("interval-stats", false, "Print summary statistics (temperature range and mean, max gust, most common flight category) over the METARs read instead of each report")
+	fieldsFlag := flag.String("fields", "", "Comma-separated columns for -csv, in order (e.g. station,category,wind,temp; default: all)")
+	radiusFlag := flag.Float64("radius", 50.0, "Search radius, in -distance-units, when finding nearest airport (default 50)")
+	distanceUnitsFlag := flag.String("distance-units", "mi", "Units for nearest-airport distances and -radius: mi or km")
 	nearestFlag := flag.Bool("nearest", false, "Find nearest airport to your current location")
+	countryFlag := flag.String("country", "us", "Two-letter country code of postal codes given in place of a station code, e.g. gb or de (default us)")
+	latLonFlag := flag.String("latlon", "", "Find the nearest airport to these coordinates in decimal degrees, e.g. 40.64,-73.78")
+	nearestTAFFlag := flag.Bool("nearest-taf", false, "When finding the nearest airport, pick the nearest one that issues TAFs; implies -nearest without a station code")
+	nearestListFlag := flag.Int("nearest-list", 0, "When finding the nearest airport, also list this many of the closest ones with their distance and bearing; implies -nearest without a station code")
 	offlineFlag := flag.Bool("offline", false, "Operate in offline mode (only works with stdin data)")
 	data := flag.String("data", "", "Decode supplied data only")
+	passthroughFlag := flag.Bool("passthrough", false, "Echo piped input unchanged and exit with status 1 when it doesn't decode as a METAR or TAF")
+	watchFlag := flag.Int("watch", 0, "Re-fetch and redisplay the reports every this many seconds until interrupted with Ctrl-C (0 to show them once)")
+	onlyChangedFlag := flag.Bool("only-changed", false, "With -watch, only redisplay the reports when they changed since the previous refresh")
+	parallelFlag := flag.Int("parallel", 0, "With several station codes, fetch this many stations at once, each with its own requests (0 for one combined request for all of them)")
+	groupStationsByFlag := flag.String("group-stations-by", "", "Group METARs piped in one per line, or several stations' reports, under a heading per group: category (flight category, worst first)")
+	limitFlag := flag.Int("limit", 0, "Show at most this many METARs, newest first, when several are piped in one per line (0 for no limit)")
+	visFractionFlag := flag.String("vis-fraction", "fraction", "How fractional statute mile visibility is shown: fraction (1 1/2), unicode (1½) or decimal (1.5)")
+	militaryFlag := flag.Bool("military", false, "Show the NATO color state, as reported or derived from cloud base and visibility")
+	colorStateFlag := flag.Bool("color-state", false, "Show the NATO color state derived from cloud base and visibility")
+	verboseFlag := flag.Bool("verbose", false, "Show extra detail cross-checked from remarks (e.g. cloud layer opacity, remark altimeter setting)")
+	asciiFlag := flag.Bool("ascii", false, "Use only ASCII characters in decoded output (e.g. \" C\" for \"°C\", \"*\" for \"•\")")
+	utcOffsetFlag := flag.Bool("utc-offset", false, "Also show times in the station's approximate local time, estimated from its longitude")
+	inputFormatFlag := flag.String("in", "raw", "Format of piped or -data input: raw, or json for AWC API JSON")
+	listFieldsFlag := flag.Bool("list-fields", false, "List the fields available on decoded METAR and TAF reports and exit")
+	serveFlag := flag.String("serve", "", "Serve decoded reports as JSON over HTTP on this address, e.g. :8080 (GET /metar/{station} and /taf/{station})")
+	updateStationsFlag := flag.Bool("update-stations", false, "Download the current station database for offline use and exit")
+	cacheDirFlag := flag.String("cache-dir", "", "Directory for the downloaded station database (default: wxcraft in the user config directory)")
+	clearCacheFlag := flag.Bool("clear-cache", false, "Delete the downloaded station database and exit")
+	unitsFlag := flag.String("units", "aviation", "Units for decoded output: aviation (as reported, with conversions), metric or imperial")
+	pressureUnitsFlag := flag.String("pressure-units", "", "Pressure units to display: inhg, hpa, mmhg, kpa or all (default: reported unit and its inHg/hPa counterpart)")
+	timeoutFlag := flag.Duration("timeout", 10*time.Second, "How long to wait for each request to aviationweather.gov and the geolocation services (0 for no limit)")
+	siteInfoTimeoutFlag := flag.Duration("site-info-timeout", 2*time.Second, "How long to wait for station site info once the report is ready")
+	logFlag := flag.String("log", "", "Append each report, with a timestamp, to this log file")
+	pprofFlag := flag.String("pprof", "", "Serve net/http/pprof profiling endpoints on this address (e.g. :6060)")
+	noForecastNumbersFlag := flag.Bool("no-forecast-numbers", false, "Label TAF forecast periods by their type and time range alone, without sequence numbers")
+	preferRemarkTempFlag := flag.Bool("prefer-remark-temp", true, "Show the temperature and dew point from the precise T group in remarks, rounded, over the body values (-prefer-remark-temp=false shows the body values verbatim)")
+	explainCategoryFlag := flag.Bool("explain-category", false, "Show each METAR's flight category with the ceiling or visibility that decided it, e.g. MVFR (ceiling 2500 ft)")
+	windArrowFlag := flag.Bool("wind-arrow", false, "Start wind lines with an arrow pointing where the wind is blowing toward (a circle for variable or calm wind)")
+	sourceTimestampFlag := flag.Bool("source-timestamp", false, "Show when each report was fetched, to tell an old observation from an old fetch")
+	runwayFlag := flag.String("runway", "", "Show the headwind and crosswind components for this runway (e.g. 27 or 09L)")
+	alertConfigFlag := flag.String("alert-config", "", "JSON file of alert rules to check each METAR against; exits with status 3 when one fires")
+	pushgatewayFlag := flag.String("pushgateway", "", "Push each station's latest METAR as metrics to the Prometheus Pushgateway at this URL, e.g. http://localhost:9091")
+	failOnUnhandledFlag := flag.Bool("fail-on-unhandled", false, "Print the parts of each METAR the decoder didn't recognize and exit with status 1 if there were any")
+	strictICAOFlag := flag.Bool("strict-icao", false, "Reject station codes that aren't in the station database, suggesting the closest match")
+	exitZeroFlag := flag.Bool("exit-zero", false, "Always exit with status 0, even when fetching or decoding fails")
 	flag.Parse()
 
+	if *metarOnly && *tafOnly {
+		return printError(exitUsage, "-metar and -taf can't be combined")
+	}
+	cacheDirOverride = *cacheDirFlag
+
+	// Best-effort scripts can ask for success regardless of errors; they are still printed
+	defer func() {
+		if *exitZeroFlag {
+			exitCode = exitOK
+		}
+	}()
+
+	// JSON output is for programs: no colors, no raw banner, just the decoded reports
+	if *jsonFlag {
+		if *noDecodeFlag {
+			return printError(exitUsage, "-json can't be combined with -no-decode")
+		}
+		*flagNoColor = true
+		*noRawFlag = true
+		activeJSONOutput = &jsonOutput{}
+	}
+
+	// CSV output has one row per METAR; TAFs don't fit its columns
+	if *fieldsFlag != "" && !*csvFlag {
+		return printError(exitUsage, "-fields needs -csv")
+	}
+	if *csvFlag {
+		if *jsonFlag || *noDecodeFlag {
+			return printError(exitUsage, "-csv can't be combined with -json or -no-decode")
+		}
+		columns, err := parseColumns(*fieldsFlag)
+		if err != nil {
+			return printError(exitUsage, "%v", err)
+		}
+		*flagNoColor = true
+		*noRawFlag = true
+		*metarOnly = true
+		activeCSVOutput = newCSVOutput(os.Stdout, columns)
+	}
+
+	// HTML output is a fragment per report, with the raw report inside it
+	if *htmlFlag {
+		if *jsonFlag || *csvFlag || *noDecodeFlag {
+			return printError(exitUsage, "-html can't be combined with -json, -csv or -no-decode")
+		}
+		*flagNoColor = true
+		*noRawFlag = true
+		activeHTMLOutput = newHTMLOutput(os.Stdout)
+	}
+
+	// Interval statistics summarize many METARs, so they replace the per-report output
+	if *intervalStatsFlag {
+		if *jsonFlag || *csvFlag || *htmlFlag || *noDecodeFlag {
+			return printError(exitUsage, "-interval-stats can't be combined with -json, -csv, -html or -no-decode")
+		}
+		*noRawFlag = true
+		*metarOnly = true
+		activeIntervalStats = newIntervalStats()
+	}
+	structuredOutput := *jsonFlag || *csvFlag || *htmlFlag || *intervalStatsFlag
+
 	if *flagNoColor {
 		color.NoColor = true // disables colorized output globally
 	}
 
+	// Profiling endpoints are only started on request
+	if *pprofFlag != "" {
+		addr, err := startPprof(*pprofFlag)
+		if err != nil {
+			return printError(exitUsage, "%v", err)
+		}
+		fmt.Fprintf(os.Stderr, "pprof available at http://%s/debug/pprof/\n", addr)
+	}
+
+	displayOptions.ApproxLocalTime = *utcOffsetFlag
+	displayOptions.ASCII = *asciiFlag
+	displayOptions.Verbose = *verboseFlag
+	displayOptions.NoForecastNumbers = *noForecastNumbersFlag
+	displayOptions.PreferRemarkTemp = *preferRemarkTempFlag
+	displayOptions.SourceTimestamp = *sourceTimestampFlag
+	displayOptions.WindArrow = *windArrowFlag
+	displayOptions.ExplainCategory = *explainCategoryFlag
+	displayOptions.Military = *militaryFlag
+	displayOptions.ColorState = *colorStateFlag
+	displayOptions.PressureUnits = strings.ToLower(*pressureUnitsFlag)
+	if displayOptions.PressureUnits != "" && !slices.Contains(pressureUnitOptions, displayOptions.PressureUnits) {
+		return printError(exitUsage, "unknown pressure units %q (expected one of %s)", *pressureUnitsFlag, strings.Join(pressureUnitOptions, ", "))
+	}
+
+	displayOptions.Units = strings.ToLower(*unitsFlag)
+	if !slices.Contains(unitOptions, displayOptions.Units) {
+		return printError(exitUsage, "unknown units %q (expected one of %s)", *unitsFlag, strings.Join(unitOptions, ", "))
+	}
+
+	if *watchFlag < 0 {
+		return printError(exitUsage, "-watch must not be negative, got %d", *watchFlag)
+	}
+	if *onlyChangedFlag && *watchFlag == 0 {
+		return printError(exitUsage, "-only-changed needs -watch")
+	}
+	// Alerts and unhandled values are reported through the exit status once every report
+	// has been shown, which a watch that runs until interrupted never reaches
+	if *watchFlag > 0 && (*offlineFlag || *jsonFlag || *csvFlag || *htmlFlag || *intervalStatsFlag || *pushgatewayFlag != "" ||
+		*alertConfigFlag != "" || *failOnUnhandledFlag) {
+		return printError(exitUsage, "-watch can't be combined with -offline, -json, -csv, -html, -interval-stats, -pushgateway, -alert-config or -fail-on-unhandled")
+	}
+
+	if *groupStationsByFlag != "" {
+		if !slices.Contains(groupByOptions, strings.ToLower(*groupStationsByFlag)) {
+			return printError(exitUsage, "unknown grouping %q (expected one of %s)", *groupStationsByFlag, strings.Join(groupByOptions, ", "))
+		}
+		if structuredOutput || *noDecodeFlag {
+			return printError(exitUsage, "-group-stations-by can't be combined with -json, -csv, -html, -interval-stats or -no-decode")
+		}
+	}
+
+	if *nearestListFlag < 0 {
+		return printError(exitUsage, "-nearest-list must not be negative, got %d", *nearestListFlag)
+	}
+
+	if !countryCodeRegex.MatchString(*countryFlag) {
+		return printError(exitUsage, "invalid country code %q (expected two letters, e.g. gb)", *countryFlag)
+	}
+
+	var latLon Position
+	if *latLonFlag != "" {
+		var err error
+		if latLon, err = parseLatLon(*latLonFlag); err != nil {
+			return printError(exitUsage, "%v", err)
+		}
+		if *nearestFlag || len(flag.Args()) > 0 {
+			return printError(exitUsage, "-latlon can't be combined with -nearest or station codes")
+		}
+	}
+
+	if *parallelFlag < 0 {
+		return printError(exitUsage, "-parallel must not be negative, got %d", *parallelFlag)
+	}
+
+	if *timeoutFlag < 0 {
+		return printError(exitUsage, "-timeout must not be negative, got %s", *timeoutFlag)
+	}
+	httpClient.Timeout = *timeoutFlag
+
+	if *limitFlag < 0 {
+		return printError(exitUsage, "-limit must not be negative, got %d", *limitFlag)
+	}
+
+	displayOptions.DistanceUnits = strings.ToLower(*distanceUnitsFlag)
+	if !slices.Contains(distanceUnitOptions, displayOptions.DistanceUnits) {
+		return printError(exitUsage, "unknown distance units %q (expected one of %s)", *distanceUnitsFlag, strings.Join(distanceUnitOptions, ", "))
+	}
+
+	if *runwayFlag != "" {
+		if _, err := runwayHeading(*runwayFlag); err != nil {
+			return printError(exitUsage, "%v", err)
+		}
+		displayOptions.Runway = strings.ToUpper(*runwayFlag)
+	}
+
+	displayOptions.VisFraction = strings.ToLower(*visFractionFlag)
+	if !slices.Contains(visFractionOptions, displayOptions.VisFraction) {
+		return printError(exitUsage, "unknown visibility fraction style %q (expected one of %s)", *visFractionFlag, strings.Join(visFractionOptions, ", "))
+	}
+
+	// Alert rules need a decoded METAR to check
+	if *alertConfigFlag != "" {
+		if *noDecodeFlag || *tafOnly {
+			return printError(exitUsage, "-alert-config can't be combined with -no-decode or -taf")
+		}
+		alerts, err := loadAlertRules(*alertConfigFlag)
+		if err != nil {
+			return printError(exitError, "%v", err)
+		}
+		activeAlerts = alerts
+	}
+
+	// Metrics come from the decoded METAR
+	if *pushgatewayFlag != "" {
+		if *noDecodeFlag || *tafOnly {
+			return printError(exitUsage, "-pushgateway can't be combined with -no-decode or -taf")
+		}
+		gateway, err := newPushgateway(*pushgatewayFlag)
+		if err != nil {
+			return printError(exitUsage, "%v", err)
+		}
+		activePushgateway = gateway
+	}
+
+	// Unrecognized tokens only show up when decoding
+	if *failOnUnhandledFlag {
+		if *noDecodeFlag || *tafOnly {
+			return printError(exitUsage, "-fail-on-unhandled can't be combined with -no-decode or -taf")
+		}
+		activeUnhandled = &unhandledChecker{}
+	}
+
+	// Append reports to a history log on request
+	if *logFlag != "" {
+		reportLog, closer, err := openReportLog(*logFlag)
+		if err != nil {
+			return printError(exitError, "%v", err)
+		}
+		defer closer.Close()
+		activeReportLog = reportLog
+	}
+
+	// Document the decoded report fields and exit
+	if *listFieldsFlag {
+		printFieldList(os.Stdout)
+		return exitOK
+	}
+
+	// Delete the downloaded station database and exit
+	if *clearCacheFlag {
+		if *updateStationsFlag {
+			return printError(exitUsage, "-clear-cache can't be combined with -update-stations")
+		}
+		removed, err := ClearCache()
+		if err != nil {
+			return printError(exitError, "%v", err)
+		}
+		if len(removed) == 0 {
+			fmt.Println("Cache is already empty")
+		}
+		for _, path := range removed {
+			fmt.Printf("Removed %s\n", path)
+		}
+		return exitOK
+	}
+
+	// Refresh the offline station database and exit
+	if *updateStationsFlag {
+		count, path, err := UpdateStationDatabase()
+		if err != nil {
+			return printError(exitError, "%v", err)
+		}
+		fmt.Printf("Updated station database with %d stations (%s)\n", count, path)
+		return exitOK
+	}
+
+	// Serve decoded reports over HTTP until interrupted
+	if *serveFlag != "" {
+		if *offlineFlag || *data != "" || len(flag.Args()) > 0 {
+			return printError(exitUsage, "-serve can't be combined with -offline, -data or station codes")
+		}
+		if err := serve(*serveFlag); err != nil {
+			return printError(exitError, "%v", err)
+		}
+		return exitOK
+	}
+
 	var rawInput string
 	if data != nil {
 		rawInput = *data
@@ -32,126 +353,285 @@ func main() {
 
 	// First check stdin for piped data
 	stationCode, rawInput, stdinHasData, isStdinTAF := readFromStdin(rawInput)
+	if stdinHasData && *watchFlag > 0 {
+		return printError(exitUsage, "-watch re-fetches reports from aviationweather.gov; there's nothing to refresh for piped or -data input")
+	}
 
-	// If no stdin data, get station code from various sources
+	// If no stdin data, get station code from various sources, finding the nearest airport
+	// for -nearest, -latlon, AUTO or a postal code
+	nearestOpts := NearestOptions{Radius: *radiusFlag, ListCount: *nearestListFlag, PreferTAF: *nearestTAFFlag, Offline: *offlineFlag}
+	var stationCodes []string // Every station code when several are given
 	if !stdinHasData {
 		var err error
 
-		// Check if -nearest flag is used
-		if *nearestFlag {
-			stationCode, err = ProcessAutoCommand(*radiusFlag)
+		// Check if -latlon or -nearest flag is used
+		if *latLonFlag != "" {
+			stationCode, err = ProcessLatLon(latLon, nearestOpts)
+			if err != nil {
+				return printError(exitError, "%v", err)
+			}
+		} else if *nearestFlag || (*nearestListFlag > 0 || *nearestTAFFlag) && len(flag.Args()) == 0 {
+			stationCode, err = ProcessAutoCommand(nearestOpts)
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
-				return
+				return printError(exitError, "%v", err)
 			}
 		} else {
 			// Try command line args first
 			remainingArgs := flag.Args()
-			if len(remainingArgs) > 0 {
+			if len(remainingArgs) > 1 {
+				// Several ICAO codes, e.g. the airports along a route
+				for _, arg := range remainingArgs {
+					code := resolveIATACode(strings.ToUpper(strings.TrimSpace(arg)))
+					if err := validateStationCode(code, *strictICAOFlag); err != nil {
+						return printError(exitError, "%v", err)
+					}
+					stationCodes = append(stationCodes, code)
+				}
+				stationCode = stationCodes[0]
+			} else if len(remainingArgs) > 0 {
 				input := strings.ToUpper(strings.TrimSpace(remainingArgs[0]))
 
 				// Check for special cases before calling the standard function
 				if input == "AUTO" {
-					stationCode, err = ProcessAutoCommand(*radiusFlag)
+					stationCode, err = ProcessAutoCommand(nearestOpts)
 					if err != nil {
-						fmt.Printf("Error: %v\n", err)
-						return
+						return printError(exitError, "%v", err)
 					}
-				} else if zipRegex.MatchString(input) {
-					stationCode, err = ProcessZipcode(input, *radiusFlag)
+				} else if country, postalCode, ok := parsePostalCode(input, *countryFlag); ok {
+					stationCode, err = ProcessZipcode(country, postalCode, nearestOpts)
 					if err != nil {
-						fmt.Printf("Error: %v\n", err)
-						return
+						return printError(exitError, "%v", err)
 					}
 				} else {
 					// Use existing function for regular ICAO codes
-					stationCode, err = getStationCodeFromArgs(remainingArgs)
+					stationCode, err = getStationCodeFromArgs(remainingArgs, *strictICAOFlag)
 					if err != nil {
-						fmt.Printf("Error: %v\n", err)
-						return
+						return printError(exitError, "%v", err)
 					}
 				}
 			} else {
 				// Prompt the user
 				stationCode, err = promptForStationCode()
 				if err != nil {
-					fmt.Printf("Error: %v\n", err)
-					return
+					return printError(exitError, "%v", err)
 				}
 
 				// Check for special cases after getting user input
 				if stationCode == "AUTO" {
-					stationCode, err = ProcessAutoCommand(*radiusFlag)
+					stationCode, err = ProcessAutoCommand(nearestOpts)
 					if err != nil {
-						fmt.Printf("Error: %v\n", err)
-						return
+						return printError(exitError, "%v", err)
 					}
-				} else if zipRegex.MatchString(stationCode) {
-					stationCode, err = ProcessZipcode(stationCode, *radiusFlag)
+				} else if country, postalCode, ok := parsePostalCode(stationCode, *countryFlag); ok {
+					stationCode, err = ProcessZipcode(country, postalCode, nearestOpts)
 					if err != nil {
-						fmt.Printf("Error: %v\n", err)
-						return
+						return printError(exitError, "%v", err)
+					}
+				} else {
+					stationCode = resolveIATACode(stationCode)
+					if err := validateStationCode(stationCode, *strictICAOFlag); err != nil {
+						return printError(exitError, "%v", err)
 					}
 				}
 			}
 		}
 	}
 
-	// Rest of your code unchanged...
-	var siteInfo SiteInfo
-	var siteInfoFetched bool
-
-	if !*noDecodeFlag {
-		fetchedSiteInfo, err := FetchSiteInfo(stationCode)
-		if err != nil {
-			fmt.Printf("Warning: Could not fetch site info for %s: %v\n", stationCode, err)
-		} else {
-			siteInfo = fetchedSiteInfo
-			siteInfoFetched = true
+	// Unwrap AWC API JSON into the raw report and the site info it carries
+	var jsonSiteInfo *wx.SiteInfo
+	switch *inputFormatFlag {
+	case "raw":
+	case "json":
+		if stdinHasData {
+			report, err := parseAWCJSON(rawInput)
+			if err != nil {
+				return printError(exitError, "%v", err)
+			}
+			stationCode = report.IcaoID
+			rawInput = report.RawReport()
+			isStdinTAF = report.IsTAF()
+			info := report.SiteInfo()
+			jsonSiteInfo = &info
 		}
+	default:
+		return printError(exitUsage, "unknown input format %q (expected raw or json)", *inputFormatFlag)
 	}
 
-	// Handle stdin data based on flags and auto-detection
-	if stdinHasData {
-		// If offline mode is enabled, get station info from embedded file
-		if *offlineFlag {
-			// Only attempt to load site info if we don't already have it
-			if !siteInfoFetched {
-				offlineSiteInfo, err := LoadEmbeddedStationInfo(stationCode)
-				if err != nil {
-					fmt.Printf("Warning: Could not load offline site info for %s: %v\n", stationCode, err)
-				} else {
-					siteInfo = offlineSiteInfo
-					siteInfoFetched = true
-				}
+	// Offline lookups parse the embedded station database; get a head start on it
+	if *offlineFlag {
+		prewarmStationData()
+	}
+
+	// Resolve site info in the background so a slow stationinfo endpoint
+	// doesn't hold up the weather fetch
+	offline := stdinHasData && *offlineFlag
+	resolveSiteInfo := func(code string) (wx.SiteInfo, error) {
+		// Use the name from JSON input when it had one
+		if jsonSiteInfo != nil && jsonSiteInfo.Name != code {
+			return *jsonSiteInfo, nil
+		}
+		info, err := FetchSiteInfo(code)
+		if err != nil && offline {
+			// If offline mode is enabled, get station info from embedded file
+			return LoadEmbeddedStationInfo(code)
+		}
+
+		// The stationinfo endpoint doesn't give coordinates; take them from the station database
+		if err == nil && displayOptions.ApproxLocalTime && !info.HasCoordinates() {
+			if station, err := findEmbeddedStation(code); err == nil {
+				info.Latitude, info.Longitude = station.Lat, station.Lon
 			}
 		}
+		return info, err
+	}
+
+	// Hand input we can't decode on unchanged, so another decoder can try it
+	asTAF := decodeAsTAF(isStdinTAF, *metarOnly, *tafOnly)
+	if stdinHasData && *passthroughFlag && isUndecodableInput(rawInput, asTAF) {
+		fmt.Println(rawInput)
+		return exitError
+	}
 
+	// The -json document is shaped by what the run shows, not by which reports decode
+	if stdinHasData {
+		activeJSONOutput.expect(!asTAF, asTAF, !asTAF && len(splitMETARReports(rawInput)) > 1)
+	} else {
+		activeJSONOutput.expect(!*tafOnly, !*metarOnly, len(stationCodes) > 1)
+	}
+
+	var siteInfo *siteInfoLookup
+	if !*noDecodeFlag {
+		siteInfo = startSiteInfoLookup(stationCode, *siteInfoTimeoutFlag, resolveSiteInfo)
+	}
+
+	// Handle stdin data based on flags and auto-detection
+	var errs []error
+	if stdinHasData {
 		// Process data according to flags, overriding auto-detection if flags are specified
-		if *tafOnly || (isStdinTAF && !*metarOnly) {
+		if asTAF {
 			// Process as TAF (either forced with -taf flag or detected as TAF and not forced to METAR)
-			processTAF(stationCode, rawInput, true, *noRawFlag, *noDecodeFlag, siteInfo, siteInfoFetched, *offlineFlag)
-		} else if *metarOnly || !isStdinTAF {
-			// Process as METAR (either forced with -metar flag or detected as METAR)
-			processMETAR(stationCode, rawInput, true, *noRawFlag, *noDecodeFlag, siteInfo, siteInfoFetched, *offlineFlag)
+			errs = append(errs, processTAF(context.Background(), os.Stdout, stationCode, rawInput, true, *noRawFlag, *noDecodeFlag, siteInfo, *offlineFlag))
+		} else {
+			// Process as METAR (either forced with -metar flag or detected as METAR),
+			// one report per line when several are piped in
+			reports := splitMETARReports(rawInput)
+			if len(reports) <= 1 {
+				errs = append(errs, processMETAR(context.Background(), os.Stdout, stationCode, rawInput, true, *noRawFlag, *noDecodeFlag, siteInfo, *offlineFlag))
+			} else {
+				groups := []reportGroup{{Reports: newestReports(reports, *limitFlag)}}
+				if *groupStationsByFlag != "" {
+					groups = groupReportsByCategory(groups[0].Reports)
+				}
+
+				lookups := map[string]*siteInfoLookup{stationCode: siteInfo}
+				for g, group := range groups {
+					if group.Heading != "" {
+						if g > 0 {
+							fmt.Print("\n")
+						}
+						fmt.Print(formatGroupHeading(group))
+					}
+
+					for i, report := range group.Reports {
+						if i > 0 && !structuredOutput {
+							fmt.Print("\n----------------------------------\n\n")
+						}
+
+						// Each station's site info is only looked up once
+						code := reportStationCode(report)
+						if _, ok := lookups[code]; !ok && !*noDecodeFlag {
+							lookups[code] = startSiteInfoLookup(code, *siteInfoTimeoutFlag, resolveSiteInfo)
+						}
+						errs = append(errs, processMETAR(context.Background(), os.Stdout, code, report, true, *noRawFlag, *noDecodeFlag, lookups[code], *offlineFlag))
+					}
+				}
+			}
 		}
 	} else {
 		// No stdin data, fetch from web based on flags
-
-		// Fetch and display METAR if requested or by default
-		if !*tafOnly {
-			processMETAR(stationCode, "", false, *noRawFlag, *noDecodeFlag, siteInfo, siteInfoFetched, *offlineFlag)
+		lookups := map[string]*siteInfoLookup{stationCode: siteInfo}
+		for _, code := range stationCodes {
+			if _, ok := lookups[code]; !ok && !*noDecodeFlag && *parallelFlag == 0 {
+				lookups[code] = startSiteInfoLookup(code, *siteInfoTimeoutFlag, resolveSiteInfo)
+			}
 		}
 
-		// Fetch and display TAF if requested or by default
-		if !*metarOnly {
-			// Add a line break if we also displayed METAR
+		fetchReports := func(ctx context.Context, w io.Writer) []error {
+			if len(stationCodes) > 1 && *parallelFlag > 0 {
+				// Site info is looked up in the worker pool along with the reports
+				lookupSiteInfo := func(code string) *siteInfoLookup {
+					if code == stationCode || *noDecodeFlag {
+						return siteInfo
+					}
+					return startSiteInfoLookup(code, *siteInfoTimeoutFlag, resolveSiteInfo)
+				}
+				return processStationsParallel(ctx, w, os.Stderr, stationCodes, *parallelFlag, !*tafOnly, !*metarOnly, *noRawFlag, *noDecodeFlag, lookupSiteInfo, *offlineFlag, structuredOutput, *groupStationsByFlag)
+			}
+			if len(stationCodes) > 1 {
+				return processStations(ctx, w, stationCodes, !*tafOnly, !*metarOnly, *noRawFlag, *noDecodeFlag, lookups, *offlineFlag, structuredOutput, *groupStationsByFlag)
+			}
+
+			var errs []error
+
+			// Fetch and display METAR if requested or by default
 			if !*tafOnly {
-				fmt.Print("\n----------------------------------\n\n")
+				errs = append(errs, processMETAR(ctx, w, stationCode, "", false, *noRawFlag, *noDecodeFlag, siteInfo, *offlineFlag))
+			}
+
+			// Fetch and display TAF if requested or by default
+			if !*metarOnly {
+				// Add a line break if we also displayed METAR
+				if !*tafOnly && !structuredOutput {
+					fmt.Fprint(w, "\n----------------------------------\n\n")
+				}
+
+				// Fetch and process TAF from the web
+				errs = append(errs, processTAF(ctx, w, stationCode, "", false, *noRawFlag, *noDecodeFlag, siteInfo, *offlineFlag))
 			}
+			return errs
+		}
+
+		// Keep refreshing with -watch until interrupted; failed fetches were already
+		// printed and are retried on the next refresh. An interrupt also abandons a
+		// fetch in progress rather than waiting out its retries.
+		if *watchFlag > 0 {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
 
-			// Fetch and process TAF from the web
-			processTAF(stationCode, "", false, *noRawFlag, *noDecodeFlag, siteInfo, siteInfoFetched, *offlineFlag)
+			activeWatchedReports = &watchedReports{}
+			watchReports(ctx, os.Stdout, time.Duration(*watchFlag)*time.Second, *onlyChangedFlag, func(ctx context.Context, w io.Writer) string {
+				fetchReports(ctx, w)
+				return activeWatchedReports.take()
+			})
+			return exitOK
 		}
+
+		errs = append(errs, fetchReports(context.Background(), os.Stdout)...)
+	}
+
+	if err := activeJSONOutput.write(os.Stdout); err != nil {
+		errs = append(errs, err)
+		errorColor.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+	if err := activeIntervalStats.write(os.Stdout); err != nil {
+		errs = append(errs, err)
+		errorColor.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+	if err := activePushgateway.push(context.Background()); err != nil {
+		errs = append(errs, err)
+		errorColor.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+
+	unhandled := activeUnhandled.report(os.Stderr)
+
+	// A fired rule is the answer a go/no-go check is after, even if another report failed
+	if activeAlerts.report(os.Stderr) {
+		return exitAlert
+	}
+
+	// Errors were already printed where they happened; only the exit code is left
+	if errors.Join(errs...) != nil || unhandled {
+		return exitError
 	}
+	return exitOK
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,7 +9,12 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rmitchellscott/WxCraft/wx"
 )
 
 // Position represents a geographic coordinate
@@ -19,18 +25,42 @@ type Position struct {
 
 // Station represents an airport or weather station from the AWC API
 type Station struct {
-	ICAO      string  `json:"icaoId"`
-	Name      string  `json:"name"`
-	State     string  `json:"state"`
-	Country   string  `json:"country"`
-	Latitude  float64 `json:"lat"`
-	Longitude float64 `json:"lon"`
-	Elevation int     `json:"elev"`
+	ICAO      string   `json:"icaoId"`
+	Name      string   `json:"name"`
+	State     string   `json:"state"`
+	Country   string   `json:"country"`
+	Latitude  float64  `json:"lat"`
+	Longitude float64  `json:"lon"`
+	Elevation int      `json:"elev"`
+	SiteType  []string `json:"siteType"` // Reports issued for the station (e.g. METAR, TAF), when known
+}
+
+// NearestOptions controls how the nearest airport is found
+type NearestOptions struct {
+	Radius    float64 // Search radius, in the display distance units
+	ListCount int     // How many of the nearest airports to list first; 0 or 1 lists none
+	PreferTAF bool    // Pick the nearest airport that issues TAFs rather than the nearest of all
+	Offline   bool    // Search the embedded station database instead of the stationinfo API
 }
 
+// stationFinder finds the stations within a radius in miles of a position
+type stationFinder func(position Position, radiusMiles float64) ([]Station, error)
+
+// maxTAFProbes is how many TAFs NearestOptions.PreferTAF fetches at most to find an airport
+// that issues them, when the station info doesn't say
+const maxTAFProbes = 5
+
 // Regular expression for matching US zipcodes
 var zipRegex = regexp.MustCompile(`^\d{5}(-\d{4})?$`)
 
+// Regular expressions for matching postal codes in any country, and ones given with their
+// country (e.g. GB:SW1A or DE:10115)
+var (
+	postalCodeRegex        = regexp.MustCompile(`^[A-Z0-9][A-Z0-9 -]{1,9}$`)
+	countryPostalCodeRegex = regexp.MustCompile(`^([A-Z]{2}):([A-Z0-9][A-Z0-9 -]{1,9})$`)
+	countryCodeRegex       = regexp.MustCompile(`^[A-Za-z]{2}$`)
+)
+
 // degreesToRadians converts degrees to radians
 func degreesToRadians(degrees float64) float64 {
 	return degrees * math.Pi / 180
@@ -57,6 +87,37 @@ func calculateDistance(pos1, pos2 Position) float64 {
 	return distance
 }
 
+// Bearing returns the initial great-circle bearing from one position to another, in degrees
+// clockwise from true north (0 up to 360). Crossing the antimeridian takes the short way
+// round, and the bearing between identical positions is 0.
+func Bearing(from, to Position) float64 {
+	if from == to {
+		return 0
+	}
+
+	lat1 := degreesToRadians(from.Latitude)
+	lat2 := degreesToRadians(to.Latitude)
+	dLon := degreesToRadians(to.Longitude - from.Longitude)
+
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	bearing := math.Atan2(y, x) * 180 / math.Pi
+
+	return math.Mod(bearing+360, 360)
+}
+
+// CompassPoint names the 16-point compass direction of a bearing in degrees (e.g. 73 is ENE)
+func CompassPoint(bearing float64) string {
+	points := []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+	return points[int(math.Round(math.Mod(bearing+360, 360)/22.5))%len(points)]
+}
+
+// formatBearing renders a bearing in degrees with its compass point, e.g. "bearing 073° ENE"
+func formatBearing(bearing float64) string {
+	degrees := math.Mod(math.Round(bearing), 360)
+	return fmt.Sprintf("bearing %03.0f° %s", degrees, CompassPoint(bearing))
+}
+
 // createBoundingBox creates a bounding box around a position with the given radius in miles
 func createBoundingBox(pos Position, radiusMiles float64) (minLat, minLon, maxLat, maxLon float64) {
 	// Approximate degrees latitude per mile (roughly 1 degree = 69 miles)
@@ -96,7 +157,7 @@ func findNearbyStations(position Position, radiusMiles float64) ([]Station, erro
 	u.RawQuery = q.Encode()
 
 	// Make the request
-	resp, err := http.Get(u.String())
+	resp, err := httpClient.Get(u.String())
 	if err != nil {
 		return nil, fmt.Errorf("failed to query Aviation Weather API: %w", err)
 	}
@@ -120,56 +181,136 @@ func findNearbyStations(position Position, radiusMiles float64) ([]Station, erro
 	return stations, nil
 }
 
+// findEmbeddedNearbyStations finds the airports within a radius in miles of a position in
+// the embedded station database, without going to the network. Entries without an
+// ICAO-style code, such as buoys, are left out.
+func findEmbeddedNearbyStations(position Position, radiusMiles float64) ([]Station, error) {
+	stations, err := loadStations()
+	if err != nil {
+		return nil, err
+	}
+
+	var nearby []Station
+	seen := make(map[string]bool)
+	for _, station := range stations {
+		code := station.ICAOId
+		if len(code) != 4 || code[0] < 'A' || code[0] > 'Z' || seen[code] {
+			continue
+		}
+		seen[code] = true
+
+		if calculateDistance(position, Position{Latitude: station.Lat, Longitude: station.Lon}) > radiusMiles {
+			continue
+		}
+		nearby = append(nearby, Station{
+			ICAO:      code,
+			Name:      station.Site,
+			State:     station.State,
+			Country:   station.Country,
+			Latitude:  station.Lat,
+			Longitude: station.Lon,
+			Elevation: station.Elev,
+		})
+	}
+	return nearby, nil
+}
+
+// StationDistance is a station found near a position, with its distance in miles and the
+// bearing to it in degrees true
+type StationDistance struct {
+	Station  Station
+	Distance float64
+	Bearing  float64
+}
+
 // GetNearestAirportICAO finds the nearest airport's ICAO code
 func GetNearestAirportICAO(latitude, longitude float64, searchRadiusMiles float64) (string, float64, error) {
+	nearest, err := GetNearestAirports(latitude, longitude, searchRadiusMiles, 1)
+	if err != nil {
+		return "", 0, err
+	}
+	return nearest[0].Station.ICAO, nearest[0].Distance, nil
+}
+
+// GetNearestAirports finds the n airports closest to a position within the search radius,
+// nearest first. Fewer are returned when there aren't n within the radius.
+func GetNearestAirports(latitude, longitude float64, searchRadiusMiles float64, n int) ([]StationDistance, error) {
+	return nearestAirports(findNearbyStations, latitude, longitude, searchRadiusMiles, n)
+}
+
+// nearestAirports finds the n airports closest to a position within the search radius,
+// nearest first, among the stations found by find
+func nearestAirports(find stationFinder, latitude, longitude float64, searchRadiusMiles float64, n int) ([]StationDistance, error) {
 	position := Position{
 		Latitude:  latitude,
 		Longitude: longitude,
 	}
 
 	// Find nearby airports
-	stations, err := findNearbyStations(position, searchRadiusMiles)
+	stations, err := find(position, searchRadiusMiles)
 	if err != nil {
-		return "", 0, err
+		return nil, err
 	}
 
 	if len(stations) == 0 {
-		return "", 0, fmt.Errorf("no airports found within %.1f miles", searchRadiusMiles)
+		return nil, fmt.Errorf("no airports found within %s", formatDistance(searchRadiusMiles))
 	}
 
-	// Calculate distances and sort
-	type stationDistance struct {
-		station  Station
-		distance float64
-	}
+	return nearestStations(position, stations, n), nil
+}
 
-	var stationsWithDistance []stationDistance
+// nearestStations returns the n stations closest to position, nearest first
+func nearestStations(position Position, stations []Station, n int) []StationDistance {
+	var stationsWithDistance []StationDistance
 	for _, station := range stations {
 		stationPos := Position{
 			Latitude:  station.Latitude,
 			Longitude: station.Longitude,
 		}
-		distance := calculateDistance(position, stationPos)
-		stationsWithDistance = append(stationsWithDistance, stationDistance{
-			station:  station,
-			distance: distance,
+		stationsWithDistance = append(stationsWithDistance, StationDistance{
+			Station:  station,
+			Distance: calculateDistance(position, stationPos),
+			Bearing:  Bearing(position, stationPos),
 		})
 	}
 
 	// Sort by distance
 	sort.Slice(stationsWithDistance, func(i, j int) bool {
-		return stationsWithDistance[i].distance < stationsWithDistance[j].distance
+		return stationsWithDistance[i].Distance < stationsWithDistance[j].Distance
 	})
 
-	if len(stationsWithDistance) == 0 {
-		return "", 0, fmt.Errorf("failed to find nearest airport")
+	return stationsWithDistance[:min(n, len(stationsWithDistance))]
+}
+
+// formatNearestList renders a numbered list of nearby stations, e.g.
+// "1. KJFK  John F Kennedy Intl  12.3 miles, bearing 045° NE"
+func formatNearestList(nearest []StationDistance) string {
+	var sb strings.Builder
+	for i, s := range nearest {
+		fmt.Fprintf(&sb, "%d. %-4s  %s  %s, %s\n", i+1, s.Station.ICAO, s.Station.Name,
+			formatDistance(s.Distance), formatBearing(s.Bearing))
 	}
+	return sb.String()
+}
 
-	return stationsWithDistance[0].station.ICAO, stationsWithDistance[0].distance, nil
+// radiusInMiles converts a search radius given in the display distance units to miles
+func radiusInMiles(radius float64) float64 {
+	if displayOptions.DistanceUnits == "km" {
+		return wx.KMToMiles(radius)
+	}
+	return radius
+}
+
+// formatDistance formats a distance in miles in the display distance units
+func formatDistance(miles float64) string {
+	if displayOptions.DistanceUnits == "km" {
+		return fmt.Sprintf("%.1f km", wx.MilesToKM(miles))
+	}
+	return fmt.Sprintf("%.1f miles", miles)
 }
 
 // ProcessAutoCommand handles the AUTO command to find the nearest airport
-func ProcessAutoCommand(radiusMiles float64) (string, error) {
+func ProcessAutoCommand(opts NearestOptions) (string, error) {
 	fmt.Println("Finding nearest airport to your location...")
 	location, err := GetLocation()
 	if err != nil {
@@ -180,46 +321,140 @@ func ProcessAutoCommand(radiusMiles float64) (string, error) {
 		location.City, location.Country,
 		location.Latitude, location.Longitude)
 
-	// Get the nearest airport ICAO code
-	fmt.Printf("Searching for airports within %.1f miles...\n", radiusMiles)
-	icaoCode, distance, err := GetNearestAirportICAO(
-		location.Latitude,
-		location.Longitude,
-		radiusMiles,
-	)
+	return findNearestAirport(location.Latitude, location.Longitude, opts)
+}
 
+// ProcessLatLon finds the nearest airport to a position given on the command line
+func ProcessLatLon(position Position, opts NearestOptions) (string, error) {
+	fmt.Printf("Finding nearest airport to %.4f, %.4f...\n", position.Latitude, position.Longitude)
+	return findNearestAirport(position.Latitude, position.Longitude, opts)
+}
+
+// parseLatLon parses a "latitude,longitude" pair in decimal degrees, e.g. "40.64,-73.78"
+func parseLatLon(s string) (Position, error) {
+	latText, lonText, ok := strings.Cut(s, ",")
+	if !ok {
+		return Position{}, fmt.Errorf("invalid coordinates %q (expected latitude,longitude, e.g. 40.64,-73.78)", s)
+	}
+
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(latText), 64)
 	if err != nil {
-		return "", err
+		return Position{}, fmt.Errorf("invalid latitude %q in coordinates %q", latText, s)
+	}
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(lonText), 64)
+	if err != nil {
+		return Position{}, fmt.Errorf("invalid longitude %q in coordinates %q", lonText, s)
+	}
+
+	if math.IsNaN(latitude) || latitude < -90 || latitude > 90 {
+		return Position{}, fmt.Errorf("latitude %s out of range (expected -90 to 90)", strings.TrimSpace(latText))
+	}
+	if math.IsNaN(longitude) || longitude < -180 || longitude > 180 {
+		return Position{}, fmt.Errorf("longitude %s out of range (expected -180 to 180)", strings.TrimSpace(lonText))
 	}
 
-	fmt.Printf("Nearest airport: %s (%.1f miles away)\n", icaoCode, distance)
-	return icaoCode, nil
+	return Position{Latitude: latitude, Longitude: longitude}, nil
 }
 
-// ProcessZipcode handles the zipcode input to find the nearest airport
-func ProcessZipcode(zipcode string, radiusMiles float64) (string, error) {
-	fmt.Printf("Looking up location for zipcode %s...\n", zipcode)
-	location, err := GetLocationByZipcode(zipcode)
+// parsePostalCode reports whether input is a postal code to find the nearest airport to,
+// and which country it's in. A country prefix (e.g. GB:SW1A) always makes one. Otherwise, in
+// the US (the default country) only zip codes are; in any other country set with -country,
+// input is read as a postal code of that country unless it's an ICAO or IATA code in the
+// station database.
+func parsePostalCode(input, country string) (string, string, bool) {
+	if matches := countryPostalCodeRegex.FindStringSubmatch(input); matches != nil {
+		return matches[1], matches[2], true
+	}
+
+	country = strings.ToUpper(country)
+	if country == "US" {
+		return country, input, zipRegex.MatchString(input)
+	}
+	if _, err := findEmbeddedStation(input); err == nil {
+		return country, input, false
+	}
+	if _, ok := ResolveStation(input); ok {
+		return country, input, false
+	}
+	return country, input, postalCodeRegex.MatchString(input)
+}
+
+// ProcessZipcode handles the zipcode input, a postal code in the given country, to find
+// the nearest airport
+func ProcessZipcode(country, zipcode string, opts NearestOptions) (string, error) {
+	fmt.Printf("Looking up location for postal code %s in %s...\n", zipcode, strings.ToUpper(country))
+	location, err := GetLocationByZipcode(country, zipcode)
 	if err != nil {
-		return "", fmt.Errorf("failed to get location for zipcode: %v", err)
+		return "", fmt.Errorf("failed to get location for postal code: %v", err)
 	}
 
-	fmt.Printf("Zipcode location: %s, %s, %s (%.4f, %.4f)\n",
+	fmt.Printf("Postal code location: %s, %s, %s (%.4f, %.4f)\n",
 		location.City, location.Region, location.Country,
 		location.Latitude, location.Longitude)
 
-	// Get the nearest airport ICAO code
-	fmt.Printf("Searching for airports within %.1f miles...\n", radiusMiles)
-	icaoCode, distance, err := GetNearestAirportICAO(
-		location.Latitude,
-		location.Longitude,
-		radiusMiles,
-	)
+	return findNearestAirport(location.Latitude, location.Longitude, opts)
+}
+
+// findNearestAirport searches for airports around a location and returns the nearest one's
+// ICAO code, or the nearest one issuing TAFs with opts.PreferTAF. With a positive ListCount,
+// that many of the nearest airports are listed first. With opts.Offline, the embedded
+// station database is searched instead of the stationinfo API.
+func findNearestAirport(latitude, longitude float64, opts NearestOptions) (string, error) {
+	radiusMiles := radiusInMiles(opts.Radius)
+	find, probes := stationFinder(findNearbyStations), maxTAFProbes
+	if opts.Offline {
+		// Without the network there's no TAF to fetch to check for one
+		find, probes = findEmbeddedNearbyStations, 0
+		fmt.Printf("Searching the station database for airports within %s...\n", formatDistance(radiusMiles))
+	} else {
+		fmt.Printf("Searching for airports within %s...\n", formatDistance(radiusMiles))
+	}
 
+	count := max(opts.ListCount, 1)
+	if opts.PreferTAF {
+		count = math.MaxInt
+	}
+	nearest, err := nearestAirports(find, latitude, longitude, radiusMiles, count)
 	if err != nil {
 		return "", err
 	}
 
-	fmt.Printf("Nearest airport: %s (%.1f miles away)\n", icaoCode, distance)
-	return icaoCode, nil
+	if opts.ListCount > 0 {
+		fmt.Println("Nearest airports:")
+		fmt.Print(formatNearestList(nearest[:min(opts.ListCount, len(nearest))]))
+	}
+
+	chosen, label := nearest[0], "Nearest airport"
+	if opts.PreferTAF {
+		if station, ok := pickTAFStation(nearest, probes); ok {
+			chosen, label = station, "Nearest airport with a TAF"
+		} else {
+			warningColor.Printf("Warning: No airport with a TAF found nearby; using the nearest airport\n")
+		}
+	}
+
+	fmt.Printf("%s: %s (%s away, %s)\n", label, chosen.Station.ICAO,
+		formatDistance(chosen.Distance), formatBearing(chosen.Bearing))
+	return chosen.Station.ICAO, nil
+}
+
+// pickTAFStation returns the first of the candidates, nearest first, that issues TAFs: one
+// whose station info lists TAF, or, when the station info doesn't say, whose TAF can be
+// fetched. At most maxProbes TAFs are fetched.
+func pickTAFStation(candidates []StationDistance, maxProbes int) (StationDistance, bool) {
+	probes := 0
+	for _, candidate := range candidates {
+		if slices.Contains(candidate.Station.SiteType, "TAF") {
+			return candidate, true
+		}
+		if len(candidate.Station.SiteType) > 0 || probes >= maxProbes {
+			continue
+		}
+
+		probes++
+		if _, err := fetchStationTAF(context.Background(), candidate.Station.ICAO); err == nil {
+			return candidate, true
+		}
+	}
+	return StationDistance{}, false
 }
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBearing(t *testing.T) {
+	t.Parallel()
+
+	origin := Position{Latitude: 40, Longitude: -74}
+	assert.InDelta(t, 0, Bearing(origin, Position{Latitude: 41, Longitude: -74}), 0.01)
+	assert.InDelta(t, 180, Bearing(origin, Position{Latitude: 39, Longitude: -74}), 0.01)
+	assert.InDelta(t, 90, Bearing(origin, Position{Latitude: 40, Longitude: -73}), 0.5)
+	assert.InDelta(t, 270, Bearing(origin, Position{Latitude: 40, Longitude: -75}), 0.5)
+
+	// New York to Los Angeles and back
+	nyc := Position{Latitude: 40.7128, Longitude: -74.0060}
+	la := Position{Latitude: 34.0522, Longitude: -118.2437}
+	assert.InDelta(t, 273.7, Bearing(nyc, la), 0.1)
+	assert.InDelta(t, 65.9, Bearing(la, nyc), 0.1)
+
+	// Across the antimeridian the short way round, e.g. Fiji to Samoa
+	assert.InDelta(t, 90, Bearing(Position{Latitude: 0, Longitude: 179}, Position{Latitude: 0, Longitude: -179}), 0.01)
+	assert.InDelta(t, 270, Bearing(Position{Latitude: 0, Longitude: -179}, Position{Latitude: 0, Longitude: 179}), 0.01)
+
+	assert.Zero(t, Bearing(nyc, nyc))
+}
+
+func TestCompassPoint(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "N", CompassPoint(0))
+	assert.Equal(t, "N", CompassPoint(359))
+	assert.Equal(t, "ENE", CompassPoint(73))
+	assert.Equal(t, "SW", CompassPoint(225))
+	assert.Equal(t, "NNW", CompassPoint(331))
+	assert.Equal(t, "bearing 073° ENE", formatBearing(73.4))
+	assert.Equal(t, "bearing 000° N", formatBearing(359.7))
+}
+
+func TestNearestStations(t *testing.T) {
+	t.Parallel()
+
+	jfk := Position{Latitude: 40.6398, Longitude: -73.7789}
+	stations := []Station{
+		{ICAO: "KEWR", Name: "Newark Liberty Intl", Latitude: 40.6925, Longitude: -74.1687},
+		{ICAO: "KJFK", Name: "New York/JF Kennedy Intl", Latitude: 40.6398, Longitude: -73.7789},
+		{ICAO: "KLGA", Name: "New York/LaGuardia", Latitude: 40.7769, Longitude: -73.8740},
+	}
+
+	nearest := nearestStations(jfk, stations, 2)
+	require.Len(t, nearest, 2)
+	assert.Equal(t, "KJFK", nearest[0].Station.ICAO)
+	assert.Zero(t, nearest[0].Distance)
+	assert.Equal(t, "KLGA", nearest[1].Station.ICAO)
+	assert.InDelta(t, 10.7, nearest[1].Distance, 0.1)
+	assert.Equal(t, "NNW", CompassPoint(nearest[1].Bearing))
+
+	// Asking for more than there are returns them all
+	assert.Len(t, nearestStations(jfk, stations, 10), 3)
+
+	assert.Equal(t, "1. KJFK  New York/JF Kennedy Intl  0.0 miles, bearing 000° N\n"+
+		"2. KLGA  New York/LaGuardia  10.7 miles, bearing 332° NNW\n", formatNearestList(nearest))
+}
+
+func TestPickTAFStation(t *testing.T) {
+	savedTAF := fetchStationTAF
+	t.Cleanup(func() { fetchStationTAF = savedTAF })
+
+	var probed []string
+	fetchStationTAF = func(_ context.Context, code string) (string, error) {
+		probed = append(probed, code)
+		if code == "KTAF" {
+			return "TAF KTAF 081720Z 0818/0924 27010KT P6SM SCT050", nil
+		}
+		return "", errors.New("no TAF data available")
+	}
+
+	candidate := func(code string, siteType ...string) StationDistance {
+		return StationDistance{Station: Station{ICAO: code, SiteType: siteType}}
+	}
+
+	// A station listed as a TAF site is picked without fetching anything
+	station, ok := pickTAFStation([]StationDistance{candidate("KAWO", "METAR"), candidate("KBFI", "METAR", "TAF")}, maxTAFProbes)
+	require.True(t, ok)
+	assert.Equal(t, "KBFI", station.Station.ICAO)
+	assert.Empty(t, probed)
+
+	// Otherwise the stations whose site type isn't known are probed in order
+	station, ok = pickTAFStation([]StationDistance{candidate("K0S9"), candidate("KAWO", "METAR"), candidate("KTAF")}, maxTAFProbes)
+	require.True(t, ok)
+	assert.Equal(t, "KTAF", station.Station.ICAO)
+	assert.Equal(t, []string{"K0S9", "KTAF"}, probed)
+
+	// Probing stops after maxTAFProbes stations
+	probed = nil
+	var candidates []StationDistance
+	for _, code := range []string{"K001", "K002", "K003", "K004", "K005", "KTAF"} {
+		candidates = append(candidates, candidate(code))
+	}
+	_, ok = pickTAFStation(candidates, maxTAFProbes)
+	assert.False(t, ok)
+	assert.Len(t, probed, maxTAFProbes)
+
+	// Without probes only the station info counts
+	probed = nil
+	_, ok = pickTAFStation(candidates, 0)
+	assert.False(t, ok)
+	assert.Empty(t, probed)
+}
+
+func TestFindEmbeddedNearbyStations(t *testing.T) {
+	t.Parallel()
+
+	jfk := Position{Latitude: 40.6398, Longitude: -73.7787}
+	stations, err := findEmbeddedNearbyStations(jfk, 15)
+	require.NoError(t, err)
+
+	// The same distance and sort logic as the online search
+	nearest, err := nearestAirports(func(Position, float64) ([]Station, error) { return stations, nil }, jfk.Latitude, jfk.Longitude, 15, 2)
+	require.NoError(t, err)
+	require.Len(t, nearest, 2)
+	assert.Equal(t, "KJFK", nearest[0].Station.ICAO)
+	assert.Less(t, nearest[0].Distance, 1.0)
+	assert.LessOrEqual(t, nearest[0].Distance, nearest[1].Distance)
+
+	var codes []string
+	for _, station := range stations {
+		codes = append(codes, station.ICAO)
+		assert.LessOrEqual(t, calculateDistance(jfk, Position{Latitude: station.Latitude, Longitude: station.Longitude}), 15.0)
+		assert.Len(t, station.ICAO, 4)
+	}
+	assert.Contains(t, codes, "KLGA")
+
+	// Nothing nearby in the middle of the ocean
+	stations, err = findEmbeddedNearbyStations(Position{Latitude: -50, Longitude: -140}, 10)
+	require.NoError(t, err)
+	assert.Empty(t, stations)
+	_, err = nearestAirports(findEmbeddedNearbyStations, -50, -140, 10, 1)
+	assert.Error(t, err)
+}
+
+func TestParseLatLon(t *testing.T) {
+	position, err := parseLatLon("40.64,-73.78")
+	require.NoError(t, err)
+	assert.Equal(t, Position{Latitude: 40.64, Longitude: -73.78}, position)
+
+	position, err = parseLatLon(" -33.95 , 151.18 ")
+	require.NoError(t, err)
+	assert.Equal(t, Position{Latitude: -33.95, Longitude: 151.18}, position)
+
+	for _, input := range []string{"", "40.64", "40.64;-73.78", "north,-73.78", "40.64,", "91,0", "-90.5,0", "0,180.1", "0,-181", "NaN,0"} {
+		_, err := parseLatLon(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParsePostalCode(t *testing.T) {
+	tests := []struct {
+		input, country string
+		wantCountry    string
+		wantCode       string
+		ok             bool
+	}{
+		{"90210", "us", "US", "90210", true},
+		{"90210-1234", "us", "US", "90210-1234", true},
+		{"KJFK", "us", "US", "KJFK", false},
+		{"GB:SW1A", "us", "GB", "SW1A", true},
+		{"DE:10115", "us", "DE", "10115", true},
+		{"SW1A 1AA", "gb", "GB", "SW1A 1AA", true},
+		{"10115", "de", "DE", "10115", true},
+		{"EGLL", "gb", "GB", "EGLL", false},
+		{"LHR", "gb", "GB", "LHR", false},
+		{"KJFK", "de", "DE", "KJFK", false},
+		{"GB:", "us", "US", "GB:", false},
+	}
+
+	for _, tt := range tests {
+		country, code, ok := parsePostalCode(tt.input, tt.country)
+		assert.Equal(t, tt.ok, ok, tt.input)
+		if tt.ok {
+			assert.Equal(t, tt.wantCountry, country, tt.input)
+			assert.Equal(t, tt.wantCode, code, tt.input)
+		}
+	}
+}
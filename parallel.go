@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+)
+
+// The single-station fetchers used by -parallel, replaced in tests
+var (
+	fetchStationMETAR = FetchMETARContext
+	fetchStationTAF   = FetchTAFContext
+)
+
+// stationOutput is one station's share of -parallel output, buffered until the stations
+// before it have been written
+type stationOutput struct {
+	out, errOut strings.Builder
+	errs        []error
+	metar       *stationReport
+	done        chan struct{}
+}
+
+// processStationsParallel fetches the METAR, TAF and site info of several stations with at
+// most parallel stations in flight at once. Each station is rendered into its own buffers
+// and written to w (errors to errW) in command-line order as soon as the stations before it
+// are done, so concurrent fetches never interleave. With groupBy set, nothing is written
+// until every station is done, and the stations are grouped by their METAR's flight category.
+func processStationsParallel(ctx context.Context, w io.Writer, errW io.Writer, stationCodes []string, parallel int, showMETAR bool, showTAF bool, noRaw bool, noDecode bool, lookupSiteInfo func(string) *siteInfoLookup, offlineMode bool, structuredOutput bool, groupBy string) []error {
+	if offlineMode {
+		errorColor.Fprintln(errW, "Error: Cannot fetch reports in offline mode without piped input.")
+		return []error{errOfflineFetch}
+	}
+
+	fetch := func(fetchReport func(context.Context, string) (string, error), stationCode string) *stationReport {
+		raw, err := fetchReport(ctx, stationCode)
+		return &stationReport{raw: raw, fetchedAt: time.Now().UTC(), err: err}
+	}
+
+	slots := make(chan struct{}, parallel)
+	outputs := make([]*stationOutput, len(stationCodes))
+	renders := make([]func(first bool), len(stationCodes))
+	for i, code := range stationCodes {
+		output := &stationOutput{done: make(chan struct{})}
+		outputs[i] = output
+
+		go func() {
+			defer close(output.done)
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			siteInfo := lookupSiteInfo(code)
+			var metar, taf *stationReport
+			if showMETAR {
+				metar = fetch(fetchStationMETAR, code)
+				output.metar = metar
+			}
+			if showTAF {
+				taf = fetch(fetchStationTAF, code)
+			}
+
+			render := func(first bool) {
+				output.errs = displayStation(&output.out, &output.errOut, code, first, metar, taf, noRaw, noDecode, siteInfo, structuredOutput)
+			}
+			// -json, -csv and -interval-stats collect reports in the order they're
+			// displayed, and grouped stations aren't displayed in command-line order,
+			// so they're rendered in order below rather than here
+			if structuredOutput || groupBy != "" {
+				renders[i] = render
+				return
+			}
+			render(i == 0)
+		}()
+	}
+
+	var errs []error
+	write := func(i int, first bool) {
+		output := outputs[i]
+		<-output.done
+		if renders[i] != nil {
+			renders[i](first)
+		}
+
+		io.WriteString(w, output.out.String())
+		io.WriteString(errW, output.errOut.String())
+		errs = append(errs, output.errs...)
+	}
+	if groupBy == "" {
+		for i := range outputs {
+			write(i, i == 0)
+		}
+		return errs
+	}
+
+	// A station code given more than once is written once per time it was given
+	metars := make(map[string]string)
+	indexes := make(map[string][]int)
+	for i, output := range outputs {
+		<-output.done
+		if output.metar != nil && output.metar.err == nil {
+			metars[stationCodes[i]] = output.metar.raw
+		}
+		indexes[stationCodes[i]] = append(indexes[stationCodes[i]], i)
+	}
+	for g, group := range groupStationsByCategory(stationCodes, metars) {
+		if g > 0 {
+			io.WriteString(w, "\n")
+		}
+		io.WriteString(w, formatGroupHeading(group))
+
+		for j, code := range group.Reports {
+			write(indexes[code][0], j == 0)
+			indexes[code] = indexes[code][1:]
+		}
+	}
+	return errs
+}
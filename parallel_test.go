@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessStationsParallel_orderedOutput(t *testing.T) {
+	savedMETAR, savedTAF := fetchStationMETAR, fetchStationTAF
+	t.Cleanup(func() { fetchStationMETAR, fetchStationTAF = savedMETAR, savedTAF })
+
+	// Earlier stations finish last
+	delays := map[string]time.Duration{"KAAA": 60 * time.Millisecond, "KBBB": 30 * time.Millisecond, "KCCC": 0}
+	fetchStationMETAR = func(_ context.Context, code string) (string, error) {
+		time.Sleep(delays[code])
+		if code == "KBBB" {
+			return "", errors.New("no METAR data found for station KBBB")
+		}
+		return code + " 081551Z 27010KT 10SM FEW250 21/09 A3012", nil
+	}
+	fetchStationTAF = func(_ context.Context, code string) (string, error) {
+		time.Sleep(delays[code])
+		if code == "KCCC" {
+			return "", errors.New("no TAF data found for station KCCC")
+		}
+		return "TAF " + code + " 081720Z 0818/0924 27010KT P6SM SCT050", nil
+	}
+
+	var out, errOut bytes.Buffer
+	noSiteInfo := func(string) *siteInfoLookup { return nil }
+	errs := processStationsParallel(context.Background(), &out, &errOut, []string{"KAAA", "KBBB", "KCCC"}, 3, true, true, false, true, noSiteInfo, false, false, "")
+
+	var failed int
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	assert.Equal(t, 2, failed)
+
+	output := out.String()
+	var positions []int
+	for _, want := range []string{
+		"=== KAAA ===", "KAAA 081551Z", "TAF KAAA 081720Z",
+		"=== KBBB ===", "TAF KBBB 081720Z",
+		"=== KCCC ===", "KCCC 081551Z",
+	} {
+		i := strings.Index(output, want)
+		require.NotEqual(t, -1, i, "%q missing from output:\n%s", want, output)
+		positions = append(positions, i)
+	}
+	assert.IsIncreasing(t, positions, output)
+
+	assert.Equal(t, "Error fetching METAR: no METAR data found for station KBBB\n"+
+		"Error fetching TAF: no TAF data found for station KCCC\n", errOut.String())
+}
+
+func TestProcessStationsParallel_limit(t *testing.T) {
+	savedMETAR := fetchStationMETAR
+	t.Cleanup(func() { fetchStationMETAR = savedMETAR })
+
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	fetchStationMETAR = func(_ context.Context, code string) (string, error) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return code + " 081551Z 27010KT 10SM FEW250 21/09 A3012", nil
+	}
+
+	var out, errOut bytes.Buffer
+	noSiteInfo := func(string) *siteInfoLookup { return nil }
+	processStationsParallel(context.Background(), &out, &errOut, []string{"KAAA", "KBBB", "KCCC", "KDDD", "KEEE"}, 2, true, false, false, true, noSiteInfo, false, false, "")
+	assert.LessOrEqual(t, maxInFlight, 2)
+	assert.Equal(t, 5, strings.Count(out.String(), "081551Z"))
+	assert.Empty(t, errOut.String())
+}
+
+func TestProcessStationsParallel_groupByCategory(t *testing.T) {
+	savedMETAR := fetchStationMETAR
+	t.Cleanup(func() { fetchStationMETAR = savedMETAR })
+
+	metars := map[string]string{
+		"KAAA": "KAAA 081551Z 27010KT 10SM FEW250 21/09 A3012",
+		"KBBB": "KBBB 081556Z 28012KT 1/2SM FG OVC002 12/12 A3001",
+		"KCCC": "KCCC 081553Z 18008KT 10SM SCT080 15/02 A3001",
+	}
+	fetchStationMETAR = func(_ context.Context, code string) (string, error) {
+		if raw, ok := metars[code]; ok {
+			return raw, nil
+		}
+		return "", errors.New("no METAR data found for station " + code)
+	}
+
+	var out, errOut bytes.Buffer
+	noSiteInfo := func(string) *siteInfoLookup { return nil }
+	processStationsParallel(context.Background(), &out, &errOut, []string{"KAAA", "KDDD", "KBBB", "KCCC"}, 2, true, false, false, true, noSiteInfo, false, false, "category")
+
+	output := out.String()
+	var positions []int
+	for _, want := range []string{
+		"=== LIFR (1 report) ===", "=== KBBB ===",
+		"=== VFR (2 reports) ===", "=== KAAA ===", "=== KCCC ===",
+		"=== Unknown category (1 report) ===", "=== KDDD ===",
+	} {
+		i := strings.Index(output, want)
+		require.NotEqual(t, -1, i, "%q missing from output:\n%s", want, output)
+		positions = append(positions, i)
+	}
+	assert.IsIncreasing(t, positions, output)
+	assert.Equal(t, "Error fetching METAR: no METAR data found for station KDDD\n", errOut.String())
+}
+
+func TestProcessStationsParallel_cancel(t *testing.T) {
+	savedMETAR := fetchStationMETAR
+	t.Cleanup(func() { fetchStationMETAR = savedMETAR })
+
+	// Every fetch stalls until it's cancelled
+	fetchStationMETAR = func(ctx context.Context, code string) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	var out, errOut bytes.Buffer
+	noSiteInfo := func(string) *siteInfoLookup { return nil }
+	start := time.Now()
+	errs := processStationsParallel(ctx, &out, &errOut, []string{"KAAA", "KBBB"}, 2, true, false, false, true, noSiteInfo, false, false, "")
+	assert.Less(t, time.Since(start), 2*time.Second)
+	require.Len(t, errs, 2)
+	assert.ErrorIs(t, errs[0], context.Canceled)
+}
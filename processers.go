@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"regexp"
-	"strconv"
+	"io"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
+	"github.com/rmitchellscott/WxCraft/wx"
 )
 
 // Color variables for consistent formatting
@@ -14,582 +18,371 @@ var (
 	errorColor = color.New(color.FgRed).Add(color.Bold)
 )
 
-func newRemarkCodes() map[string]string {
-	// Common remark codes and their descriptions
-	codes := map[string]string{
-		"AO1":    "automated station without precipitation sensor",
-		"AO2":    "automated station with precipitation sensor",
-		"AO1A":   "automated station without precipitation sensor",
-		"AO2A":   "automated station with precipitation sensor",
-		"SLP":    "sea level pressure",
-		"SLPNO":  "sea level pressure information not available",
-		"FZRANO": "freezing rain information not available",
-		"TSNO":   "thunderstorm information not available",
-		"RMK":    "remarks indicator",
-		"PRESRR": "pressure rising rapidly",
-		"PRESFR": "pressure falling rapidly",
-		"NOSIG":  "no significant changes expected",
-		"TEMPO":  "temporary",
-		"BECMG":  "becoming",
-		"VIRGA":  "precipitation not reaching ground",
-		"FROPA":  "frontal passage",
-		"$":      "weather observing equipment requires maintenance",
+// isUndecodableMETAR reports whether decoding essentially failed: the station or
+// observation time is missing and more than half of the report body went unhandled
+func isUndecodableMETAR(m wx.METAR) bool {
+	parts := strings.Fields(m.Raw)
+	if len(parts) < 2 {
+		return true
 	}
 
-	// Fix invalid remark codes starting with "A0"
-	for k, v := range codes {
-		if strings.HasPrefix(k, "AO") {
-			codes["A0"+k[2:]] = v
-		}
+	if m.Station != "" && !m.Time.IsZero() {
+		return false
 	}
 
-	return codes
-}
-
-// processRemarks processes the remarks section of a METAR
-func processRemarks(remarkParts []string) []Remark {
-	remarks := []Remark{}
-
-	remarkCodes := newRemarkCodes()
-
-	// Process individual remarks or groups of related remarks
-	i := 0
-	for i < len(remarkParts) {
-		part := remarkParts[i]
-
-		// Handle altimeter setting in remarks (format A2994)
-		if pressureRegex.MatchString(part) {
-			matches := pressureRegex.FindStringSubmatch(part)
-			pressureStr := matches[1]
-			pressureInt, _ := strconv.Atoi(pressureStr)
-			pressure := float64(pressureInt) / 100.0
-
-			remarks = append(remarks, Remark{
-				Raw:         part,
-				Description: fmt.Sprintf("altimeter setting %.2f inHg", pressure),
-			})
-			i++
-			continue
+	// Count the tokens of the main section, which starts after the station and time
+	bodyCount := 0
+	for _, part := range parts[2:] {
+		if part == "RMK" {
+			break
 		}
+		bodyCount++
+	}
 
-		// Handle peak wind
-		if strings.HasPrefix(part, "PK") && i+2 < len(remarkParts) {
-			windRegex := regexp.MustCompile(`^PK\s+WND\s+(\d{3})(\d{2,3})/(\d{2})(\d{2})$`)
-			if windRegex.MatchString(strings.Join(remarkParts[i:i+3], " ")) {
-				matches := windRegex.FindStringSubmatch(strings.Join(remarkParts[i:i+3], " "))
-				dir := matches[1]
-				speed := matches[2]
-				hour := matches[3]
-				minute := matches[4]
-
-				remarks = append(remarks, Remark{
-					Raw:         strings.Join(remarkParts[i:i+3], " "),
-					Description: fmt.Sprintf("peak wind %s° at %s knots at %s:%s", dir, speed, hour, minute),
-				})
-				i += 3
-				continue
-			}
-		}
-
-		// Handle precipitation beginning/ending (e.g., SNB20, RAE15)
-		precipBERegex := regexp.MustCompile(`^(RA|SN|DZ|GR|GS|PE|IC|PL|SG|TS|FG|FU|VA|DU|SA|HZ|PY|BR|SHSN|SHRA|SHPE|SHPL|SHGR|SHGS)(B|E)(\d{2})$`)
-		if precipBERegex.MatchString(part) {
-			matches := precipBERegex.FindStringSubmatch(part)
-			phenType := matches[1]
-			beType := matches[2]
-			minute := matches[3]
-
-			// Map of weather phenomena for begin/end remarks
-			precipitationTypes := map[string]string{
-				"RA":   "rain",
-				"SN":   "snow",
-				"DZ":   "drizzle",
-				"GR":   "hail",
-				"GS":   "small hail",
-				"PE":   "ice pellets",
-				"IC":   "ice crystals",
-				"PL":   "ice pellets",
-				"SG":   "snow grains",
-				"TS":   "thunderstorm",
-				"FG":   "fog",
-				"FU":   "smoke",
-				"VA":   "volcanic ash",
-				"DU":   "dust",
-				"SA":   "sand",
-				"HZ":   "haze",
-				"PY":   "spray",
-				"BR":   "mist",
-				"SHSN": "snow shower",
-				"SHRA": "rain shower",
-				"SHPE": "ice pellet shower",
-				"SHPL": "ice pellet shower",
-				"SHGR": "hail shower",
-				"SHGS": "small hail shower",
-			}
-
-			// Get the phenomenon description
-			phenDesc, found := precipitationTypes[phenType]
-			if !found {
-				phenDesc = phenType
-			}
-
-			// Get action (began or ended)
-			action := "began"
-			if beType == "E" {
-				action = "ended"
-			}
+	return bodyCount == 0 || len(m.Unhandled)*2 > bodyCount
+}
 
-			// Parse the minute value
-			min, _ := strconv.Atoi(minute)
+// isUndecodableTAF reports whether decoding essentially failed: neither the
+// issuance time nor the valid period could be found
+func isUndecodableTAF(t wx.TAF) bool {
+	return t.Station == "" || (t.Time.IsZero() && t.ValidFrom.IsZero())
+}
 
-			remarks = append(remarks, Remark{
-				Raw:         part,
-				Description: fmt.Sprintf("%s %s at %d minutes past the hour", phenDesc, action, min),
-			})
-			i++
-			continue
-		}
+// isUndecodableInput reports whether piped input fails to decode as a TAF, or as
+// METARs (one per line) when asTAF is false. Any undecodable METAR line counts.
+func isUndecodableInput(rawInput string, asTAF bool) bool {
+	if asTAF {
+		return isUndecodableTAF(wx.DecodeTAF(rawInput))
+	}
 
-		// Handle sea level pressure
-		if strings.HasPrefix(part, "SLP") {
-			slpValue := part[3:] // This gets the value after "SLP"
-
-			if slpValue == "NO" {
-				// Handle the "SLPNO" case where sea-level pressure is not available
-				remarks = append(remarks, Remark{
-					Raw:         part,
-					Description: "sea level pressure not available",
-				})
-			} else {
-				slp, err := strconv.Atoi(slpValue)
-				if err == nil {
-					// SLP is given in tenths of millibars with an implied leading 10 or 9
-					var prefix float64 = 1000.0
-					if slp >= 500 {
-						prefix = 900.0
-					}
-					slpHpa := prefix + float64(slp)/10
-					remarks = append(remarks, Remark{
-						Raw:         part,
-						Description: fmt.Sprintf("sea level pressure %.1f hPa", slpHpa),
-					})
-				} else {
-					remarks = append(remarks, Remark{
-						Raw:         part,
-						Description: "sea level pressure (invalid format)",
-					})
-				}
-			}
-			i++
-			continue
+	reports := splitMETARReports(rawInput)
+	if len(reports) == 0 {
+		return true
+	}
+	for _, report := range reports {
+		if isUndecodableMETAR(wx.DecodeMETARCached(report)) {
+			return true
 		}
+	}
+	return false
+}
 
-		// Handle Temperature/Dew Point in tenths of degrees
-		tempDetailedRegex := regexp.MustCompile(`^T(\d)(\d{3})(\d)(\d{3})$`)
-		if tempDetailedRegex.MatchString(part) {
-			matches := tempDetailedRegex.FindStringSubmatch(part)
-			tempSign := matches[1]
-			tempVal := matches[2]
-			dewSign := matches[3]
-			dewVal := matches[4]
-
-			temp, _ := strconv.Atoi(tempVal)
-			dew, _ := strconv.Atoi(dewVal)
-
-			// Convert to tenths of degrees
-			tempF := float64(temp) / 10.0
-			dewF := float64(dew) / 10.0
+// printUndecodable prints a note and the raw report to w when it couldn't be decoded
+func printUndecodable(w io.Writer, reportType string, raw string, noRaw bool) {
+	warningColor.Fprintf(w, "Unable to decode %s, showing raw report instead\n", reportType)
+	if noRaw {
+		fmt.Fprintln(w, raw)
+	}
+}
 
-			if tempSign == "1" {
-				tempF = -tempF
-			}
-			if dewSign == "1" {
-				dewF = -dewF
-			}
+// errOfflineFetch is returned when a report would have to be fetched in offline mode
+var errOfflineFetch = errors.New("cannot fetch in offline mode without piped input")
 
-			remarks = append(remarks, Remark{
-				Raw:         part,
-				Description: fmt.Sprintf("temperature %.1f°C, dew point %.1f°C", tempF, dewF),
-			})
-			i++
-			continue
-		}
+// processMETAR fetches, decodes and displays METAR data with site information, writing the
+// report to w
+func processMETAR(ctx context.Context, w io.Writer, stationCode string, rawInput string, stdinHasData bool, noRaw bool, noDecode bool, siteInfo *siteInfoLookup, offlineMode bool) error {
+	var rawMetar string
+	var fetchedAt time.Time
+	var err error
 
-		// Handle 6-hour maximum temperature (format: 1sTTT)
-		if len(part) == 5 && part[0] == '1' {
-			sign := part[1]
-			tempStr := part[2:]
-			temp, err := strconv.Atoi(tempStr)
-			if err == nil {
-				tempValue := float64(temp) / 10.0 // Convert to degrees
-				if sign == '1' {
-					tempValue = -tempValue // Apply negative sign if needed
-				}
-				remarks = append(remarks, Remark{
-					Raw:         part,
-					Description: fmt.Sprintf("6-hour maximum temperature %.1f°C", tempValue),
-				})
-				i++
-				continue
-			}
+	// Get the raw METAR data
+	if stdinHasData {
+		rawMetar = rawInput
+	} else if !offlineMode {
+		// Only fetch from API if not in offline mode
+		rawMetar, err = FetchMETARContext(ctx, stationCode)
+		if err != nil {
+			errorColor.Fprintf(os.Stderr, "Error fetching METAR: %v\n", err)
+			return err
 		}
+		fetchedAt = time.Now().UTC()
+	} else {
+		// In offline mode without stdin data, we can't proceed
+		errorColor.Fprintln(os.Stderr, "Error: Cannot fetch METAR in offline mode without piped input.")
+		return errOfflineFetch
+	}
 
-		// Handle 6-hour minimum temperature (format: 2sTTT)
-		if len(part) == 5 && part[0] == '2' {
-			sign := part[1]
-			tempStr := part[2:]
-			temp, err := strconv.Atoi(tempStr)
-			if err == nil {
-				tempValue := float64(temp) / 10.0 // Convert to degrees
-				if sign == '1' {
-					tempValue = -tempValue // Apply negative sign if needed
-				}
-				remarks = append(remarks, Remark{
-					Raw:         part,
-					Description: fmt.Sprintf("6-hour minimum temperature %.1f°C", tempValue),
-				})
-				i++
-				continue
-			}
-		}
+	return displayMETAR(w, os.Stderr, rawMetar, fetchedAt, noRaw, noDecode, siteInfo)
+}
 
-		// Handle 24-hour maximum and minimum temperature (format: 4snTxTxTxsnTnTnTn)
-		if len(part) == 9 && part[0] == '4' {
-			maxTempSign := part[1:2]
-			maxTempStr := part[2:5]
-			minTempSign := part[5:6]
-			minTempStr := part[6:9]
-
-			// Parse maximum temperature
-			maxTemp, err1 := strconv.Atoi(maxTempStr)
-			// Parse minimum temperature
-			minTemp, err2 := strconv.Atoi(minTempStr)
-
-			if err1 == nil && err2 == nil {
-				// Convert to degrees Celsius
-				maxValue := float64(maxTemp) / 10.0
-				minValue := float64(minTemp) / 10.0
-
-				// Apply signs
-				if maxTempSign == "1" {
-					maxValue = -maxValue // negative if sign digit is 1
-				}
-				if minTempSign == "1" {
-					minValue = -minValue // negative if sign digit is 1
-				}
-
-				// Calculate Fahrenheit values
-				maxValueF := (maxValue * 9 / 5) + 32
-				minValueF := (minValue * 9 / 5) + 32
-
-				remarks = append(remarks, Remark{
-					Raw: part,
-					Description: fmt.Sprintf("24-hour temperature range: max %.1f°C (%.1f°F), min %.1f°C (%.1f°F)",
-						maxValue, maxValueF, minValue, minValueF),
-				})
-				i++
-				continue
-			}
-		}
+// displayMETAR logs, decodes and displays a raw METAR fetched at fetchedAt (zero when it
+// was supplied as input), writing the report to w and errors and warnings to errW
+func displayMETAR(w io.Writer, errW io.Writer, rawMetar string, fetchedAt time.Time, noRaw bool, noDecode bool, siteInfo *siteInfoLookup) error {
+	// Keep a history of reports when -log is set
+	if err := activeReportLog.record("METAR", rawMetar); err != nil {
+		warningColor.Fprintf(errW, "Warning: %v\n", err)
+	}
+	activeWatchedReports.add(rawMetar)
 
-		// Handle 3-hour pressure change (format: 3PPPP)
-		if len(part) == 5 && part[0] == '3' {
-			pressStr := part[1:]
-			press, err := strconv.Atoi(pressStr)
-			if err == nil {
-				hpa := float64(press) / 10.0 // Convert to hPa
-				remarks = append(remarks, Remark{
-					Raw:         part,
-					Description: fmt.Sprintf("3-hour pressure change: %.1f hPa", hpa),
-				})
-				i++
-				continue
-			}
-		}
+	// Print the raw METAR if requested
+	if !noRaw {
+		functionColor.Fprintln(w, "----- Raw METAR -----")
+		fmt.Fprintln(w, rawMetar)
 
-		// Handle pressure tendency (format: 5appp)
-		if len(part) == 5 && part[0] == '5' {
-			tendencyCode := part[1]
-			changeStr := part[2:]
-			change, err := strconv.Atoi(changeStr)
-			if err == nil {
-				changeValue := float64(change) / 10.0 // Convert to hPa
-
-				tendencyDesc := "unknown"
-				switch tendencyCode {
-				case '0':
-					tendencyDesc = "increasing, then decreasing"
-				case '1':
-					tendencyDesc = "increasing, then steady"
-				case '2':
-					tendencyDesc = "increasing steadily"
-				case '3':
-					tendencyDesc = "increasing, then increasing more rapidly"
-				case '4':
-					tendencyDesc = "steady"
-				case '5':
-					tendencyDesc = "decreasing, then increasing"
-				case '6':
-					tendencyDesc = "decreasing, then steady"
-				case '7':
-					tendencyDesc = "decreasing steadily"
-				case '8':
-					tendencyDesc = "decreasing, then decreasing more rapidly"
-				}
-
-				remarks = append(remarks, Remark{
-					Raw:         part,
-					Description: fmt.Sprintf("pressure tendency: %s, %.1f hPa change", tendencyDesc, changeValue),
-				})
-				i++
-				continue
-			}
+		// Add a line break if we're also showing decoded data
+		if !noDecode {
+			fmt.Fprintln(w)
 		}
+	}
 
-		// Handle precipitation amounts
-		if precRegex := regexp.MustCompile(`^P(\d{4})$`); precRegex.MatchString(part) {
-			matches := precRegex.FindStringSubmatch(part)
-			precip, _ := strconv.Atoi(matches[1])
-			inches := float64(precip) / 100.0
-
-			remarks = append(remarks, Remark{
-				Raw:         part,
-				Description: fmt.Sprintf("precipitation of %.2f inches in the last hour", inches),
-			})
-			i++
-			continue
+	// Decode and display the METAR if requested
+	if !noDecode {
+		// Decode the METAR
+		metar := wx.DecodeMETARCached(rawMetar)
+		if displayOptions.PreferRemarkTemp {
+			metar = metar.WithRemarkTemperatures()
 		}
-
-		// Handle 24-hour precipitation (format: 7RRRR)
-		if len(part) == 5 && part[0] == '7' {
-			precipStr := part[1:]
-			precip, err := strconv.Atoi(precipStr)
-			if err == nil {
-				inches := float64(precip) / 100.0 // Convert to inches
-				remarks = append(remarks, Remark{
-					Raw:         part,
-					Description: fmt.Sprintf("24-hour precipitation: %.2f inches", inches),
-				})
-				i++
-				continue
-			}
+		metar.FetchedAt = fetchedAt
+
+		// Check the -alert-config rules whichever way the report is shown
+		activeAlerts.check(metar)
+		activeUnhandled.check(metar)
+		activePushgateway.addMETAR(metar)
+
+		// Collect it for -json, which has no text fallback for undecodable reports
+		if activeJSONOutput != nil {
+			// A site info warning would end up ahead of the JSON document, so it goes to errW
+			metar.SiteInfo = siteInfo.get(errW)
+			activeJSONOutput.addMETAR(metar)
+			return nil
 		}
 
-		// Handle snow depth on ground (format: 4/sss)
-		if strings.HasPrefix(part, "4/") && len(part) == 5 {
-			snowStr := part[2:]
-			snow, err := strconv.Atoi(snowStr)
-			if err == nil {
-				remarks = append(remarks, Remark{
-					Raw:         part,
-					Description: fmt.Sprintf("snow depth: %d inches", snow),
-				})
-				i++
-				continue
-			}
+		// Only accumulate it for -interval-stats, which reports on the whole stream at the end
+		if activeIntervalStats != nil {
+			activeIntervalStats.addMETAR(metar)
+			return nil
 		}
 
-		// Handle ice accretion (format: IhVV)
-		if len(part) == 5 && part[0] == 'I' && part[1] >= '1' && part[1] <= '3' {
-			hourDigit := part[1]
-			accretionStr := part[2:]
-			accretion, err := strconv.Atoi(accretionStr)
-			if err == nil {
-				hours := map[byte]string{
-					'1': "1-hour",
-					'3': "3-hour",
-					'6': "6-hour",
-				}
-
-				timeframe := hours[hourDigit]
-				inches := float64(accretion) / 100.0 // Convert to inches
-
-				remarks = append(remarks, Remark{
-					Raw:         part,
-					Description: fmt.Sprintf("%s ice accretion: %.2f inches", timeframe, inches),
-				})
-				i++
-				continue
+		// Write a row for -csv, whose columns don't include site info
+		if activeCSVOutput != nil {
+			if err := activeCSVOutput.addMETAR(metar); err != nil {
+				errorColor.Fprintf(errW, "Error: %v\n", err)
+				return err
 			}
+			return nil
 		}
 
-		// Handle recent weather (format: REww)
-		if strings.HasPrefix(part, "RE") && len(part) >= 4 {
-			wxType := part[2:]
-			weatherMap := map[string]string{
-				"RA": "rain",
-				"SN": "snow",
-				"GR": "hail",
-				"GS": "small hail",
-				"TS": "thunderstorm",
-				"FG": "fog",
-				"SQ": "squall",
-				"FC": "funnel cloud",
+		// Write a fragment for -html, which shows the raw report alongside the decoded fields
+		if activeHTMLOutput != nil {
+			// A site info warning would end up inside the page, so it goes to errW instead
+			metar.SiteInfo = siteInfo.get(errW)
+			if err := activeHTMLOutput.addMETAR(metar); err != nil {
+				errorColor.Fprintf(errW, "Error: %v\n", err)
+				return err
 			}
-
-			if desc, ok := weatherMap[wxType]; ok {
-				remarks = append(remarks, Remark{
-					Raw:         part,
-					Description: fmt.Sprintf("recent %s", desc),
-				})
-			} else {
-				remarks = append(remarks, Remark{
-					Raw:         part,
-					Description: "recent weather phenomenon",
-				})
-			}
-			i++
-			continue
-		}
-
-		// Handle runway visual range (format: Rrrr/Vvvvft or similar)
-		if strings.HasPrefix(part, "R") && strings.Contains(part, "/") {
-			remarks = append(remarks, Remark{
-				Raw:         part,
-				Description: "runway visual range information",
-			})
-			i++
-			continue
+			return nil
 		}
 
-		// Handle SNOINCR (format: SNINCR int/int)
-		if part == "SNINCR" && i+1 < len(remarkParts) {
-			snowData := remarkParts[i+1]
-			if strings.Contains(snowData, "/") {
-				parts := strings.Split(snowData, "/")
-				if len(parts) == 2 {
-					remarks = append(remarks, Remark{
-						Raw:         part + " " + snowData,
-						Description: fmt.Sprintf("snow increasing rapidly: %s inch within %s hour", parts[0], parts[1]),
-					})
-					i += 2
-					continue
-				}
-			}
+		// Fall back to the raw report rather than printing an empty decoded block
+		if isUndecodableMETAR(metar) {
+			printUndecodable(w, "METAR", rawMetar, noRaw)
+			return nil
 		}
 
-		// Handle CIG for ceiling (format: CIG ddd)
-		if part == "CIG" && i+1 < len(remarkParts) {
-			if height, err := strconv.Atoi(remarkParts[i+1]); err == nil {
-				remarks = append(remarks, Remark{
-					Raw:         part + " " + remarkParts[i+1],
-					Description: fmt.Sprintf("variable ceiling height: %d feet", height*100),
-				})
-				i += 2
-				continue
-			}
-		}
+		// Add site information, or just the station code if it hasn't arrived yet
+		metar.SiteInfo = siteInfo.get(w)
 
-		// Check for known remark codes
-		if desc, ok := remarkCodes[part]; ok {
-			remarks = append(remarks, Remark{
-				Raw:         part,
-				Description: desc,
-			})
-			i++
-			continue
-		}
-
-		// Catch-all for unrecognized remarks
-		remarks = append(remarks, Remark{
-			Raw:         part,
-			Description: "unknown remark code",
-		})
-		i++
+		// Display the decoded METAR
+		functionColor.Fprintln(w, "--- Decoded METAR ---")
+		fmt.Fprint(w, FormatMETAR(metar))
 	}
 
-	return remarks
+	return nil
 }
 
-// processMETAR fetches, decodes and displays METAR data with site information
-func processMETAR(stationCode string, rawInput string, stdinHasData bool, noRaw bool, noDecode bool, siteInfo SiteInfo, siteInfoFetched bool, offlineMode bool) {
-	var rawMetar string
+// processTAF fetches, decodes and displays TAF data with site information, writing the
+// report to w. This follows the same pattern as processMETAR to handle both stdin and network calls
+func processTAF(ctx context.Context, w io.Writer, stationCode string, rawInput string, stdinHasData bool, noRaw bool, noDecode bool, siteInfo *siteInfoLookup, offlineMode bool) error {
+	var rawTAF string
+	var fetchedAt time.Time
 	var err error
 
-	// Get the raw METAR data
+	// Get the raw TAF data
 	if stdinHasData {
-		rawMetar = rawInput
+		rawTAF = rawInput
 	} else if !offlineMode {
 		// Only fetch from API if not in offline mode
-		rawMetar, err = FetchMETAR(stationCode)
+		rawTAF, err = FetchTAFContext(ctx, stationCode)
 		if err != nil {
-			errorColor.Printf("Error fetching METAR: %v\n", err)
-			return
+			errorColor.Fprintf(os.Stderr, "Error fetching TAF: %v\n", err)
+			return err
 		}
+		fetchedAt = time.Now().UTC()
 	} else {
 		// In offline mode without stdin data, we can't proceed
-		errorColor.Println("Error: Cannot fetch METAR in offline mode without piped input.")
-		return
+		errorColor.Fprintln(os.Stderr, "Error: Cannot fetch TAF in offline mode without piped input.")
+		return errOfflineFetch
 	}
 
-	// Print the raw METAR if requested
+	return displayTAF(w, os.Stderr, rawTAF, fetchedAt, noRaw, noDecode, siteInfo)
+}
+
+// displayTAF logs, decodes and displays a raw TAF fetched at fetchedAt (zero when it was
+// supplied as input), writing the report to w and errors and warnings to errW
+func displayTAF(w io.Writer, errW io.Writer, rawTAF string, fetchedAt time.Time, noRaw bool, noDecode bool, siteInfo *siteInfoLookup) error {
+	// Keep a history of reports when -log is set
+	if err := activeReportLog.record("TAF", rawTAF); err != nil {
+		warningColor.Fprintf(errW, "Warning: %v\n", err)
+	}
+	activeWatchedReports.add(rawTAF)
+
+	// Print the raw TAF if requested
 	if !noRaw {
-		functionColor.Println("----- Raw METAR -----")
-		fmt.Println(rawMetar)
+		functionColor.Fprintln(w, "------ Raw TAF ------")
+		fmt.Fprintln(w, rawTAF)
 
 		// Add a line break if we're also showing decoded data
 		if !noDecode {
-			fmt.Println()
+			fmt.Fprintln(w)
 		}
 	}
 
-	// Decode and display the METAR if requested
+	// Decode and display the TAF if requested
 	if !noDecode {
-		// Decode the METAR
-		metar := DecodeMETAR(rawMetar)
+		// Decode the TAF
+		taf := wx.DecodeTAF(rawTAF)
+		taf.FetchedAt = fetchedAt
+
+		// Collect it for -json, which has no text fallback for undecodable reports
+		if activeJSONOutput != nil {
+			// A site info warning would end up ahead of the JSON document, so it goes to errW
+			taf.SiteInfo = siteInfo.get(errW)
+			activeJSONOutput.addTAF(taf)
+			return nil
+		}
+
+		// Write a fragment for -html, which shows the raw report alongside the decoded fields
+		if activeHTMLOutput != nil {
+			// A site info warning would end up inside the page, so it goes to errW instead
+			taf.SiteInfo = siteInfo.get(errW)
+			if err := activeHTMLOutput.addTAF(taf); err != nil {
+				errorColor.Fprintf(errW, "Error: %v\n", err)
+				return err
+			}
+			return nil
+		}
+
+		// Fall back to the raw report rather than printing an empty decoded block
+		if isUndecodableTAF(taf) {
+			printUndecodable(w, "TAF", rawTAF, noRaw)
+			return nil
+		}
 
-		// Add site information
-		metar.SiteInfo = siteInfo
+		// Add site information, or just the station code if it hasn't arrived yet
+		taf.SiteInfo = siteInfo.get(w)
 
-		// Display the decoded METAR
-		functionColor.Println("--- Decoded METAR ---")
-		fmt.Print(FormatMETAR(metar))
+		// Display the decoded TAF
+		functionColor.Fprintln(w, "---- Decoded TAF ----")
+		fmt.Fprint(w, FormatTAF(taf))
 	}
+
+	return nil
 }
 
-// processTAF fetches, decodes and displays TAF data with site information
-// This follows the same pattern as processMETAR to handle both stdin and network calls
-func processTAF(stationCode string, rawInput string, stdinHasData bool, noRaw bool, noDecode bool, siteInfo SiteInfo, siteInfoFetched bool, offlineMode bool) {
-	var rawTAF string
-	var err error
+// processStations fetches the reports for several stations, with one request for all their
+// METARs and one for all their TAFs, and displays them station by station under a heading.
+// A station without a report is reported without stopping the others. Reports are written to w.
+// With groupBy set, the stations are grouped by their METAR's flight category.
+func processStations(ctx context.Context, w io.Writer, stationCodes []string, showMETAR bool, showTAF bool, noRaw bool, noDecode bool, siteInfo map[string]*siteInfoLookup, offlineMode bool, structuredOutput bool, groupBy string) []error {
+	if offlineMode {
+		errorColor.Fprintln(os.Stderr, "Error: Cannot fetch reports in offline mode without piped input.")
+		return []error{errOfflineFetch}
+	}
 
-	// Get the raw TAF data
-	if stdinHasData {
-		rawTAF = rawInput
-	} else if !offlineMode {
-		// Only fetch from API if not in offline mode
-		rawTAF, err = FetchTAF(stationCode)
+	var errs []error
+	fetch := func(reportType string, fetchReports func(context.Context, []string) (map[string]string, error)) (map[string]string, time.Time) {
+		reports, err := fetchReports(ctx, stationCodes)
 		if err != nil {
-			errorColor.Printf("Error fetching TAF: %v\n", err)
-			return
+			errorColor.Fprintf(os.Stderr, "Error fetching %ss: %v\n", reportType, err)
+			errs = append(errs, err)
+			return nil, time.Time{}
 		}
-	} else {
-		// In offline mode without stdin data, we can't proceed
-		errorColor.Println("Error: Cannot fetch TAF in offline mode without piped input.")
-		return
+		return reports, time.Now().UTC()
 	}
 
-	// Print the raw TAF if requested
-	if !noRaw {
-		functionColor.Println("------ Raw TAF ------")
-		fmt.Println(rawTAF)
+	var metars, tafs map[string]string
+	var metarsFetchedAt, tafsFetchedAt time.Time
+	if showMETAR {
+		metars, metarsFetchedAt = fetch("METAR", FetchMETARsContext)
+	}
+	if showTAF {
+		tafs, tafsFetchedAt = fetch("TAF", FetchTAFsContext)
+	}
+	if metars == nil && tafs == nil {
+		return errs
+	}
 
-		// Add a line break if we're also showing decoded data
-		if !noDecode {
-			fmt.Println()
+	// A failed request was reported above; only report stations missing from a response
+	report := func(reports map[string]string, fetchedAt time.Time, reportType string, stationCode string) *stationReport {
+		if reports == nil {
+			return nil
 		}
+		if raw, ok := reports[stationCode]; ok {
+			return &stationReport{raw: raw, fetchedAt: fetchedAt}
+		}
+		return &stationReport{err: fmt.Errorf("no %s data found for station %s", reportType, stationCode)}
 	}
 
-	// Decode and display the TAF if requested
-	if !noDecode {
-		// Decode the TAF
-		taf := DecodeTAF(rawTAF)
+	groups := []reportGroup{{Reports: stationCodes}}
+	if groupBy != "" {
+		groups = groupStationsByCategory(stationCodes, metars)
+	}
+	for g, group := range groups {
+		if group.Heading != "" {
+			if g > 0 {
+				fmt.Fprint(w, "\n")
+			}
+			fmt.Fprint(w, formatGroupHeading(group))
+		}
+
+		for i, code := range group.Reports {
+			metar := report(metars, metarsFetchedAt, "METAR", code)
+			taf := report(tafs, tafsFetchedAt, "TAF", code)
+			errs = append(errs, displayStation(w, os.Stderr, code, i == 0, metar, taf, noRaw, noDecode, siteInfo[code], structuredOutput)...)
+		}
+	}
+	return errs
+}
 
-		// Add site information
-		taf.SiteInfo = siteInfo
+// stationReport is a station's fetched METAR or TAF, or why it couldn't be fetched
+type stationReport struct {
+	raw       string
+	fetchedAt time.Time
+	err       error
+}
 
-		// Display the decoded TAF
-		functionColor.Println("---- Decoded TAF ----")
-		fmt.Print(FormatTAF(taf))
+// displayStation displays a station's METAR and TAF under a heading with its station code,
+// writing the reports to w and errors and warnings to errW. A nil report isn't shown.
+func displayStation(w io.Writer, errW io.Writer, stationCode string, first bool, metar *stationReport, taf *stationReport, noRaw bool, noDecode bool, siteInfo *siteInfoLookup, structuredOutput bool) []error {
+	if !structuredOutput {
+		if !first {
+			fmt.Fprint(w, "\n")
+		}
+		sectionColor.Fprintf(w, "=== %s ===\n\n", stationCode)
+	}
+
+	var errs []error
+	if metar != nil {
+		if metar.err != nil {
+			errorColor.Fprintf(errW, "Error fetching METAR: %v\n", metar.err)
+			errs = append(errs, metar.err)
+		} else {
+			errs = append(errs, displayMETAR(w, errW, metar.raw, metar.fetchedAt, noRaw, noDecode, siteInfo))
+		}
+	}
+
+	if taf != nil {
+		// Add a line break if we also displayed METAR
+		if metar != nil && !structuredOutput {
+			fmt.Fprint(w, "\n----------------------------------\n\n")
+		}
+
+		if taf.err != nil {
+			errorColor.Fprintf(errW, "Error fetching TAF: %v\n", taf.err)
+			errs = append(errs, taf.err)
+		} else {
+			errs = append(errs, displayTAF(w, errW, taf.raw, taf.fetchedAt, noRaw, noDecode, siteInfo))
+		}
 	}
+	return errs
 }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsUndecodableMETAR(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{raw: "", want: true},
+		{raw: "KJFK", want: true},
+		{raw: "HELLO WORLD THIS IS NOT A WEATHER REPORT", want: true},
+		{raw: "404 PAGE NOT FOUND PLEASE TRY AGAIN", want: true},
+		{raw: "KJFK 110154Z 09007KT 10SM FEW040 BKN250 12/01 A3013 RMK AO2", want: false},
+		// Missing time but the body still decodes
+		{raw: "KJFK XXXXXX 09007KT 10SM FEW040 BKN250 12/01 A3013", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			assert.Equal(t, tt.want, isUndecodableMETAR(wx.DecodeMETAR(tt.raw)))
+		})
+	}
+}
+
+func TestIsUndecodableTAF(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{raw: "", want: true},
+		{raw: "HELLO WORLD THIS IS NOT A FORECAST", want: true},
+		{raw: "TAF KBOS 110547Z 1106/1212 14012KT 4SM -RA BR OVC008", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			assert.Equal(t, tt.want, isUndecodableTAF(wx.DecodeTAF(tt.raw)))
+		})
+	}
+}
+
+func TestIsUndecodableInput(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, isUndecodableInput("KJFK 110154Z 09007KT 10SM FEW040 BKN250 12/01 A3013 RMK AO2", false))
+	assert.True(t, isUndecodableInput("HELLO WORLD THIS IS NOT A WEATHER REPORT", false))
+
+	// One garbled line among several METARs is enough to pass the input on
+	assert.True(t, isUndecodableInput("KJFK 110154Z 09007KT 10SM FEW040 BKN250 12/01 A3013\n404 PAGE NOT FOUND PLEASE TRY AGAIN", false))
+
+	assert.False(t, isUndecodableInput("TAF KBOS 110547Z 1106/1212 14012KT 4SM -RA BR OVC008", true))
+	assert.True(t, isUndecodableInput("HELLO WORLD THIS IS NOT A FORECAST", true))
+}
+
+func TestDisplayMETAR_jsonSiteInfoWarning(t *testing.T) {
+	defer func(saved *jsonOutput) { activeJSONOutput = saved }(activeJSONOutput)
+	activeJSONOutput = &jsonOutput{}
+	activeJSONOutput.expect(true, true, false)
+
+	failed := startSiteInfoLookup("KORD", time.Second, func(string) (wx.SiteInfo, error) {
+		return wx.SiteInfo{}, errors.New("unexpected status code: 502")
+	})
+
+	var out, errOut bytes.Buffer
+	require.NoError(t, displayMETAR(&out, &errOut, "KORD 081551Z 27010KT 10SM FEW250 21/09 A3012", time.Time{}, true, false, failed))
+	require.NoError(t, displayTAF(&out, &errOut, "TAF KORD 081720Z 0818/0924 27010KT P6SM SCT050", time.Time{}, true, false, failed))
+	require.NoError(t, activeJSONOutput.write(&out))
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &doc), out.String())
+	assert.Equal(t, "Warning: Could not fetch site info for KORD: unexpected status code: 502\n", errOut.String())
+}
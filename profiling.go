@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+)
+
+// startPprof serves the net/http/pprof endpoints on addr (e.g. ":6060") in the
+// background and returns the address it listens on. The handlers get their own
+// mux so they are never exposed on any other server the process runs.
+func startPprof(addr string) (net.Addr, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("error starting pprof listener: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			errorColor.Fprintf(os.Stderr, "Error serving pprof: %v\n", err)
+		}
+	}()
+
+	return listener.Addr(), nil
+}
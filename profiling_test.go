@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartPprof(t *testing.T) {
+	t.Parallel()
+
+	addr, err := startPprof("127.0.0.1:0")
+	require.NoError(t, err)
+
+	resp, err := http.Get("http://" + addr.String() + "/debug/pprof/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Only the pprof endpoints are served
+	resp, err = http.Get("http://" + addr.String() + "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// A listener that can't be opened is reported
+	_, err = startPprof(addr.String())
+	assert.Error(t, err)
+}
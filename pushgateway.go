@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+)
+
+// pushgateway collects the latest decoded METAR of each station for -pushgateway, which
+// pushes their metrics to a Prometheus Pushgateway once every report has been processed
+type pushgateway struct {
+	mu      sync.Mutex
+	url     string
+	latest  map[string]wx.METAR
+	ordered []string // Stations in the order their first report arrived
+}
+
+// activePushgateway is the collector set up by -pushgateway, or nil when nothing is pushed
+var activePushgateway *pushgateway
+
+// pushgatewayJob is the job label of the pushed metrics
+const pushgatewayJob = "wxcraft"
+
+// newPushgateway returns a collector pushing to the Pushgateway at rawURL
+func newPushgateway(rawURL string) (*pushgateway, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Pushgateway URL %q (expected http:// or https://)", rawURL)
+	}
+	return &pushgateway{url: strings.TrimSuffix(rawURL, "/"), latest: make(map[string]wx.METAR)}, nil
+}
+
+// pushError is returned when the Pushgateway refuses the metrics of a station
+type pushError struct {
+	Station    string
+	StatusCode int
+	Message    string // Start of the response body, which says what was wrong
+}
+
+func (e pushError) Error() string {
+	msg := fmt.Sprintf("Pushgateway rejected metrics for %s: status %d", e.Station, e.StatusCode)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// addMETAR records a decoded METAR, replacing an older one from the same station. It does
+// nothing on a nil collector.
+func (p *pushgateway) addMETAR(m wx.METAR) {
+	if p == nil || m.Station == "" {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	previous, ok := p.latest[m.Station]
+	if !ok {
+		p.ordered = append(p.ordered, m.Station)
+	}
+	if !ok || !m.Time.Before(previous.Time) {
+		p.latest[m.Station] = m
+	}
+}
+
+// push sends the metrics of each station to the Pushgateway, grouped by a station label.
+// It does nothing on a nil collector.
+func (p *pushgateway) push(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, station := range p.ordered {
+		if err := p.pushStation(ctx, station, formatPrometheusMetrics(p.latest[station])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pushStation POSTs one station's metrics, replacing the ones of the same names in its group
+func (p *pushgateway) pushStation(ctx context.Context, station, metrics string) error {
+	target := fmt.Sprintf("%s/metrics/job/%s/station/%s", p.url, pushgatewayJob, url.PathEscape(station))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(metrics))
+	if err != nil {
+		return fmt.Errorf("error pushing metrics for %s: %w", station, err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error pushing metrics for %s: %w", station, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return pushError{Station: station, StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+	return nil
+}
+
+// formatPrometheusMetrics renders a decoded METAR as Prometheus text exposition format.
+// Values the report doesn't give are left out; the flight category is a gauge per
+// category that is 1 for the report's.
+func formatPrometheusMetrics(m wx.METAR) string {
+	var sb strings.Builder
+	gauge := func(name, help string, value float64) {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", name, help, name, name, value)
+	}
+
+	if !m.Time.IsZero() {
+		gauge("wxcraft_observation_timestamp_seconds", "Time of the observation.", float64(m.Time.Unix()))
+	}
+	if m.Temperature != nil {
+		gauge("wxcraft_temperature_celsius", "Air temperature.", float64(*m.Temperature))
+	}
+	if m.DewPoint != nil {
+		gauge("wxcraft_dewpoint_celsius", "Dew point.", float64(*m.DewPoint))
+	}
+	if speed, ok := m.Wind.SpeedKnots(); ok {
+		gauge("wxcraft_wind_speed_knots", "Sustained wind speed.", float64(speed))
+		gauge("wxcraft_wind_gust_knots", "Wind gust speed, 0 without gusts.", float64(m.Wind.GustKnots()))
+	}
+	if m.Visibility.Unit != "" {
+		gauge("wxcraft_visibility_statute_miles", "Prevailing visibility.", m.Visibility.StatuteMiles)
+	}
+	if ceiling, ok := m.Ceiling(); ok {
+		gauge("wxcraft_ceiling_feet", "Height of the lowest broken or overcast layer or vertical visibility.", float64(ceiling))
+	}
+	if m.Pressure > 0 {
+		hpa := m.Pressure
+		if m.PressureUnit != "hPa" {
+			hpa = wx.InHgToMillibars(m.Pressure)
+		}
+		gauge("wxcraft_pressure_hpa", "Altimeter setting.", hpa)
+	}
+
+	if category := m.FlightCategory(); category != "" {
+		sb.WriteString("# HELP wxcraft_flight_category Flight category, 1 for the reported one.\n# TYPE wxcraft_flight_category gauge\n")
+		for _, c := range []string{"LIFR", "IFR", "MVFR", "VFR"} {
+			value := 0
+			if c == category {
+				value = 1
+			}
+			fmt.Fprintf(&sb, "wxcraft_flight_category{category=%q} %d\n", c, value)
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatPrometheusMetrics(t *testing.T) {
+	t.Parallel()
+
+	metrics := formatPrometheusMetrics(wx.DecodeMETAR("KORD 081551Z 27010G22KT 2SM BR BKN008 21/09 A3012"))
+	assert.Contains(t, metrics, "# TYPE wxcraft_temperature_celsius gauge\nwxcraft_temperature_celsius 21\n")
+	assert.Contains(t, metrics, "wxcraft_dewpoint_celsius 9\n")
+	assert.Contains(t, metrics, "wxcraft_wind_speed_knots 10\n")
+	assert.Contains(t, metrics, "wxcraft_wind_gust_knots 22\n")
+	assert.Contains(t, metrics, "wxcraft_visibility_statute_miles 2\n")
+	assert.Contains(t, metrics, "wxcraft_ceiling_feet 800\n")
+	assert.Contains(t, metrics, "wxcraft_pressure_hpa 1019.")
+	assert.Contains(t, metrics, `wxcraft_flight_category{category="IFR"} 1`+"\n")
+	assert.Contains(t, metrics, `wxcraft_flight_category{category="VFR"} 0`+"\n")
+
+	// Missing values are left out
+	metrics = formatPrometheusMetrics(wx.DecodeMETAR("KORD 081551Z 27010KT 10SM CLR A3012"))
+	assert.NotContains(t, metrics, "wxcraft_temperature_celsius")
+	assert.NotContains(t, metrics, "wxcraft_ceiling_feet")
+}
+
+func TestPushgateway(t *testing.T) {
+	var paths, bodies []string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		body, _ := io.ReadAll(r.Body)
+		paths = append(paths, r.URL.Path)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			io.WriteString(w, "text format parsing error\n")
+		}
+	}))
+	defer server.Close()
+
+	_, err := newPushgateway("localhost:9091")
+	assert.Error(t, err)
+
+	gateway, err := newPushgateway(server.URL + "/")
+	require.NoError(t, err)
+
+	// Only the latest report of each station is pushed
+	gateway.addMETAR(wx.DecodeMETAR("KORD 081551Z 27010KT 10SM FEW250 21/09 A3012"))
+	gateway.addMETAR(wx.DecodeMETAR("KORD 081451Z 27008KT 10SM FEW250 20/09 A3011"))
+	gateway.addMETAR(wx.DecodeMETAR("KMDW 081553Z 26012KT 10SM CLR 22/08 A3012"))
+
+	require.NoError(t, gateway.push(context.Background()))
+	assert.Equal(t, []string{"/metrics/job/wxcraft/station/KORD", "/metrics/job/wxcraft/station/KMDW"}, paths)
+	assert.Contains(t, bodies[0], "wxcraft_temperature_celsius 21\n")
+
+	status = http.StatusBadRequest
+	err = gateway.push(context.Background())
+	var pushErr pushError
+	require.ErrorAs(t, err, &pushErr)
+	assert.Equal(t, pushError{Station: "KORD", StatusCode: http.StatusBadRequest, Message: "text format parsing error"}, pushErr)
+
+	// Nothing is pushed without -pushgateway
+	var none *pushgateway
+	none.addMETAR(wx.DecodeMETAR("KORD 081551Z 27010KT 10SM FEW250 21/09 A3012"))
+	assert.NoError(t, none.push(context.Background()))
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reportLog appends one timestamped line per report to a log file, building a
+// personal weather history across runs
+type reportLog struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+}
+
+// activeReportLog is the log set up by -log, or nil when reports aren't logged
+var activeReportLog *reportLog
+
+// openReportLog opens (creating if needed) the log file at path for appending
+func openReportLog(path string) (*reportLog, io.Closer, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening report log: %w", err)
+	}
+	return &reportLog{w: f, now: time.Now}, f, nil
+}
+
+// record writes a line such as "2025-03-08T15:52:03Z METAR KORD 081551Z ..." for a raw
+// report in its one-line form. It does nothing on a nil log.
+func (l *reportLog) record(kind string, raw string) error {
+	if l == nil {
+		return nil
+	}
+
+	line := fmt.Sprintf("%s %s\n", l.now().UTC().Format(time.RFC3339), oneLineReport(kind, raw))
+
+	// Each line goes out in a single write so reports from concurrent lookups don't interleave
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := io.WriteString(l.w, line); err != nil {
+		return fmt.Errorf("error writing report log: %w", err)
+	}
+	return nil
+}
+
+// oneLineReport returns a raw report on one line with single spaces, starting with its
+// type (e.g. "TAF KORD 081120Z ... FM081800 ..."). A report that already starts with its
+// type doesn't get it twice.
+func oneLineReport(kind string, raw string) string {
+	fields := strings.Fields(raw)
+	if len(fields) == 0 || fields[0] != kind {
+		fields = append([]string{kind}, fields...)
+	}
+	return strings.Join(fields, " ")
+}
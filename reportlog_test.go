@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportLog_record(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "wx.log")
+	require.NoError(t, os.WriteFile(path, []byte("earlier line\n"), 0o644))
+
+	log, closer, err := openReportLog(path)
+	require.NoError(t, err)
+	log.now = func() time.Time { return time.Date(2025, 3, 8, 15, 52, 3, 0, time.UTC) }
+
+	require.NoError(t, log.record("METAR", "KORD 081551Z 27010KT 10SM FEW250 21/09 A3012"))
+	require.NoError(t, log.record("TAF", "TAF KORD 081120Z 0812/0918 27012KT P6SM SCT250\n  FM081800 28015G22KT P6SM BKN250"))
+	require.NoError(t, log.record("TAF", "KMDW 081120Z 0812/0918 27012KT P6SM SCT250\r\n  FM081800 28015G22KT P6SM BKN250\n"))
+	require.NoError(t, closer.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "earlier line\n"+
+		"2025-03-08T15:52:03Z METAR KORD 081551Z 27010KT 10SM FEW250 21/09 A3012\n"+
+		"2025-03-08T15:52:03Z TAF KORD 081120Z 0812/0918 27012KT P6SM SCT250 FM081800 28015G22KT P6SM BKN250\n"+
+		"2025-03-08T15:52:03Z TAF KMDW 081120Z 0812/0918 27012KT P6SM SCT250 FM081800 28015G22KT P6SM BKN250\n",
+		string(data))
+}
+
+func TestReportLog_concurrent(t *testing.T) {
+	t.Parallel()
+
+	var sb strings.Builder
+	log := &reportLog{w: &sb, now: time.Now}
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, log.record("METAR", "KORD 081551Z 27010KT 10SM FEW250 21/09 A3012"))
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n")
+	assert.Len(t, lines, 20)
+	for _, line := range lines {
+		assert.True(t, strings.HasSuffix(line, " METAR KORD 081551Z 27010KT 10SM FEW250 21/09 A3012"), line)
+	}
+}
+
+func TestReportLog_nil(t *testing.T) {
+	t.Parallel()
+
+	var log *reportLog
+	assert.NoError(t, log.record("METAR", "KORD 081551Z 27010KT"))
+}
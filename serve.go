@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+)
+
+// reportFetcher fetches the raw report of one type for a station
+type reportFetcher func(ctx context.Context, stationCode string) (string, error)
+
+// serveErrorBody is the JSON body of an error response from -serve
+type serveErrorBody struct {
+	Error string `json:"error"`
+}
+
+// newReportHandler returns the -serve handler: GET /metar/{station} and GET /taf/{station}
+// answer with the decoded report as JSON. A station without a report is a 404 and a
+// failed fetch a 502, each with a JSON error body.
+func newReportHandler(fetchMETAR, fetchTAF reportFetcher) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metar/{station}", func(w http.ResponseWriter, r *http.Request) {
+		serveReport(w, r, fetchMETAR, func(raw string, fetchedAt time.Time) any {
+			m := wx.DecodeMETAR(raw)
+			m.FetchedAt = fetchedAt
+			return m
+		})
+	})
+	mux.HandleFunc("GET /taf/{station}", func(w http.ResponseWriter, r *http.Request) {
+		serveReport(w, r, fetchTAF, func(raw string, fetchedAt time.Time) any {
+			t := wx.DecodeTAF(raw)
+			t.FetchedAt = fetchedAt
+			return t
+		})
+	})
+	return mux
+}
+
+// serveReport fetches the report for the requested station and writes it decoded
+func serveReport(w http.ResponseWriter, r *http.Request, fetch reportFetcher, decode func(raw string, fetchedAt time.Time) any) {
+	code := strings.ToUpper(r.PathValue("station"))
+	if err := validateStationCode(code, false); err != nil {
+		writeServeJSON(w, http.StatusBadRequest, serveErrorBody{Error: err.Error()})
+		return
+	}
+
+	raw, err := fetch(r.Context(), code)
+	var noData noDataError
+	switch {
+	case errors.As(err, &noData):
+		writeServeJSON(w, http.StatusNotFound, serveErrorBody{Error: err.Error()})
+		return
+	case err != nil:
+		writeServeJSON(w, http.StatusBadGateway, serveErrorBody{Error: err.Error()})
+		return
+	}
+
+	writeServeJSON(w, http.StatusOK, decode(raw, time.Now().UTC()))
+}
+
+// writeServeJSON writes v as the JSON body of a response with the given status
+func writeServeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// serve runs the -serve HTTP server on addr until interrupted with Ctrl-C or SIGTERM.
+// Fetches share the client timeout and retries of the command line.
+func serve(addr string) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           newReportHandler(FetchMETARContext, FetchTAFContext),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+	fmt.Printf("Serving decoded reports on %s (GET /metar/{station}, GET /taf/{station})\n", addr)
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("error serving: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportHandler(t *testing.T) {
+	t.Parallel()
+
+	fetchMETAR := func(_ context.Context, code string) (string, error) {
+		switch code {
+		case "KORD":
+			return "KORD 081551Z 27010KT 10SM FEW250 21/09 A3012", nil
+		case "KBBB":
+			return "", noDataError{dataType: "METAR", stationCode: code}
+		}
+		return "", errors.New("unexpected status code: 502")
+	}
+	fetchTAF := func(_ context.Context, code string) (string, error) {
+		return "TAF " + code + " 081720Z 0818/0924 27010KT P6SM SCT050", nil
+	}
+	handler := newReportHandler(fetchMETAR, fetchTAF)
+
+	get := func(path string) (*httptest.ResponseRecorder, map[string]any) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body), rec.Body.String())
+		return rec, body
+	}
+
+	rec, body := get("/metar/kord")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "KORD", body["station"])
+	assert.NotEmpty(t, body["fetched_at"])
+
+	rec, body = get("/taf/KORD")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "KORD", body["station"])
+
+	rec, body = get("/metar/KBBB")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "no METAR data found for station KBBB", body["error"])
+
+	rec, body = get("/metar/KCCC")
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+	assert.Equal(t, "unexpected status code: 502", body["error"])
+
+	rec, _ = get("/metar/KORDX")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/metar/KORD", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+)
+
+// intervalStats accumulates summary statistics over a stream of decoded METARs for
+// -interval-stats, which prints them once every report has been processed
+type intervalStats struct {
+	mu          sync.Mutex
+	count       int
+	skipped     int
+	stations    []string
+	first, last time.Time
+
+	tempCount        int
+	tempMin, tempMax int
+	tempSum          int
+
+	maxGust     int
+	maxGustTime time.Time
+
+	categories map[string]int
+}
+
+// activeIntervalStats is the accumulator set up by -interval-stats, or nil when each
+// report is shown on its own
+var activeIntervalStats *intervalStats
+
+// newIntervalStats returns an empty accumulator
+func newIntervalStats() *intervalStats {
+	return &intervalStats{categories: make(map[string]int)}
+}
+
+// addMETAR adds a decoded METAR to the statistics. Reports that couldn't be decoded are
+// only counted as skipped.
+func (s *intervalStats) addMETAR(m wx.METAR) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if isUndecodableMETAR(m) {
+		s.skipped++
+		return
+	}
+	s.count++
+
+	if !slices.Contains(s.stations, m.Station) {
+		s.stations = append(s.stations, m.Station)
+	}
+
+	if !m.Time.IsZero() {
+		if s.first.IsZero() || m.Time.Before(s.first) {
+			s.first = m.Time
+		}
+		if m.Time.After(s.last) {
+			s.last = m.Time
+		}
+	}
+
+	if m.Temperature != nil {
+		temp := *m.Temperature
+		if s.tempCount == 0 || temp < s.tempMin {
+			s.tempMin = temp
+		}
+		if s.tempCount == 0 || temp > s.tempMax {
+			s.tempMax = temp
+		}
+		s.tempSum += temp
+		s.tempCount++
+	}
+
+	if gust := m.Wind.GustKnots(); gust > s.maxGust {
+		s.maxGust = gust
+		s.maxGustTime = m.Time
+	}
+
+	if category := m.FlightCategory(); category != "" {
+		s.categories[category]++
+	}
+}
+
+// mostCommonCategory returns the flight category reported most often and how many
+// reports had it, preferring the worse category on a tie
+func (s *intervalStats) mostCommonCategory() (string, int) {
+	best, bestCount := "", 0
+	for _, category := range []string{"LIFR", "IFR", "MVFR", "VFR"} {
+		if n := s.categories[category]; n > bestCount {
+			best, bestCount = category, n
+		}
+	}
+	return best, bestCount
+}
+
+// format renders the statistics as a text report
+func (s *intervalStats) format() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sb strings.Builder
+
+	functionColor.Fprintln(&sb, "--- Interval Statistics ---")
+	labelColor.Fprint(&sb, "Reports: ")
+	sb.WriteString(fmt.Sprintf("%d", s.count))
+	if len(s.stations) > 0 {
+		sb.WriteString(fmt.Sprintf(" (%s)", strings.Join(s.stations, ", ")))
+	}
+	if s.skipped > 0 {
+		sb.WriteString(fmt.Sprintf(", %d skipped as undecodable", s.skipped))
+	}
+	sb.WriteString("\n")
+
+	if !s.first.IsZero() {
+		labelColor.Fprint(&sb, "Period: ")
+		sb.WriteString(fmt.Sprintf("%s – %s UTC\n", s.first.Format("2006-01-02 15:04"), s.last.Format("2006-01-02 15:04")))
+	}
+
+	if s.tempCount > 0 {
+		mean := float64(s.tempSum) / float64(s.tempCount)
+		labelColor.Fprint(&sb, "Temperature: ")
+		sb.WriteString(fmt.Sprintf("min %d°C | %d°F, max %d°C | %d°F, mean %.1f°C | %.1f°F\n",
+			s.tempMin, wx.CelsiusToFahrenheit(s.tempMin),
+			s.tempMax, wx.CelsiusToFahrenheit(s.tempMax),
+			mean, mean*9/5+32))
+	}
+
+	labelColor.Fprint(&sb, "Max Gust: ")
+	if s.maxGust > 0 {
+		sb.WriteString(fmt.Sprintf("%d knots", s.maxGust))
+		if !s.maxGustTime.IsZero() {
+			sb.WriteString(fmt.Sprintf(" at %s UTC", s.maxGustTime.Format("2006-01-02 15:04")))
+		}
+		sb.WriteString("\n")
+	} else {
+		sb.WriteString("None\n")
+	}
+
+	if category, n := s.mostCommonCategory(); n > 0 {
+		labelColor.Fprint(&sb, "Flight Category: ")
+		sb.WriteString(fmt.Sprintf("%s (%d of %d reports)\n", category, n, s.count))
+	}
+
+	return finishOutput(sb.String())
+}
+
+// write prints the statistics to w. Nothing is written on a nil accumulator.
+func (s *intervalStats) write(w io.Writer) error {
+	if s == nil {
+		return nil
+	}
+	if _, err := io.WriteString(w, s.format()); err != nil {
+		return fmt.Errorf("error writing interval statistics: %w", err)
+	}
+	return nil
+}
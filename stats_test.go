@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntervalStats(t *testing.T) {
+	t.Parallel()
+
+	stats := newIntervalStats()
+	for _, raw := range []string{
+		"KORD 081351Z 27010KT 10SM FEW250 16/09 A3012",
+		"KORD 081451Z 27015G32KT 2SM BR BKN008 18/12 A3010",
+		"KORD 081551Z 27010KT 10SM FEW250 21/09 A3012",
+		"KORD 081651Z 27012G20KT 10SM FEW250 M/M A3012",
+		"not a weather report",
+	} {
+		stats.addMETAR(wx.DecodeMETAR(raw))
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, stats.write(&out))
+	output := out.String()
+
+	assert.Contains(t, output, "Reports: 4 (KORD), 1 skipped as undecodable\n")
+	assert.Contains(t, output, "Temperature: min 16°C | 60°F, max 21°C | 69°F, mean 18.3°C | 65.0°F\n")
+	assert.Regexp(t, `Max Gust: 32 knots at \d{4}-\d{2}-08 14:51 UTC\n`, output)
+	assert.Contains(t, output, "Flight Category: VFR (3 of 4 reports)\n")
+}
+
+func TestIntervalStats_mostCommonCategoryTie(t *testing.T) {
+	t.Parallel()
+
+	// A tie goes to the worse category
+	stats := newIntervalStats()
+	stats.addMETAR(wx.DecodeMETAR("KORD 081451Z 27015KT 2SM BR BKN008 18/12 A3010"))
+	stats.addMETAR(wx.DecodeMETAR("KORD 081551Z 27010KT 10SM FEW250 21/09 A3012"))
+
+	category, n := stats.mostCommonCategory()
+	assert.Equal(t, "IFR", category)
+	assert.Equal(t, 1, n)
+}
+
+func TestIntervalStats_nil(t *testing.T) {
+	t.Parallel()
+
+	var stats *intervalStats
+	var out bytes.Buffer
+	require.NoError(t, stats.write(&out))
+	assert.Empty(t, out.String())
+}
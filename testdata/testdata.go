@@ -13,7 +13,7 @@ import (
 //go:embed *.gz
 var data embed.FS
 
-func newScanner(t *testing.T, path string) *bufio.Scanner {
+func newScanner(t testing.TB, path string) *bufio.Scanner {
 	f, err := data.Open(path)
 	require.NoError(t, err)
 
@@ -31,10 +31,10 @@ func newScanner(t *testing.T, path string) *bufio.Scanner {
 	return scanner
 }
 
-func METAR(t *testing.T) *bufio.Scanner {
+func METAR(t testing.TB) *bufio.Scanner {
 	return newScanner(t, "metar.txt.gz")
 }
 
-func TAF(t *testing.T) *bufio.Scanner {
+func TAF(t testing.TB) *bufio.Scanner {
 	return newScanner(t, "taf.txt.gz")
 }
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+)
+
+// unhandledChecker collects the tokens the decoder didn't recognize for
+// -fail-on-unhandled, so a CI run can catch reports the decoder doesn't cover
+type unhandledChecker struct {
+	mu    sync.Mutex
+	found []string
+}
+
+// activeUnhandled is the checker set up by -fail-on-unhandled, or nil when unrecognized
+// tokens are only shown in the decoded report
+var activeUnhandled *unhandledChecker
+
+// check records the unrecognized tokens of a decoded METAR. It does nothing on a nil checker.
+func (u *unhandledChecker) check(m wx.METAR) {
+	if u == nil {
+		return
+	}
+
+	tokens := m.UndecodedTokens()
+	if len(tokens) == 0 {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.found = append(u.found, fmt.Sprintf("%s in %q", strings.Join(tokens, ", "), m.Raw))
+}
+
+// report prints a line for each report with unrecognized tokens and reports whether
+// there were any. It does nothing on a nil checker.
+func (u *unhandledChecker) report(w io.Writer) bool {
+	if u == nil {
+		return false
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for _, message := range u.found {
+		errorColor.Fprintf(w, "Unhandled: %s\n", message)
+	}
+	return len(u.found) > 0
+}
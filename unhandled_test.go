@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnhandledChecker(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	checker := &unhandledChecker{}
+	checker.check(wx.DecodeMETAR("KJFK 081551Z 27020KT 10SM SKC 21/09 A3012 RMK AO2"))
+	assert.False(t, checker.report(&out))
+	assert.Empty(t, out.String())
+
+	checker.check(wx.DecodeMETAR("KLGA 081551Z 27020KT 10SM SKC 21/09 A3012 XX9X RMK AO2 Z9Z9"))
+	assert.True(t, checker.report(&out))
+	assert.Equal(t, "Unhandled: XX9X, Z9Z9 in \"KLGA 081551Z 27020KT 10SM SKC 21/09 A3012 XX9X RMK AO2 Z9Z9\"\n", out.String())
+
+	// A nil checker, without -fail-on-unhandled, never fails
+	var none *unhandledChecker
+	none.check(wx.DecodeMETAR("KLGA 081551Z 27020KT 10SM SKC 21/09 A3012 XX9X"))
+	assert.False(t, none.report(&out))
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Terminal control sequences used by -watch
+const (
+	clearScreen = "\033[H\033[2J" // Move the cursor home and clear the screen
+	resetColors = "\033[0m"       // Reset colors and text attributes
+)
+
+// watchedReports collects the raw reports displayed during a -watch refresh, so that
+// -only-changed can tell whether they changed since the previous one
+type watchedReports struct {
+	mu   sync.Mutex
+	raws []string
+}
+
+// activeWatchedReports is the collector set up by -watch, or nil when not watching
+var activeWatchedReports *watchedReports
+
+// add records a displayed raw report. It does nothing on a nil collector.
+func (r *watchedReports) add(raw string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.raws = append(r.raws, raw)
+}
+
+// take returns the reports recorded since the last call, sorted so that the order
+// parallel fetches finish in doesn't count as a change, and starts over
+func (r *watchedReports) take() string {
+	if r == nil {
+		return ""
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	slices.Sort(r.raws)
+	reports := strings.Join(r.raws, "\n")
+	r.raws = nil
+	return reports
+}
+
+// watchReports calls refresh straight away and again every interval, clearing the screen
+// before each update, until ctx is done. refresh writes the update to its writer and
+// returns the raw reports it showed, abandoning its fetches when ctx is done; with
+// onlyChanged, an update whose reports are the same as the previous one's isn't shown.
+func watchReports(ctx context.Context, w io.Writer, interval time.Duration, onlyChanged bool, refresh func(ctx context.Context, w io.Writer) string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Don't leave the terminal in whatever color was last printed
+	defer func() {
+		if !color.NoColor {
+			fmt.Fprint(w, resetColors)
+		}
+		fmt.Fprintln(w)
+	}()
+
+	var previous string
+	for first := true; ; first = false {
+		if onlyChanged {
+			// Hold the update back until it's known whether the reports changed
+			var update bytes.Buffer
+			reports := refresh(ctx, &update)
+			if ctx.Err() != nil {
+				return
+			}
+			if first || reports != previous {
+				fmt.Fprint(w, clearScreen)
+				update.WriteTo(w)
+				printWatchFooter(w, interval)
+			}
+			previous = reports
+		} else {
+			fmt.Fprint(w, clearScreen)
+			refresh(ctx, w)
+			if ctx.Err() != nil {
+				return
+			}
+			printWatchFooter(w, interval)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		// A stop that arrived along with the tick still wins
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// printWatchFooter prints when the reports were last updated below them
+func printWatchFooter(w io.Writer, interval time.Duration) {
+	functionColor.Fprintf(w, "\nUpdated %s, refreshing every %s. Press Ctrl-C to stop.\n",
+		time.Now().UTC().Format("15:04:05 UTC"), interval)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchReports(t *testing.T) {
+	t.Parallel()
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	refreshes := 0
+	refresh := func(_ context.Context, w io.Writer) string {
+		refreshes++
+		fmt.Fprintf(w, "refresh %d\n", refreshes)
+		if refreshes == 3 {
+			stop()
+		}
+		return "KORD 081551Z 27010KT 10SM FEW250 21/09 A3012"
+	}
+
+	var out bytes.Buffer
+	watchReports(ctx, &out, time.Millisecond, false, refresh)
+
+	assert.Equal(t, 3, refreshes)
+	assert.Equal(t, 3, strings.Count(out.String(), clearScreen))
+	assert.Equal(t, 2, strings.Count(out.String(), "Press Ctrl-C to stop."), "no footer after the interrupted refresh")
+	assert.Contains(t, out.String(), "refreshing every 1ms. Press Ctrl-C to stop.\n")
+}
+
+func TestWatchReports_onlyChanged(t *testing.T) {
+	t.Parallel()
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	reports := []string{"KORD 081551Z", "KORD 081551Z", "KORD 081651Z", "KORD 081651Z", "KORD 081751Z"}
+	refreshes := 0
+	refresh := func(_ context.Context, w io.Writer) string {
+		report := reports[refreshes]
+		fmt.Fprintf(w, "refresh %d: %s\n", refreshes+1, report)
+		refreshes++
+		if refreshes == len(reports) {
+			stop()
+		}
+		return report
+	}
+
+	var out bytes.Buffer
+	watchReports(ctx, &out, time.Millisecond, true, refresh)
+
+	assert.Equal(t, 5, refreshes)
+	assert.Equal(t, 2, strings.Count(out.String(), clearScreen))
+	assert.Contains(t, out.String(), "refresh 1: KORD 081551Z\n")
+	assert.NotContains(t, out.String(), "refresh 2:")
+	assert.Contains(t, out.String(), "refresh 3: KORD 081651Z\n")
+	assert.NotContains(t, out.String(), "refresh 4:")
+	assert.NotContains(t, out.String(), "refresh 5:", "an interrupted refresh isn't shown")
+}
+
+func TestWatchReports_interruptStalledFetch(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	ctx, stop := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, stop)
+
+	var fetchErr error
+	refresh := func(ctx context.Context, w io.Writer) string {
+		_, fetchErr = fetchData(ctx, server.URL+"?ids=%s", "KORD", "METAR")
+		return ""
+	}
+
+	start := time.Now()
+	var out bytes.Buffer
+	watchReports(ctx, &out, time.Minute, false, refresh)
+
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.ErrorIs(t, fetchErr, context.Canceled)
+	assert.NotContains(t, out.String(), "Press Ctrl-C to stop.")
+}
+
+func TestWatchedReports(t *testing.T) {
+	t.Parallel()
+
+	var reports watchedReports
+	reports.add("KORD 081551Z")
+	reports.add("KMDW 081553Z")
+	assert.Equal(t, "KMDW 081553Z\nKORD 081551Z", reports.take())
+	assert.Empty(t, reports.take())
+
+	var none *watchedReports
+	none.add("KORD 081551Z")
+	assert.Empty(t, none.take())
+}
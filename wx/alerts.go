@@ -0,0 +1,165 @@
+package wx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// flightCategories lists the FAA flight categories from worst to best
+var flightCategories = []string{"LIFR", "IFR", "MVFR", "VFR"}
+
+// Rule is a condition on a decoded METAR, such as
+// {"station":"KJFK","category_below":"MVFR","wind_gust_above":30}. A rule fires when every
+// condition it sets is met; unset conditions are ignored, and a condition on a value the
+// report doesn't include (e.g. the temperature) is never met.
+type Rule struct {
+	Name            string   `json:"name,omitempty"`             // Shown when the rule fires, instead of its position in the list
+	Station         string   `json:"station,omitempty"`          // Only apply to this station (default: every station)
+	CategoryBelow   string   `json:"category_below,omitempty"`   // Flight category worse than this one (VFR, MVFR or IFR)
+	CeilingBelow    *int     `json:"ceiling_below,omitempty"`    // Ceiling lower than this many feet
+	VisibilityBelow *float64 `json:"visibility_below,omitempty"` // Visibility less than this many statute miles
+	WindAbove       *int     `json:"wind_above,omitempty"`       // Sustained wind stronger than this many knots
+	WindGustAbove   *int     `json:"wind_gust_above,omitempty"`  // Gusts stronger than this many knots
+	TempBelow       *int     `json:"temp_below,omitempty"`       // Temperature colder than this, in Celsius
+	TempAbove       *int     `json:"temp_above,omitempty"`       // Temperature warmer than this, in Celsius
+	Weather         []string `json:"weather,omitempty"`          // Present weather containing any of these codes (e.g. "TS", "FZ")
+}
+
+// ParseRules parses a JSON array of rules, rejecting unknown keys, unknown flight
+// categories and rules without any condition
+func ParseRules(data []byte) ([]Rule, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var rules []Rule
+	if err := dec.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("error parsing alert rules: %w", err)
+	}
+
+	for i, rule := range rules {
+		if rule.CategoryBelow != "" && !slices.Contains(flightCategories, strings.ToUpper(rule.CategoryBelow)) {
+			return nil, fmt.Errorf("alert %s: unknown flight category %q (expected one of %s)", rule.label(i), rule.CategoryBelow, strings.Join(flightCategories, ", "))
+		}
+		if !rule.hasCondition() {
+			return nil, fmt.Errorf("alert %s: no conditions set", rule.label(i))
+		}
+	}
+	return rules, nil
+}
+
+// EvaluateAlerts returns a message for each rule that fires for the METAR, such as
+// `KJFK: rule "gusty IFR": category IFR below MVFR, gusts 35 kt above 30 kt`.
+// It returns nil when no rule fires.
+func EvaluateAlerts(m METAR, rules []Rule) []string {
+	var fired []string
+	for i, rule := range rules {
+		if reasons, ok := rule.evaluate(m); ok {
+			fired = append(fired, fmt.Sprintf("%s: %s: %s", m.Station, rule.label(i), strings.Join(reasons, ", ")))
+		}
+	}
+	return fired
+}
+
+// label names the rule at index i of its list in messages
+func (r Rule) label(i int) string {
+	if r.Name != "" {
+		return fmt.Sprintf("rule %q", r.Name)
+	}
+	return fmt.Sprintf("rule %d", i+1)
+}
+
+// hasCondition reports whether the rule sets anything beyond its name and station
+func (r Rule) hasCondition() bool {
+	return r.CategoryBelow != "" || r.CeilingBelow != nil || r.VisibilityBelow != nil ||
+		r.WindAbove != nil || r.WindGustAbove != nil || r.TempBelow != nil || r.TempAbove != nil ||
+		len(r.Weather) > 0
+}
+
+// evaluate reports whether every condition of the rule is met by the METAR, along with
+// a description of each one
+func (r Rule) evaluate(m METAR) ([]string, bool) {
+	if r.Station != "" && !strings.EqualFold(r.Station, m.Station) {
+		return nil, false
+	}
+
+	var reasons []string
+	met := func(ok bool, format string, args ...any) bool {
+		if ok {
+			reasons = append(reasons, fmt.Sprintf(format, args...))
+		}
+		return ok
+	}
+
+	if r.CategoryBelow != "" {
+		category := m.FlightCategory()
+		threshold := strings.ToUpper(r.CategoryBelow)
+		if !met(category != "" && slices.Index(flightCategories, category) < slices.Index(flightCategories, threshold),
+			"category %s below %s", category, threshold) {
+			return nil, false
+		}
+	}
+
+	if r.CeilingBelow != nil {
+		ceiling, ok := m.Ceiling()
+		if !met(ok && ceiling < *r.CeilingBelow, "ceiling %d ft below %d ft", ceiling, *r.CeilingBelow) {
+			return nil, false
+		}
+	}
+
+	if r.VisibilityBelow != nil {
+		miles := m.Visibility.StatuteMiles
+		if !met(m.Visibility.Unit != "" && miles < *r.VisibilityBelow, "visibility %g SM below %g SM", miles, *r.VisibilityBelow) {
+			return nil, false
+		}
+	}
+
+	if r.WindAbove != nil {
+		speed, ok := m.Wind.SpeedKnots()
+		if !met(ok && speed > *r.WindAbove, "wind %d kt above %d kt", speed, *r.WindAbove) {
+			return nil, false
+		}
+	}
+
+	if r.WindGustAbove != nil {
+		gust := m.Wind.GustKnots()
+		if !met(gust > *r.WindGustAbove, "gusts %d kt above %d kt", gust, *r.WindGustAbove) {
+			return nil, false
+		}
+	}
+
+	if r.TempBelow != nil {
+		if !met(m.Temperature != nil && *m.Temperature < *r.TempBelow, "temperature below %d°C", *r.TempBelow) {
+			return nil, false
+		}
+	}
+
+	if r.TempAbove != nil {
+		if !met(m.Temperature != nil && *m.Temperature > *r.TempAbove, "temperature above %d°C", *r.TempAbove) {
+			return nil, false
+		}
+	}
+
+	if len(r.Weather) > 0 {
+		weather, ok := matchWeather(m.Weather, r.Weather)
+		if !met(ok, "weather %s", weather) {
+			return nil, false
+		}
+	}
+
+	return reasons, true
+}
+
+// matchWeather returns the first present weather group containing one of the codes
+func matchWeather(weather []string, codes []string) (string, bool) {
+	for _, group := range weather {
+		for _, code := range codes {
+			if code != "" && strings.Contains(group, strings.ToUpper(code)) {
+				return group, true
+			}
+		}
+	}
+	return "", false
+}
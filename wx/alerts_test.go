@@ -0,0 +1,95 @@
+package wx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestEvaluateAlerts(t *testing.T) {
+	t.Parallel()
+
+	metar := DecodeMETAR("KJFK 081551Z 27020G35KT 2SM +TSRA BKN008 21/19 A3012")
+
+	tests := []struct {
+		name  string
+		rules []Rule
+		want  []string
+	}{
+		{
+			name:  "all conditions met",
+			rules: []Rule{{Station: "KJFK", CategoryBelow: "MVFR", WindGustAbove: ptr.To(30)}},
+			want:  []string{"KJFK: rule 1: category IFR below MVFR, gusts 35 kt above 30 kt"},
+		},
+		{
+			name:  "one condition not met",
+			rules: []Rule{{CategoryBelow: "MVFR", WindGustAbove: ptr.To(40)}},
+		},
+		{
+			name:  "other station",
+			rules: []Rule{{Station: "KLGA", CategoryBelow: "MVFR"}},
+		},
+		{
+			name: "named rules",
+			rules: []Rule{
+				{Name: "low ceiling", CeilingBelow: ptr.To(1000)},
+				{Name: "thunder", Weather: []string{"TS"}},
+				{Name: "fog", Weather: []string{"FG"}},
+			},
+			want: []string{
+				`KJFK: rule "low ceiling": ceiling 800 ft below 1000 ft`,
+				`KJFK: rule "thunder": weather +TSRA`,
+			},
+		},
+		{
+			name: "visibility, wind and temperature",
+			rules: []Rule{
+				{VisibilityBelow: ptr.To(3.0), WindAbove: ptr.To(15)},
+				{TempAbove: ptr.To(30)},
+				{TempBelow: ptr.To(25)},
+			},
+			want: []string{
+				"KJFK: rule 1: visibility 2 SM below 3 SM, wind 20 kt above 15 kt",
+				"KJFK: rule 3: temperature below 25°C",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, EvaluateAlerts(metar, tt.rules))
+		})
+	}
+}
+
+func TestEvaluateAlerts_missingValues(t *testing.T) {
+	t.Parallel()
+
+	// Conditions on values the report doesn't have are never met
+	metar := DecodeMETAR("KJFK 081551Z 27020KT 10SM SKC A3012")
+	rules := []Rule{
+		{CeilingBelow: ptr.To(1000)},
+		{TempBelow: ptr.To(50)},
+		{WindGustAbove: ptr.To(0)},
+	}
+	assert.Nil(t, EvaluateAlerts(metar, rules))
+}
+
+func TestParseRules(t *testing.T) {
+	t.Parallel()
+
+	rules, err := ParseRules([]byte(`[{"station":"KJFK","category_below":"mvfr","wind_gust_above":30}]`))
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{{Station: "KJFK", CategoryBelow: "mvfr", WindGustAbove: ptr.To(30)}}, rules)
+
+	_, err = ParseRules([]byte(`[{"category_below":"MARGINAL"}]`))
+	assert.ErrorContains(t, err, `rule 1: unknown flight category "MARGINAL"`)
+
+	_, err = ParseRules([]byte(`[{"name":"empty","station":"KJFK"}]`))
+	assert.ErrorContains(t, err, `rule "empty": no conditions set`)
+
+	_, err = ParseRules([]byte(`[{"gusts_above":30}]`))
+	assert.ErrorContains(t, err, `unknown field "gusts_above"`)
+}
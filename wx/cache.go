@@ -0,0 +1,80 @@
+package wx
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// decodeCacheSize is how many decoded reports of each kind are kept
+const decodeCacheSize = 128
+
+// decodeCache is a small least-recently-used cache of decoded reports keyed by their raw text
+type decodeCache[T any] struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // Most recently used at the front
+	entries  map[string]*list.Element
+}
+
+// decodeCacheEntry is a cached report and the key it was stored under
+type decodeCacheEntry[T any] struct {
+	key    string
+	report T
+}
+
+// newDecodeCache creates a cache holding at most capacity reports
+func newDecodeCache[T any](capacity int) *decodeCache[T] {
+	return &decodeCache[T]{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// get returns the cached report for raw, decoding and storing it on a miss
+func (c *decodeCache[T]) get(raw string, decode func(string) T) T {
+	// Decoding fills in the year and month from the current date, so a report
+	// decoded last month is decoded again
+	key := time.Now().UTC().Format("2006-01") + " " + raw
+
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		c.mu.Unlock()
+		return elem.Value.(*decodeCacheEntry[T]).report
+	}
+	c.mu.Unlock()
+
+	report := decode(raw)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		c.entries[key] = c.order.PushFront(&decodeCacheEntry[T]{key: key, report: report})
+		if c.order.Len() > c.capacity {
+			oldest := c.order.Back()
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(*decodeCacheEntry[T]).key)
+		}
+	}
+	return report
+}
+
+var (
+	metarCache = newDecodeCache[METAR](decodeCacheSize)
+	tafCache   = newDecodeCache[TAF](decodeCacheSize)
+)
+
+// DecodeMETARCached decodes a METAR like DecodeMETAR, reusing the result for raw text
+// it has decoded recently. The report's age is worked out when it is formatted, so a
+// cached report stays current. Callers share the cached slices and must not modify them.
+func DecodeMETARCached(raw string) METAR {
+	return metarCache.get(raw, DecodeMETAR)
+}
+
+// DecodeTAFCached decodes a TAF like DecodeTAF, reusing the result for raw text it has
+// decoded recently. Callers share the cached slices and must not modify them.
+func DecodeTAFCached(raw string) TAF {
+	return tafCache.get(raw, DecodeTAF)
+}
@@ -0,0 +1,48 @@
+package wx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const benchmarkMETAR = "KORD 081551Z 27010G18KT 240V300 10SM -RA FEW035 BKN250 21/09 A3012 RMK AO2 PK WND 28025/1520 SLP199 P0001 T02110089 $"
+
+func TestDecodeCache(t *testing.T) {
+	t.Parallel()
+
+	decodes := 0
+	cache := newDecodeCache[METAR](2)
+	decode := func(raw string) METAR {
+		decodes++
+		return DecodeMETAR(raw)
+	}
+
+	first := cache.get(benchmarkMETAR, decode)
+	assert.Equal(t, DecodeMETAR(benchmarkMETAR), first)
+	assert.Equal(t, first, cache.get(benchmarkMETAR, decode))
+	assert.Equal(t, 1, decodes)
+
+	// The least recently used report is evicted once the cache is full
+	cache.get("KMDW 081551Z 26012KT 10SM CLR 21/08 A3012", decode)
+	cache.get(benchmarkMETAR, decode)
+	cache.get("KPWK 081552Z 27008KT 10SM CLR 20/08 A3013", decode)
+	assert.Equal(t, 3, decodes)
+	cache.get(benchmarkMETAR, decode)
+	assert.Equal(t, 3, decodes)
+	cache.get("KMDW 081551Z 26012KT 10SM CLR 21/08 A3012", decode)
+	assert.Equal(t, 4, decodes)
+	assert.Equal(t, 2, cache.order.Len())
+}
+
+func BenchmarkDecodeMETAR(b *testing.B) {
+	for b.Loop() {
+		DecodeMETAR(benchmarkMETAR)
+	}
+}
+
+func BenchmarkDecodeMETARCached(b *testing.B) {
+	for b.Loop() {
+		DecodeMETARCached(benchmarkMETAR)
+	}
+}
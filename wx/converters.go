@@ -0,0 +1,126 @@
+package wx
+
+import "math"
+
+// CelsiusToFahrenheit converts temperature from Celsius to Fahrenheit
+func CelsiusToFahrenheit(celsius int) int {
+	return (celsius * 9 / 5) + 32
+}
+
+// InHgToMillibars converts pressure from inches of mercury to millibars (hPa)
+func InHgToMillibars(inHg float64) float64 {
+	return inHg * 33.8639
+}
+
+// MillibarsToInHg converts pressure from millibars (hPa) to inches of mercury
+func MillibarsToInHg(hpa float64) float64 {
+	return hpa / 33.8639
+}
+
+// HpaToMmHg converts pressure from hectopascals to millimetres of mercury
+func HpaToMmHg(hpa float64) float64 {
+	return hpa * 0.750062
+}
+
+// HpaToKpa converts pressure from hectopascals to kilopascals
+func HpaToKpa(hpa float64) float64 {
+	return hpa / 10
+}
+
+// InHgToMmHg converts pressure from inches of mercury to millimetres of mercury
+func InHgToMmHg(inHg float64) float64 {
+	return inHg * 25.4
+}
+
+// InHgToKpa converts pressure from inches of mercury to kilopascals
+func InHgToKpa(inHg float64) float64 {
+	return inHg * 3.38639
+}
+
+// MilesToKM converts distance from statute miles to kilometers
+func MilesToKM(miles float64) float64 {
+	return miles * 1.609344
+}
+
+// KMToMiles converts distance from kilometers to statute miles
+func KMToMiles(km float64) float64 {
+	return km / 1.609344
+}
+
+// MpsToKnots converts speed from meters per second to knots
+func MpsToKnots(mps int) int {
+	return int(math.Round(float64(mps) * 1.94384))
+}
+
+// KmhToKnots converts speed from kilometers per hour to knots
+func KmhToKnots(kmh int) int {
+	return int(math.Round(float64(kmh) / 1.852))
+}
+
+// RelativeHumidity returns the relative humidity, in whole percent, for a temperature and
+// dew point in Celsius, using the Magnus formula
+func RelativeHumidity(tempC, dewC int) int {
+	const a, b = 17.625, 243.04
+	saturation := func(c float64) float64 { return math.Exp(a * c / (b + c)) }
+	rh := 100 * saturation(float64(dewC)) / saturation(float64(tempC))
+	return int(math.Round(math.Max(0, math.Min(100, rh))))
+}
+
+// Ranges in which the wind chill and heat index apply
+const (
+	windChillMaxTempC  = 10
+	windChillMinWindKt = 5
+	heatIndexMinTempC  = 27
+)
+
+// WindChill returns the wind chill in Celsius for a temperature in Celsius and a wind speed
+// in knots, using the NWS (2001 JAG/TI) formula in its metric form. Outside the range where
+// it applies (10°C or colder, wind above 5 knots) it returns the temperature unchanged.
+func WindChill(tempC int, windKt int) float64 {
+	if tempC > windChillMaxTempC || windKt <= windChillMinWindKt {
+		return float64(tempC)
+	}
+
+	t := float64(tempC)
+	v := math.Pow(float64(windKt)*1.852, 0.16) // km/h
+	return 13.12 + 0.6215*t - 11.37*v + 0.3965*t*v
+}
+
+// HeatIndex returns the heat index in Celsius for a temperature and dew point in Celsius,
+// using the NWS Rothfusz regression with its low and high humidity adjustments. Below
+// 27°C, where it doesn't apply, it returns the temperature unchanged.
+func HeatIndex(tempC, dewC int) float64 {
+	if tempC < heatIndexMinTempC {
+		return float64(tempC)
+	}
+
+	t := float64(tempC)*9/5 + 32
+	rh := float64(RelativeHumidity(tempC, dewC))
+	hi := -42.379 + 2.04901523*t + 10.14333127*rh -
+		0.22475541*t*rh - 0.00683783*t*t - 0.05481717*rh*rh +
+		0.00122874*t*t*rh + 0.00085282*t*rh*rh - 0.00000199*t*t*rh*rh
+
+	switch {
+	case rh < 13 && t <= 112:
+		hi -= (13 - rh) / 4 * math.Sqrt((17-math.Abs(t-95))/17)
+	case rh > 85 && t <= 87:
+		hi += (rh - 85) / 10 * (87 - t) / 5
+	}
+
+	return (hi - 32) * 5 / 9
+}
+
+// KnotsToKmh converts speed from knots to kilometers per hour
+func KnotsToKmh(knots float64) float64 {
+	return knots * 1.852
+}
+
+// KnotsToMph converts speed from knots to statute miles per hour
+func KnotsToMph(knots float64) float64 {
+	return knots * 1.150779
+}
+
+// FeetToMeters converts a height from feet to meters
+func FeetToMeters(feet float64) float64 {
+	return feet * 0.3048
+}
@@ -0,0 +1,111 @@
+package wx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPressureConversions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		convert func(float64) float64
+		in      float64
+		want    float64
+	}{
+		{name: "InHgToMillibars", convert: InHgToMillibars, in: 29.92, want: 1013.2},
+		{name: "MillibarsToInHg", convert: MillibarsToInHg, in: 1013.25, want: 29.92},
+		{name: "HpaToMmHg", convert: HpaToMmHg, in: 1013.25, want: 760.0},
+		{name: "HpaToKpa", convert: HpaToKpa, in: 1013.25, want: 101.325},
+		{name: "InHgToMmHg", convert: InHgToMmHg, in: 29.92, want: 759.97},
+		{name: "InHgToKpa", convert: InHgToKpa, in: 29.92, want: 101.32},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, tt.convert(tt.in), 0.01)
+		})
+	}
+}
+
+func TestDistanceConversions(t *testing.T) {
+	t.Parallel()
+
+	assert.InDelta(t, 80.47, MilesToKM(50), 0.01)
+	assert.InDelta(t, 31.07, KMToMiles(50), 0.01)
+	assert.InDelta(t, 50, KMToMiles(MilesToKM(50)), 1e-9)
+}
+
+func TestSpeedAndHeightConversions(t *testing.T) {
+	t.Parallel()
+
+	assert.InDelta(t, 37.04, KnotsToKmh(20), 0.01)
+	assert.InDelta(t, 23.02, KnotsToMph(20), 0.01)
+	assert.InDelta(t, 1066.8, FeetToMeters(3500), 0.01)
+}
+
+func TestRelativeHumidity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		temp, dew   int
+		wantPercent int
+	}{
+		{name: "saturated", temp: 10, dew: 10, wantPercent: 100},
+		{name: "humid", temp: 21, dew: 18, wantPercent: 83},
+		{name: "dry", temp: 30, dew: 0, wantPercent: 14},
+		{name: "below freezing", temp: -5, dew: -10, wantPercent: 68},
+		{name: "dew point above temperature clamped", temp: 5, dew: 6, wantPercent: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantPercent, RelativeHumidity(tt.temp, tt.dew))
+		})
+	}
+}
+
+func TestWindChill(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		temp   int
+		windKt int
+		want   float64
+	}{
+		{name: "cold and windy", temp: -10, windKt: 20, want: -20.4},
+		{name: "freezing", temp: 0, windKt: 10, want: -5.0},
+		{name: "too warm", temp: 12, windKt: 20, want: 12},
+		{name: "light wind", temp: 0, windKt: 5, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, WindChill(tt.temp, tt.windKt), 0.1)
+		})
+	}
+}
+
+func TestHeatIndex(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		temp, dew int
+		want      float64
+	}{
+		{name: "hot and humid", temp: 32, dew: 23, want: 36.8},
+		{name: "hot and dry", temp: 35, dew: 20, want: 37.8},
+		{name: "too cool", temp: 26, dew: 20, want: 26},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, HeatIndex(tt.temp, tt.dew), 0.1)
+		})
+	}
+}
@@ -0,0 +1,867 @@
+package wx
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/utils/ptr"
+)
+
+// DecodeTAF decodes a raw TAF string into a TAF struct
+func DecodeTAF(raw string) TAF {
+	return DecodeTAFAt(raw, time.Now())
+}
+
+// DecodeTAFAt decodes a raw TAF string like DecodeTAF, placing its issue time on or before
+// now's day and its valid and change-group times in the months nearest to now
+func DecodeTAFAt(raw string, now time.Time) TAF {
+	t := TAF{WeatherData: WeatherData{Raw: raw}}
+	now = now.UTC()
+
+	// Remove line breaks and consolidate whitespace
+	cleanedRaw := strings.TrimSpace(raw)
+	cleanedRaw = regexp.MustCompile(`\s+`).ReplaceAllString(cleanedRaw, " ")
+
+	// Split into parts
+	parts := strings.Fields(cleanedRaw)
+	if len(parts) < 3 {
+		return t
+	}
+
+	// Check for TAF indicator and extract station
+	startIdx := 0
+	if parts[0] == "TAF" {
+		startIdx = 1
+		t.Station = parts[1]
+	} else {
+		t.Station = parts[0]
+	}
+	// Initialize default site info
+	t.SiteInfo = SiteInfo{
+		Name:    t.Station,
+		State:   "",
+		Country: "",
+	}
+
+	// Parse issuance time
+	for i := startIdx + 1; i < len(parts); i++ {
+		if timeRegex.MatchString(parts[i]) {
+			if parsedTime, err := parseTime(parts[i], now); err == nil {
+				t.Time = parsedTime
+			}
+			continue
+		}
+
+		// Parse valid time period, skipping stray tokens that only look like one
+		if validRegex.MatchString(parts[i]) {
+			if from, to, ok := parseValidPeriod(parts[i], t.Time, now); ok {
+				t.ValidFrom, t.ValidTo = from, to
+				break
+			}
+		}
+	}
+
+	// Take the administrative AMD NOT SKED tail off before parsing forecast groups
+	parts = parseAmendmentsNotScheduled(&t, parts, now)
+
+	// Create base forecast from the main TAF line
+	baseForecast := Forecast{
+		Type: "BASE",
+		From: t.ValidFrom,
+		Raw:  cleanedRaw,
+	}
+
+	// Find index of first FM, BECMG, TEMPO, or PROB
+	var changeIndex int
+	for i := range parts {
+		if isChangeGroupStart(parts, i) {
+			changeIndex = i
+			break
+		}
+
+		if i >= len(parts)-1 {
+			changeIndex = len(parts)
+		}
+	}
+
+	// Parse elements for base forecast
+	if changeIndex > 0 {
+		validTimeRegex := regexp.MustCompile(`\d{4}/\d{4}`)
+		for i := startIdx + 1; i < changeIndex; i++ {
+			part := parts[i]
+			if timeRegex.MatchString(part) || validTimeRegex.MatchString(part) {
+				continue
+			}
+			if applyProbabilityModifier(&baseForecast, part) {
+				continue
+			}
+			parseForecastElement(&baseForecast, part)
+		}
+	}
+
+	t.Forecasts = append(t.Forecasts, baseForecast)
+
+	// Process change groups
+	i := changeIndex
+	for i < len(parts) {
+		part := parts[i]
+
+		if part == "FM" || strings.HasPrefix(part, "FM") {
+			forecast := Forecast{
+				Type: "FM",
+				Raw:  part,
+			}
+
+			// Parse FM time
+			var fmTime string
+			if part == "FM" && i+1 < len(parts) {
+				fmTime = parts[i+1]
+				i++
+			} else if strings.HasPrefix(part, "FM") {
+				fmTime = part[2:]
+			}
+
+			if len(fmTime) == 6 {
+				day, _ := strconv.Atoi(fmTime[0:2])
+				hour, _ := strconv.Atoi(fmTime[2:4])
+				minute, _ := strconv.Atoi(fmTime[4:6])
+
+				forecast.From = nearestDayTime(t.periodReference(now), day, hour, minute)
+			}
+
+			// Set the To time of the previous forecast if it needs it
+			if len(t.Forecasts) > 0 && t.Forecasts[len(t.Forecasts)-1].To.IsZero() {
+				t.Forecasts[len(t.Forecasts)-1].To = forecast.From
+			}
+
+			// Parse elements until next change indicator
+			i = parseChangeGroupElements(&forecast, parts, i+1)
+
+			t.Forecasts = append(t.Forecasts, forecast)
+			continue
+		}
+
+		if part == "BECMG" || part == "TEMPO" {
+			forecast := Forecast{
+				Type: part,
+				Raw:  part,
+			}
+
+			// Parse time period if available
+			i++
+			if i < len(parts) {
+				timeRegex := regexp.MustCompile(`^(\d{2})(\d{2})/(\d{2})(\d{2})$`)
+				if timeRegex.MatchString(parts[i]) {
+					matches := timeRegex.FindStringSubmatch(parts[i])
+					fromDay, _ := strconv.Atoi(matches[1])
+					fromHour, _ := strconv.Atoi(matches[2])
+					toDay, _ := strconv.Atoi(matches[3])
+					toHour, _ := strconv.Atoi(matches[4])
+
+					forecast.From = nearestDayTime(t.periodReference(now), fromDay, fromHour, 0)
+					forecast.To = nearestDayTime(forecast.From, toDay, toHour, 0)
+					i++
+				}
+			}
+
+			// Parse elements until next change indicator
+			i = parseChangeGroupElements(&forecast, parts, i)
+
+			t.Forecasts = append(t.Forecasts, forecast)
+			continue
+		}
+
+		// Handle PROBnn forecasts
+		if strings.HasPrefix(part, "PROB") {
+			// A malformed probability (e.g. PROBXX) still starts its own period, so the
+			// conditions after it aren't lost; the probability is just left unknown (0)
+			probValue, _ := strconv.Atoi(part[4:])
+
+			forecast := Forecast{
+				Type:        part,
+				Probability: probValue,
+				Raw:         part,
+			}
+
+			// Parse time period if available
+			i++
+			if i < len(parts) {
+				timeRegex := regexp.MustCompile(`^(\d{2})(\d{2})/(\d{2})(\d{2})$`)
+				if timeRegex.MatchString(parts[i]) {
+					matches := timeRegex.FindStringSubmatch(parts[i])
+					fromDay, _ := strconv.Atoi(matches[1])
+					fromHour, _ := strconv.Atoi(matches[2])
+					toDay, _ := strconv.Atoi(matches[3])
+					toHour, _ := strconv.Atoi(matches[4])
+
+					forecast.From = nearestDayTime(t.periodReference(now), fromDay, fromHour, 0)
+					forecast.To = nearestDayTime(forecast.From, toDay, toHour, 0)
+					i++
+				}
+			}
+
+			// If no time period is given, use the current valid period
+			if forecast.From.IsZero() {
+				// Try to use the time from the most recent forecast
+				if len(t.Forecasts) > 0 {
+					lastForecast := t.Forecasts[len(t.Forecasts)-1]
+					forecast.From = lastForecast.From
+					forecast.To = lastForecast.To
+				} else {
+					forecast.From = t.ValidFrom
+					forecast.To = t.ValidTo
+				}
+			}
+
+			// Parse elements until next change indicator
+			i = parseChangeGroupElements(&forecast, parts, i)
+
+			t.Forecasts = append(t.Forecasts, forecast)
+			continue
+		}
+
+		i++
+	}
+
+	// Set the final forecast's To time if needed
+	if len(t.Forecasts) > 0 && t.Forecasts[len(t.Forecasts)-1].To.IsZero() {
+		t.Forecasts[len(t.Forecasts)-1].To = t.ValidTo
+	}
+
+	return t
+}
+
+// maxTAFValidity is the longest valid period a TAF can have; ICAO TAFs run for up to 30 hours
+const maxTAFValidity = 30 * time.Hour
+
+// parseValidPeriod parses a TAF's DDHH/DDHH valid period. The validity starts around the
+// issuance time (or now, when that's unknown) and ends after it starts, possibly in the next
+// month. It reports false for an implausible period: one starting more than a day from the
+// issue date, or running backwards or for longer than maxTAFValidity.
+func parseValidPeriod(s string, issued, now time.Time) (from, to time.Time, ok bool) {
+	matches := validRegex.FindStringSubmatch(s)
+	if matches == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	fromDay, _ := strconv.Atoi(matches[1])
+	fromHour, _ := strconv.Atoi(matches[2])
+	toDay, _ := strconv.Atoi(matches[3])
+	toHour, _ := strconv.Atoi(matches[4])
+	if fromHour > 24 || toHour > 24 {
+		return time.Time{}, time.Time{}, false
+	}
+
+	ref := issued
+	if ref.IsZero() {
+		ref = now
+	}
+	from = nearestDayTime(ref, fromDay, fromHour, 0)
+	to = nearestDayTime(from, toDay, toHour, 0)
+	if from.IsZero() || to.IsZero() || !to.After(from) || to.Sub(from) > maxTAFValidity {
+		return time.Time{}, time.Time{}, false
+	}
+
+	// Without an issue time there's nothing to check the start against
+	if !issued.IsZero() {
+		issueDate := issued.Truncate(24 * time.Hour)
+		if from.Truncate(24*time.Hour).Sub(issueDate).Abs() > 24*time.Hour {
+			return time.Time{}, time.Time{}, false
+		}
+	}
+
+	return from, to, true
+}
+
+// parseAmendmentsNotScheduled records an "AMD NOT SKED" remark (optionally followed by
+// "TIL DDHHMM", "AFT DDHHMM" or a "DDHH/DDHH" or "HHMM/HHMM" window) on the TAF and returns the parts
+// without it
+func parseAmendmentsNotScheduled(t *TAF, parts []string, now time.Time) []string {
+	for i := 2; i+2 < len(parts); i++ {
+		if parts[i] != "AMD" || parts[i+1] != "NOT" || parts[i+2] != "SKED" {
+			continue
+		}
+
+		t.AmendmentsNotScheduled = true
+		rest := parts[i+3:]
+
+		ref := t.periodReference(now)
+		switch {
+		case len(rest) >= 2 && rest[0] == "TIL":
+			t.AmendmentsTo, _ = parseTAFDayTime(rest[1], ref)
+		case len(rest) >= 2 && rest[0] == "AFT":
+			t.AmendmentsFrom, _ = parseTAFDayTime(rest[1], ref)
+		case len(rest) >= 1 && validRegex.MatchString(rest[0]):
+			from, to, _ := strings.Cut(rest[0], "/")
+			t.AmendmentsFrom, t.AmendmentsTo = t.amendmentWindow(from, to, ref)
+		}
+
+		return parts[:i]
+	}
+
+	return parts
+}
+
+// amendmentWindow places an AMD NOT SKED window read as DDHH/DDHH, or as HHMM/HHMM on the
+// TAF's validity days when that reading falls outside the validity (e.g. 0400/1100 for
+// 04:00 to 11:00 UTC)
+func (t *TAF) amendmentWindow(fromText, toText string, ref time.Time) (time.Time, time.Time) {
+	from, _ := parseTAFDayTime(fromText, ref)
+	to, _ := parseTAFDayTime(toText, ref)
+	if t.ValidFrom.IsZero() || t.ValidTo.IsZero() || t.withinValidity(from) && t.withinValidity(to) && to.After(from) {
+		return from, to
+	}
+
+	fromOffset, fromOK := parseHourMinute(fromText)
+	toOffset, toOK := parseHourMinute(toText)
+	if !fromOK || !toOK {
+		return from, to
+	}
+
+	day := t.ValidFrom.Truncate(24 * time.Hour)
+	from, to = day.Add(fromOffset), day.Add(toOffset)
+	if !to.After(from) {
+		to = to.Add(24 * time.Hour)
+	}
+	// A window that ended before the validity began is on the next day
+	if !to.After(t.ValidFrom) {
+		from, to = from.Add(24*time.Hour), to.Add(24*time.Hour)
+	}
+	return from, to
+}
+
+// withinValidity reports whether tm is within the TAF's validity period
+func (t *TAF) withinValidity(tm time.Time) bool {
+	return !tm.Before(t.ValidFrom) && !tm.After(t.ValidTo)
+}
+
+// parseHourMinute parses an HHMM time of day as the time since midnight
+func parseHourMinute(s string) (time.Duration, bool) {
+	if len(s) != 4 {
+		return 0, false
+	}
+	hour, err := strconv.Atoi(s[:2])
+	if err != nil || hour > 24 {
+		return 0, false
+	}
+	minute, err := strconv.Atoi(s[2:])
+	if err != nil || minute > 59 {
+		return 0, false
+	}
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, true
+}
+
+// parseTAFDayTime parses a DDHH or DDHHMM time in the month nearest to ref
+func parseTAFDayTime(s string, ref time.Time) (time.Time, bool) {
+	if len(s) != 4 && len(s) != 6 {
+		return time.Time{}, false
+	}
+
+	values := make([]int, 0, 3)
+	for j := 0; j < len(s); j += 2 {
+		v, err := strconv.Atoi(s[j : j+2])
+		if err != nil {
+			return time.Time{}, false
+		}
+		values = append(values, v)
+	}
+	if len(values) == 2 {
+		values = append(values, 0)
+	}
+
+	return nearestDayTime(ref, values[0], values[1], values[2]), true
+}
+
+// periodReference is the time TAF period groups are placed near: the start of the
+// validity, or the issuance time or now if it isn't known
+func (t TAF) periodReference(now time.Time) time.Time {
+	switch {
+	case !t.ValidFrom.IsZero():
+		return t.ValidFrom
+	case !t.Time.IsZero():
+		return t.Time
+	default:
+		return now
+	}
+}
+
+// isChangeGroupStart reports whether parts[i] starts a new forecast change group.
+// A PROB token only starts a new period when a valid period (or a TEMPO/BECMG
+// indicator) follows it; otherwise it modifies the conditions of the current group.
+func isChangeGroupStart(parts []string, i int) bool {
+	part := parts[i]
+	if part == "FM" || strings.HasPrefix(part, "FM") ||
+		part == "BECMG" || part == "TEMPO" {
+		return true
+	}
+
+	if strings.HasPrefix(part, "PROB") {
+		if i+1 >= len(parts) {
+			return false
+		}
+		next := parts[i+1]
+		return validRegex.MatchString(next) || next == "TEMPO" || next == "BECMG"
+	}
+
+	return false
+}
+
+// applyProbabilityModifier records a PROB token that qualifies conditions within a
+// change group rather than starting a new period. Returns true if the token was consumed.
+func applyProbabilityModifier(forecast *Forecast, part string) bool {
+	if !strings.HasPrefix(part, "PROB") {
+		return false
+	}
+
+	if matches := probRegex.FindStringSubmatch(part); matches != nil && forecast.Probability == 0 {
+		forecast.Probability, _ = strconv.Atoi(matches[1])
+	}
+	return true
+}
+
+// parseMETARTrends parses the trend forecasts appended to a METAR observation (e.g.
+// "BECMG 25015KT" or "TEMPO FM1000 TL1100 3000 RA BKN008"). FM, TL and AT times are placed
+// on the observation's day, or the next day when they'd fall well before it.
+func parseMETARTrends(parts []string, observed time.Time) []Forecast {
+	var trends []Forecast
+	for _, part := range parts {
+		switch part {
+		case "TEMPO", "BECMG", "INTER", "NOSIG":
+			trends = append(trends, Forecast{Type: part, Raw: part})
+			continue
+		}
+		if len(trends) == 0 {
+			continue
+		}
+
+		trend := &trends[len(trends)-1]
+		trend.Raw += " " + part
+
+		if matches := trendTimeRegex.FindStringSubmatch(part); matches != nil {
+			if observed.IsZero() {
+				continue
+			}
+			hour, _ := strconv.Atoi(matches[2])
+			minute, _ := strconv.Atoi(matches[3])
+			at := time.Date(observed.Year(), observed.Month(), observed.Day(), hour, minute, 0, 0, time.UTC)
+			if at.Before(observed.Add(-time.Hour)) {
+				at = at.AddDate(0, 0, 1)
+			}
+
+			switch matches[1] {
+			case "FM", "AT":
+				trend.From = at
+			case "TL":
+				trend.To = at
+			}
+			continue
+		}
+
+		parseForecastElement(trend, part)
+	}
+	return trends
+}
+
+// parseChangeGroupElements parses forecast elements starting at index i until the
+// next change group, returning the index where parsing stopped
+func parseChangeGroupElements(forecast *Forecast, parts []string, i int) int {
+	for i < len(parts) {
+		if isChangeGroupStart(parts, i) {
+			break
+		}
+		if !applyProbabilityModifier(forecast, parts[i]) {
+			parseForecastElement(forecast, parts[i])
+		}
+		i++
+	}
+	return i
+}
+
+// isWeatherCode checks if a string contains any weather codes
+func isWeatherCode(s string) bool {
+	// Don't match cloud patterns as weather
+	if strings.HasPrefix(s, "SKC") ||
+		strings.HasPrefix(s, "CLR") ||
+		strings.HasPrefix(s, "FEW") ||
+		strings.HasPrefix(s, "SCT") ||
+		strings.HasPrefix(s, "BKN") ||
+		strings.HasPrefix(s, "OVC") {
+		return false
+	}
+
+	// Military color states (BLU, BLACKWHT, BLU/WHT, ...) contain weather codes like BL and RA
+	if colorStateRegex.MatchString(s) || recentColorRegex.MatchString(s) {
+		return false
+	}
+
+	for code := range WeatherCodes {
+		if strings.Contains(s, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// isVisibilityInMeters checks if a string is a visibility value in meters
+func isVisibilityInMeters(s string) bool {
+	// Basic check for 4-digit number (standard visibility in meters)
+	if visRegexNum.MatchString(s) {
+		return true
+	}
+
+	// Check for visibility with direction (e.g., "2000NE")
+	if visRegexDir.MatchString(s) {
+		return true
+	}
+
+	// Check for visibility with No Directional Variation (e.g., "4000NDV")
+	if ndvRegex.MatchString(s) {
+		return true
+	}
+
+	// Special case for visibility less than 50m reported as "0000"
+	if s == "0000" {
+		return true
+	}
+
+	return false
+}
+
+// isVerticalVisibility checks if a string is a vertical visibility value
+func isVerticalVisibility(s string) bool {
+	return vvRegex.MatchString(s)
+}
+
+// DecodeMETAR decodes a raw METAR string into a METAR struct with site information
+func DecodeMETAR(raw string) METAR {
+	return DecodeMETARAt(raw, time.Now())
+}
+
+// DecodeMETARAt decodes a raw METAR string like DecodeMETAR, placing its observation time
+// on or before now's day
+func DecodeMETARAt(raw string, now time.Time) METAR {
+	m := METAR{WeatherData: WeatherData{Raw: raw}}
+	parts := strings.Fields(raw)
+
+	if len(parts) < 2 {
+		return m
+	}
+
+	// Station code
+	m.Station = parts[0]
+
+	// Initialize default site info
+	m.SiteInfo = SiteInfo{
+		Name:    m.Station,
+		State:   "",
+		Country: "",
+	}
+
+	// Time
+	if timeRegex.MatchString(parts[1]) {
+		if parsedTime, err := parseTime(parts[1], now); err == nil {
+			m.Time = parsedTime
+		}
+	}
+
+	// Find the RMK section, BECMG section, and TEMPO section if they exist
+	rmkIndex := -1
+	sectionIndices := []int{}
+
+	// Find all TEMPO, BECMG, and RMK sections
+	for i, part := range parts {
+		if part == "RMK" {
+			rmkIndex = i
+			break // RMK always ends the main section
+		}
+		if part == "TEMPO" || part == "BECMG" || part == "INTER" {
+			sectionIndices = append(sectionIndices, i)
+		}
+	}
+
+	// Find the first section marker
+	endIndex := len(parts)
+	if rmkIndex != -1 {
+		endIndex = rmkIndex
+	}
+
+	// Find the earliest TEMPO or BECMG section
+	for _, idx := range sectionIndices {
+		if idx < endIndex {
+			endIndex = idx
+		}
+	}
+
+	// Process fields in the main METAR section
+	// Variable to track if we've already found a pressure value
+	pressureFound := false
+
+	// THIS IS GROSS, I DON'T LIKE IT
+	// Special handling for split wind shear tokens
+	for i := 2; i < endIndex-1; i++ {
+		// Handle "WS ALL RWY" pattern
+		if i+2 < endIndex && parts[i] == "WS" && parts[i+1] == "ALL" && parts[i+2] == "RWY" {
+			// Found the three-token pattern for wind shear
+			m.WindShear = append(m.WindShear, WindShear{
+				Type:  "RWY",
+				Phase: "ALL",
+				Raw:   "WS ALL RWY",
+			})
+			// Skip these tokens in the main loop
+			parts[i] = "__PROCESSED__"
+			parts[i+1] = "__PROCESSED__"
+			parts[i+2] = "__PROCESSED__"
+			// Handle "WS R##" pattern
+		} else if i+1 < endIndex && parts[i] == "WS" && strings.HasPrefix(parts[i+1], "R") && len(parts[i+1]) > 1 {
+			// Found the two-token pattern for wind shear on a runway
+			m.WindShear = append(m.WindShear, WindShear{
+				Type:   "RWY",
+				Runway: parts[i+1][1:], // Remove the 'R' prefix
+				Raw:    parts[i] + " " + parts[i+1],
+			})
+			// Skip these tokens in the main loop
+			parts[i] = "__PROCESSED__"
+			parts[i+1] = "__PROCESSED__"
+		}
+	}
+
+	for i := 2; i < endIndex; i++ {
+		part := parts[i]
+
+		// Skip tokens that were already processed
+		if part == "__PROCESSED__" {
+			continue
+		}
+
+		// NOSIG is a trend forecast of no significant change
+		if part == "NOSIG" {
+			m.Trends = append(m.Trends, Forecast{Type: part, Raw: part})
+			continue
+		}
+
+		// Special conditions (AUTO, COR, etc.)
+		if specialRegex.MatchString(part) {
+			m.SpecialCodes = append(m.SpecialCodes, part)
+			continue
+		}
+
+		// Wind - check KT, MPS and KMH formats
+		if windRegex.MatchString(part) || windRegexMPS.MatchString(part) || windRegexKMH.MatchString(part) {
+			m.Wind = ParseWind(part)
+
+			// Check if the next token is a wind variation
+			if i+1 < endIndex && windVarRegex.MatchString(parts[i+1]) {
+				m.WindVariation = parseWindVariation(parts[i+1])
+				i++ // Skip the next token since we've processed it
+			}
+
+			continue
+		}
+		// Check for wind shear
+		if strings.HasPrefix(part, "WS") {
+			ws := ParseWindShear(part)
+			m.WindShear = append(m.WindShear, ws)
+			continue
+		}
+
+		// Visibility - handle special cases like "1 1/2SM" (split across two tokens)
+		if i+1 < endIndex && strings.HasSuffix(parts[i+1], "SM") &&
+			!strings.HasPrefix(parts[i], "P") && !strings.HasPrefix(parts[i], "M") &&
+			!strings.Contains(parts[i], "/") && len(parts[i]) == 1 {
+			// This could be a split visibility value like "1 1/2SM"
+			m.Visibility = ParseVisibility(parts[i] + " " + parts[i+1])
+			i++ // Skip the next token since we've processed it
+			continue
+		}
+
+		// Standard visibility check for statute miles
+		if visRegexM.MatchString(part) {
+			m.Visibility = ParseVisibility(part)
+			continue
+		}
+
+		// Check for visibility in meters
+		if isVisibilityInMeters(part) {
+			m.Visibility = ParseVisibility(part)
+			continue
+		}
+
+		// Check for vertical visibility (e.g., "VV002")
+		if isVerticalVisibility(part) {
+			matches := vvRegex.FindStringSubmatch(part)
+			if len(matches) > 1 {
+				vertVis, _ := strconv.Atoi(matches[1])
+				m.VertVis = vertVis
+			}
+			continue
+		}
+
+		// Runway Visual Range (RVR) and Runway Conditions
+		if runwayClearedRegex.MatchString(part) || runwayCondRegex.MatchString(part) || runwayStateRegex.MatchString(part) {
+			// Parse the runway condition
+			cond := ParseRunwayCondition(part)
+			if cond.Runway == "" {
+				// A runway state group with an invalid code
+				m.Unhandled = append(m.Unhandled, part)
+				continue
+			}
+			m.RunwayConditions = append(m.RunwayConditions, cond)
+			// Add visual range to legacy RVR field for compatibility
+			if cond.State == nil {
+				m.RVR = append(m.RVR, part)
+			}
+			continue
+		}
+
+		// Basic RVR format (legacy)
+		if RVRRegex.MatchString(part) {
+			m.RVR = append(m.RVR, part)
+			continue
+		}
+
+		// Weather phenomena
+		if isWeatherCode(part) {
+			m.Weather = append(m.Weather, part)
+			continue
+		}
+
+		// Wind shear
+		if strings.Contains(part, "WS") {
+			ws := ParseWindShear(part)
+			m.WindShear = append(m.WindShear, ws)
+			continue
+		}
+
+		// Clouds
+		if cloudRegex.MatchString(part) {
+			cloud := ParseCloud(part)
+			m.Clouds = append(m.Clouds, cloud)
+			continue
+		}
+
+		// Temperature and dew point - standard format
+		if tempRegex.MatchString(part) {
+			matches := tempRegex.FindStringSubmatch(part)
+			temp, _ := strconv.Atoi(matches[2])
+			if matches[1] == "M" {
+				temp = -temp
+			}
+			// Store temperature as a pointer to int
+			m.Temperature = &temp
+
+			dewPoint, _ := strconv.Atoi(matches[4])
+			if matches[3] == "M" {
+				dewPoint = -dewPoint
+			}
+			// Store dew point as a pointer to int
+			m.DewPoint = &dewPoint
+			continue
+		}
+
+		// Temperature only format "M01/" (missing dew point)
+		if tempOnlyRegex.MatchString(part) {
+			matches := tempOnlyRegex.FindStringSubmatch(part)
+			temp, _ := strconv.Atoi(matches[2])
+			if matches[1] == "M" {
+				temp = -temp
+			}
+			// Store temperature as a pointer to int
+			m.Temperature = &temp
+			// Leave DewPoint as nil to indicate missing value
+			continue
+		}
+		// Pressure in Q format (hPa/millibars) - only process if we haven't found pressure yet
+		if len(part) > 1 && part[0] == 'Q' {
+			if pressureFound {
+				continue
+			}
+			pressureStr := part[1:]
+			pressureInt, err := strconv.Atoi(pressureStr)
+			if err == nil {
+				m.Pressure = float64(pressureInt)
+				m.PressureUnit = "hPa"
+				pressureFound = true
+			}
+			continue
+		}
+
+		// Pressure in A format (inches of mercury) - only process if we haven't found pressure yet
+		if pressureRegex.MatchString(part) {
+			if pressureFound {
+				continue
+			}
+			matches := pressureRegex.FindStringSubmatch(part)
+			pressureStr := matches[1]
+			pressureInt, _ := strconv.Atoi(pressureStr)
+			m.Pressure = float64(pressureInt) / 100.0
+			m.PressureUnit = "inHg"
+			pressureFound = true
+			continue
+		}
+
+		// CAVOK - Ceiling And Visibility OK
+		if cavokRegex.MatchString(part) {
+			m.Visibility = ParseVisibility("CAVOK")
+			m.SpecialCodes = append(m.SpecialCodes, "CAVOK")
+			continue
+		}
+
+		// Military color state (e.g. BLU, BLACKWHT)
+		if colorStateRegex.MatchString(part) {
+			m.ColorStateCode = part
+			continue
+		}
+
+		// Military color state followed by the recent one (e.g. BLU/WHT)
+		if matches := recentColorRegex.FindStringSubmatch(part); matches != nil {
+			m.ColorStateCode = matches[1]
+			m.RecentColorState = matches[2]
+			continue
+		}
+
+		m.Unhandled = append(m.Unhandled, part)
+	}
+
+	// Trend forecasts between the main section and the remarks
+	trendEnd := len(parts)
+	if rmkIndex != -1 {
+		trendEnd = rmkIndex
+	}
+	if endIndex < trendEnd {
+		m.Trends = append(m.Trends, parseMETARTrends(parts[endIndex:trendEnd], m.Time)...)
+	}
+
+	// Process remarks if they exist
+	if rmkIndex != -1 && rmkIndex+1 < len(parts) {
+		m.Remarks = ProcessRemarks(parts[rmkIndex+1:])
+
+		// Keep tower and surface visibility alongside the prevailing visibility
+		for _, rmk := range m.Remarks {
+			if value, ok := strings.CutPrefix(rmk.Raw, "TWR VIS "); ok {
+				m.TowerVisibility = ParseVisibility(remarkVisibilityValue(value))
+			} else if value, ok := strings.CutPrefix(rmk.Raw, "SFC VIS "); ok {
+				m.SurfaceVisibility = ParseVisibility(remarkVisibilityValue(value))
+			} else if layers := parseCloudLayerRemark(rmk.Raw); layers != nil {
+				m.CloudLayers = layers
+			} else if cloud, ok := convectiveCloudRemark(rmk.Raw); ok {
+				m.ConvectiveClouds = append(m.ConvectiveClouds, cloud)
+			} else if temp, dew, ok := parsePreciseTemperature(rmk.Raw); ok {
+				// A missing body temperature or dew point can still be given precisely in remarks
+				if m.Temperature == nil {
+					m.Temperature = ptr.To(int(math.Round(temp)))
+				}
+				if m.DewPoint == nil && dew != nil {
+					m.DewPoint = ptr.To(int(math.Round(*dew)))
+				}
+			} else if pressure, unit, ok := parseRemarkAltimeter(rmk.Raw); ok {
+				m.RemarkPressure, m.RemarkPressureUnit = pressure, unit
+			} else if code, _, _ := strings.Cut(rmk.Raw, " "); m.ColorStateCode == "" && colorStateRegex.MatchString(code) {
+				m.ColorStateCode = code
+			}
+		}
+	}
+
+	return m
+}
@@ -0,0 +1,1254 @@
+package wx
+
+import (
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rmitchellscott/WxCraft/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestLogDirectory creates a directory for test logs if it doesn't exist
+func createTestLogDirectory(t *testing.T) string {
+	// Create logs directory
+	logDir := "test-logs"
+	err := os.MkdirAll(logDir, 0755)
+	if err != nil {
+		t.Fatalf("Failed to create test log directory: %v", err)
+	}
+	return logDir
+}
+
+// logTestFailures writes the failure information to a log file
+func logTestFailures(t *testing.T, testName string, content string) string {
+	logDir := createTestLogDirectory(t)
+
+	// Create log filename with timestamp to avoid overwrites
+	timestamp := time.Now().Format("20060102-150405")
+	logFileName := fmt.Sprintf("%s_%s.log", testName, timestamp)
+	logFilePath := filepath.Join(logDir, logFileName)
+
+	err := os.WriteFile(logFilePath, []byte(content), 0644)
+	if err != nil {
+		t.Errorf("Failed to write to log file: %v", err)
+		return ""
+	}
+
+	return logFilePath
+}
+
+func decodeMETARList(t *testing.T) iter.Seq2[string, METAR] {
+	return func(yield func(string, METAR) bool) {
+		scanner := testdata.METAR(t)
+		for scanner.Scan() {
+			if strings.Contains(scanner.Text(), "//") {
+				continue
+			}
+			line := strings.TrimSpace(scanner.Text())
+			if !yield(line, DecodeMETAR(line)) {
+				return
+			}
+		}
+	}
+}
+
+func TestDecodeMETAR_stationCode(t *testing.T) {
+	t.Parallel()
+
+	for line, metar := range decodeMETARList(t) {
+		fields := strings.Fields(line)
+		if fields[0] != metar.SiteInfo.Name {
+			t.Run(line, func(t *testing.T) {
+				t.Errorf("Raw METAR: %s\nExpected station code: %s\nActual station code: %s\n\n",
+					line, fields[0], metar.SiteInfo.Name)
+			})
+		}
+	}
+}
+
+func TestDecodeMETAR_time(t *testing.T) {
+	t.Parallel()
+
+	for line, metar := range decodeMETARList(t) {
+		fields := strings.Fields(line)
+		if fields[1] != "COR" {
+			got := metar.Time.Format("021504") + "Z"
+			if fields[1] != got {
+				t.Run(line, func(t *testing.T) {
+					t.Errorf("Raw METAR: %s\nExpected time: %s\nActual time: %s\n\n",
+						line, fields[1], got)
+				})
+			}
+		}
+	}
+}
+
+func TestDecodeMETAR_remarks(t *testing.T) {
+	t.Parallel()
+
+	var unknownValues []string
+	var failedValueCount int
+
+	for line, metar := range decodeMETARList(t) {
+		unknown := make([]string, 0, len(metar.Remarks))
+		for _, rmk := range metar.Remarks {
+			if rmk.Description == "unknown remark code" {
+				unknown = append(unknown, rmk.Raw)
+				unknownValues = append(unknownValues, rmk.Raw)
+			}
+		}
+
+		if len(unknown) != 0 {
+			failedValueCount++
+			t.Run(line, func(t *testing.T) {
+				t.Errorf("Unknown remarks:\nMETAR   = %s\nRemarks = %v", line, unknown)
+			})
+		}
+	}
+
+	t.Run("unknown remark count", func(t *testing.T) {
+		slices.Sort(unknownValues)
+		unknownValues = slices.Compact(unknownValues)
+		assert.Empty(t, len(unknownValues))
+	})
+
+	t.Run("metars with failed remarks", func(t *testing.T) {
+		assert.Zero(t, failedValueCount)
+	})
+}
+
+func TestDecodeMETAR_vicinityRemarks(t *testing.T) {
+	t.Parallel()
+
+	for line, metar := range decodeMETARList(t) {
+		for _, rmk := range metar.Remarks {
+			if !vicinityRegex.MatchString(strings.Fields(rmk.Raw)[0]) {
+				continue
+			}
+			if !strings.Contains(rmk.Description, "in the vicinity") {
+				t.Run(line, func(t *testing.T) {
+					t.Errorf("Raw METAR: %s\nRemark %q decoded as %q", line, rmk.Raw, rmk.Description)
+				})
+			}
+		}
+	}
+}
+
+func TestDecodeMETAR_remarkOrder(t *testing.T) {
+	t.Parallel()
+
+	// The T group and SLP decode the same whichever comes first
+	for _, raw := range []string{
+		"KMRY 081554Z 29011KT 10SM FEW008 M/M A3012 RMK AO2 T01330094 SLP201",
+		"KMRY 081554Z 29011KT 10SM FEW008 M/M A3012 RMK AO2 SLP201 T01330094",
+	} {
+		t.Run(raw, func(t *testing.T) {
+			metar := DecodeMETAR(raw)
+
+			descriptions := make(map[string]string)
+			for _, remark := range metar.Remarks {
+				descriptions[remark.Raw] = remark.Description
+			}
+			assert.Equal(t, "temperature 13.3°C, dew point 9.4°C", descriptions["T01330094"])
+			assert.Equal(t, "sea level pressure 1020.1 hPa", descriptions["SLP201"])
+
+			// The missing body temperature and dew point are filled in from the T group
+			require.NotNil(t, metar.Temperature)
+			require.NotNil(t, metar.DewPoint)
+			assert.Equal(t, 13, *metar.Temperature)
+			assert.Equal(t, 9, *metar.DewPoint)
+		})
+	}
+}
+
+func TestDecodeMETAR_runwayState(t *testing.T) {
+	t.Parallel()
+
+	metar := DecodeMETAR("UUEE 081530Z 18004MPS 9999 -SN BKN010 M02/M04 Q1012 R06L/5/00// R24/000065 NOSIG")
+	require.Len(t, metar.RunwayConditions, 2)
+	assert.Equal(t, &RunwayState{Deposit: "wet snow", Depth: "less than 1 mm"}, metar.RunwayConditions[0].State)
+	assert.Equal(t, &RunwayState{Deposit: "clear and dry", Extent: "0%", Depth: "less than 1 mm", Friction: "friction coefficient 0.65"}, metar.RunwayConditions[1].State)
+	assert.Empty(t, metar.RVR, "runway state groups aren't visual range")
+	assert.Empty(t, metar.Unhandled)
+
+	// A cleared runway group carries only the friction, which may be slashed out
+	metar = DecodeMETAR("UUEE 081530Z 18004MPS 9999 BKN010 M02/M04 Q1012 R24/CLRD70 R88/CLRD//")
+	require.Len(t, metar.RunwayConditions, 2)
+	assert.True(t, metar.RunwayConditions[0].Cleared)
+	assert.Equal(t, &RunwayState{Friction: "friction coefficient 0.70"}, metar.RunwayConditions[0].State)
+	assert.Equal(t, "88", metar.RunwayConditions[1].Runway)
+	assert.True(t, metar.RunwayConditions[1].Cleared)
+	assert.Equal(t, &RunwayState{}, metar.RunwayConditions[1].State)
+	assert.Empty(t, metar.Unhandled)
+
+	// A group with an invalid code is left unhandled rather than read as visual range
+	metar = DecodeMETAR("UUEE 081530Z 18004MPS 9999 BKN010 M02/M04 Q1012 R06L/53//95")
+	assert.Empty(t, metar.RunwayConditions)
+	assert.Equal(t, []string{"R06L/53//95"}, metar.Unhandled)
+}
+
+func TestDecodeMETAR_runwayStateCorpus(t *testing.T) {
+	t.Parallel()
+
+	scanner := testdata.METAR(t)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		body, _, _ := strings.Cut(line, " RMK ")
+
+		var groups []string
+		for _, part := range strings.Fields(body) {
+			if runwayStateRegex.MatchString(part) {
+				groups = append(groups, part)
+			}
+		}
+		if len(groups) == 0 {
+			continue
+		}
+
+		metar := DecodeMETAR(line)
+		for _, group := range groups {
+			i := slices.IndexFunc(metar.RunwayConditions, func(cond RunwayCondition) bool { return cond.Raw == group })
+			if assert.NotEqual(t, -1, i, "%s: %s", group, line) {
+				assert.NotNil(t, metar.RunwayConditions[i].State, "%s: %s", group, line)
+			}
+		}
+	}
+}
+
+func TestDecodeMETAR_towerSurfaceVisibility(t *testing.T) {
+	t.Parallel()
+
+	for line, metar := range decodeMETARList(t) {
+		if strings.Contains(line, "TWR VIS ") && metar.TowerVisibility.Raw == "" ||
+			strings.Contains(line, "SFC VIS ") && metar.SurfaceVisibility.Raw == "" {
+			t.Run(line, func(t *testing.T) {
+				t.Errorf("Raw METAR: %s\nTower visibility: %q\nSurface visibility: %q",
+					line, metar.TowerVisibility.Raw, metar.SurfaceVisibility.Raw)
+			})
+		}
+	}
+}
+
+func TestDecodeMETAR_trends(t *testing.T) {
+	t.Parallel()
+
+	metar := DecodeMETAR("EGLL 081550Z 24010KT 9999 FEW020 15/10 Q1013 TEMPO 3000 RA BKN008")
+	if assert.Len(t, metar.Trends, 1) {
+		trend := metar.Trends[0]
+		assert.Equal(t, "TEMPO", trend.Type)
+		assert.Equal(t, "TEMPO 3000 RA BKN008", trend.Raw)
+		assert.Equal(t, 3000, trend.Visibility.Meters)
+		assert.Equal(t, []string{"RA"}, trend.Weather)
+		assert.Equal(t, []Cloud{{Coverage: "BKN", Height: 800}}, trend.Clouds)
+	}
+	assert.Empty(t, metar.Unhandled)
+
+	metar = DecodeMETAR("EGLL 081550Z 24010KT 9999 FEW020 15/10 Q1013 NOSIG")
+	assert.Equal(t, []Forecast{{Type: "NOSIG", Raw: "NOSIG"}}, metar.Trends)
+	assert.Empty(t, metar.SpecialCodes)
+
+	// Trend times fall on the observation day, or the next one past midnight
+	metar = DecodeMETAR("EGLL 082350Z 24010KT 9999 FEW020 15/10 Q1013 BECMG FM0030 25015KT RMK AO2")
+	if assert.Len(t, metar.Trends, 1) {
+		assert.Equal(t, metar.Time.Add(40*time.Minute), metar.Trends[0].From)
+		assert.Equal(t, "250", metar.Trends[0].Wind.Direction)
+	}
+}
+
+func TestDecodeMETAR_cloudLayers(t *testing.T) {
+	t.Parallel()
+
+	for line, metar := range decodeMETARList(t) {
+		rmk := strings.Fields(line[strings.Index(line, " RMK ")+1:])
+		if !strings.Contains(line, " RMK ") || len(rmk) < 2 || !cloudLayersRegex.MatchString(rmk[1]) {
+			continue
+		}
+
+		t.Run(line, func(t *testing.T) {
+			if assert.NotEmpty(t, metar.CloudLayers) {
+				for _, layer := range metar.CloudLayers {
+					assert.LessOrEqual(t, layer.Oktas, 8)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeMETAR_remarkPressure(t *testing.T) {
+	t.Parallel()
+
+	for line, metar := range decodeMETARList(t) {
+		if metar.RemarkPressure == 0 || metar.Pressure == 0 {
+			continue
+		}
+
+		// Both the body and the remark come from the same altimeter setting
+		t.Run(line, func(t *testing.T) {
+			assert.Zero(t, metar.PressureDiscrepancy())
+		})
+	}
+}
+
+func TestDecodeMETAR_colorState(t *testing.T) {
+	t.Parallel()
+
+	metar := DecodeMETAR("EGVN 081150Z 24010KT 9999 FEW030 15/08 Q1013 BLU/WHT")
+	assert.Equal(t, "BLU", metar.ColorStateCode)
+	assert.Equal(t, "WHT", metar.RecentColorState)
+	assert.Empty(t, metar.Weather)
+	assert.Empty(t, metar.Unhandled)
+
+	for line, metar := range decodeMETARList(t) {
+		if !slices.ContainsFunc(strings.Fields(line), colorStateRegex.MatchString) {
+			continue
+		}
+
+		t.Run(line, func(t *testing.T) {
+			assert.NotEmpty(t, metar.ColorStateCode)
+			assert.NotContains(t, metar.Weather, metar.ColorStateCode)
+		})
+	}
+}
+
+func TestDecodeMETAR_ceilingRemarks(t *testing.T) {
+	t.Parallel()
+
+	for line, metar := range decodeMETARList(t) {
+		if !strings.Contains(line, " CIG ") {
+			continue
+		}
+
+		// Numeric ceiling remarks are decoded whole rather than left as unknown tokens
+		for _, rmk := range metar.Remarks {
+			if m := ceilingRemarkRegex.FindStringSubmatch(rmk.Raw + " "); rmk.Raw == "CIG" || m != nil && rmk.Description == "unknown remark code" {
+				t.Run(line, func(t *testing.T) {
+					t.Errorf("Raw METAR: %s\nUndecoded ceiling remark: %q", line, rmk.Raw)
+				})
+				break
+			}
+		}
+	}
+}
+
+func TestDecodeMETAR_weatherCode(t *testing.T) {
+	t.Parallel()
+
+	for line, metar := range decodeMETARList(t) {
+		fields := strings.Fields(line)
+
+		// Find sections to know where to stop
+		rmkIndex := -1
+		sectionIndices := []int{}
+
+		// Find all TEMPO, BECMG, and RMK sections
+		for i, part := range fields {
+			if part == "RMK" {
+				rmkIndex = i
+				break // RMK always ends the main section
+			}
+			if part == "TEMPO" || part == "BECMG" || part == "INTER" {
+				sectionIndices = append(sectionIndices, i)
+			}
+		}
+
+		// Find the first section marker
+		endIndex := len(fields)
+		if rmkIndex != -1 {
+			endIndex = rmkIndex
+		}
+
+		// Find the earliest TEMPO or BECMG section
+		for _, idx := range sectionIndices {
+			if idx < endIndex {
+				endIndex = idx
+			}
+		}
+
+		// Collect weather codes from original METAR
+		var expectedWeatherCodes []string
+		for i := 2; i < endIndex; i++ {
+			if isWeatherCode(fields[i]) {
+				expectedWeatherCodes = append(expectedWeatherCodes, fields[i])
+			}
+		}
+		// Filter out WS which is handled separately as wind shear
+		expectedWeatherCodes = slices.DeleteFunc(expectedWeatherCodes, func(s string) bool {
+			return s == "WS"
+		})
+
+		// Compare with decoded weather codes
+		if !slices.Equal(expectedWeatherCodes, metar.Weather) {
+			t.Run(line, func(t *testing.T) {
+				t.Errorf("Raw METAR: %s\nExpected weather codes: %v\nActual weather codes: %v\n\n",
+					line, expectedWeatherCodes, metar.Weather)
+			})
+		}
+	}
+}
+func TestDecodeMETAR_visibility(t *testing.T) {
+	t.Parallel()
+
+	for line, metar := range decodeMETARList(t) {
+		fields := strings.Fields(line)
+
+		// Find visibility value with SM suffix
+		var expectedVisibility string
+		for i := 1; i < len(fields); i++ {
+			if strings.HasSuffix(fields[i], "SM") {
+				// Check if this could be part of a spaced visibility like "1 1/2SM"
+				if i > 1 && strings.Contains(fields[i], "/") &&
+					!strings.HasPrefix(fields[i-1], "P") && !strings.HasPrefix(fields[i-1], "M") &&
+					!strings.Contains(fields[i-1], "/") && len(fields[i-1]) == 1 {
+					// This is likely a split visibility like "1 1/2SM"
+					expectedVisibility = fields[i-1] + " " + fields[i]
+				} else {
+					expectedVisibility = fields[i]
+				}
+				break
+			}
+		}
+
+		if expectedVisibility != "" && expectedVisibility != metar.Visibility.Raw {
+			t.Run(line, func(t *testing.T) {
+				t.Errorf("Raw METAR: %s\nExpected visibility: %s\nActual visibility: %s\n\n",
+					line, expectedVisibility, metar.Visibility.Raw)
+			})
+		}
+	}
+}
+func TestDecodeMETAR_verticalVisibility(t *testing.T) {
+	t.Parallel()
+
+	for line, metar := range decodeMETARList(t) {
+		fields := strings.Fields(line)
+
+		// Find sections to know where to stop
+		rmkIndex := -1
+		sectionIndices := []int{}
+
+		// Find all TEMPO, BECMG, and RMK sections
+		for i, part := range fields {
+			if part == "RMK" {
+				rmkIndex = i
+				break // RMK always ends the main section
+			}
+			if part == "TEMPO" || part == "BECMG" || part == "INTER" {
+				sectionIndices = append(sectionIndices, i)
+			}
+		}
+
+		// Find the first section marker
+		endIndex := len(fields)
+		if rmkIndex != -1 {
+			endIndex = rmkIndex
+		}
+
+		// Find the earliest TEMPO or BECMG section
+		for _, idx := range sectionIndices {
+			if idx < endIndex {
+				endIndex = idx
+			}
+		}
+
+		// Find vertical visibility in the original METAR
+		var expectedVertVis int
+		for i := 2; i < endIndex; i++ {
+			if isVerticalVisibility(fields[i]) {
+				matches := vvRegex.FindStringSubmatch(fields[i])
+				if len(matches) > 1 {
+					expectedVertVis, _ = strconv.Atoi(matches[1])
+					break
+				}
+			}
+		}
+
+		// Only test if vertical visibility was found in the original METAR
+		if expectedVertVis > 0 || metar.VertVis > 0 {
+			if expectedVertVis != metar.VertVis {
+				t.Run(line, func(t *testing.T) {
+					t.Errorf("Raw METAR: %s\nExpected vertical visibility: %d00ft\nActual vertical visibility: %d00ft\n\n",
+						line, expectedVertVis, metar.VertVis)
+				})
+			}
+		}
+	}
+}
+
+func TestDecodeMETAR_wind(t *testing.T) {
+	t.Parallel()
+
+	for line, metar := range decodeMETARList(t) {
+		fields := strings.Fields(line)
+		for _, field := range fields[1:] {
+			if windRegex.MatchString(field) {
+				expectedWind := ParseWind(field)
+				if expectedWind != metar.Wind {
+					t.Run(line, func(t *testing.T) {
+						t.Errorf("Raw METAR: %s\nExpected wind: %+v\nActual wind: %+v\n\n",
+							line, expectedWind, metar.Wind)
+					})
+				}
+				break
+			}
+		}
+	}
+}
+
+func TestDecodeMETAR_windUnits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		metar     string
+		wantUnit  string
+		wantSpeed int
+		wantGust  int
+	}{
+		{metar: "UUEE 121030Z 24008MPS 9999 SCT020 M02/M05 Q1015 NOSIG", wantUnit: "MPS", wantSpeed: 8},
+		{metar: "ZBAA 121030Z 32006G12MPS CAVOK 12/M08 Q1021 NOSIG", wantUnit: "MPS", wantSpeed: 6, wantGust: 12},
+		{metar: "UTTT 121030Z 27015KMH 9999 FEW030 18/05 Q1012", wantUnit: "KMH", wantSpeed: 15},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.metar, func(t *testing.T) {
+			metar := DecodeMETAR(tt.metar)
+			assert.Empty(t, metar.Unhandled)
+			assert.Equal(t, tt.wantUnit, metar.Wind.Unit)
+			require.NotNil(t, metar.Wind.Speed)
+			assert.Equal(t, tt.wantSpeed, *metar.Wind.Speed)
+			assert.Equal(t, tt.wantGust, metar.Wind.Gust)
+		})
+	}
+}
+
+func TestDecodeMETAR_windshear(t *testing.T) {
+	t.Parallel()
+	var failures []string
+
+	for line, metar := range decodeMETARList(t) {
+		fields := strings.Fields(line)
+		var expectedWindShear []WindShear
+
+		// Find wind shear in the original METAR
+		// Skip station codes and remark sections
+		rmkIndex := -1
+		for i, part := range fields {
+			if part == "RMK" {
+				rmkIndex = i
+				break
+			}
+		}
+		endIndex := len(fields)
+		if rmkIndex != -1 {
+			endIndex = rmkIndex
+		}
+
+		// Only look for wind shear in the main section (not in remarks)
+		for i := 1; i < endIndex; i++ {
+			// Handle "WS ALL RWY" pattern
+			if i+2 < endIndex && fields[i] == "WS" && fields[i+1] == "ALL" && fields[i+2] == "RWY" {
+				expectedWindShear = append(expectedWindShear, WindShear{
+					Type:  "RWY",
+					Phase: "ALL",
+					Raw:   "WS ALL RWY",
+				})
+				i += 2 // Skip the next two tokens
+			} else if i+1 < endIndex && fields[i] == "WS" && strings.HasPrefix(fields[i+1], "R") && len(fields[i+1]) > 1 {
+				// Handle "WS R##" pattern
+				expectedWindShear = append(expectedWindShear, WindShear{
+					Type:   "RWY",
+					Runway: fields[i+1][1:], // Remove the 'R' prefix
+					Raw:    fields[i] + " " + fields[i+1],
+				})
+				i++ // Skip the next token
+			} else if strings.HasPrefix(fields[i], "WS") && fields[i] != "WS" &&
+				!strings.HasPrefix(fields[i], "WSSS") &&
+				!strings.HasPrefix(fields[i], "WSSL") &&
+				!strings.HasPrefix(fields[i], "WSAP") &&
+				!strings.HasPrefix(fields[i], "WSHFT") {
+				// Single-token wind shear format
+				// Skip station codes that start with WS
+				// Skip WSHFT which is a wind shift in remarks
+				expectedWindShear = append(expectedWindShear, ParseWindShear(fields[i]))
+			}
+		}
+
+		// Skip if no wind shear in the raw METAR
+		if len(expectedWindShear) == 0 && len(metar.WindShear) == 0 {
+			continue
+		}
+
+		// Check if wind shear was parsed correctly
+		if len(expectedWindShear) != len(metar.WindShear) {
+			failures = append(failures, fmt.Sprintf("Raw METAR: %s\nWrong number of wind shear entries - Expected: %d, Got: %d\nExpected: %+v\nActual: %+v\n\n",
+				line, len(expectedWindShear), len(metar.WindShear), expectedWindShear, metar.WindShear))
+			continue
+		}
+
+		// Compare each wind shear entry
+		for i, expected := range expectedWindShear {
+			actual := metar.WindShear[i]
+
+			// Compare Type, Runway and Phase fields which are most relevant
+			if expected.Type != actual.Type ||
+				expected.Runway != actual.Runway ||
+				expected.Phase != actual.Phase {
+
+				failures = append(failures, fmt.Sprintf("Raw METAR: %s\nWind shear entry mismatch\nExpected: %+v\nActual: %+v\n\n",
+					line, expected, actual))
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		// Create log content
+		logContent := "WIND SHEAR PARSING FAILURES IN METAR\n"
+		logContent += "==================================\n\n"
+		logContent += strings.Join(failures, "")
+
+		// Write to log file
+		logFile := logTestFailures(t, "wind_shear_parsing_failures", logContent)
+
+		t.Errorf("Found %d wind shear parsing failures in METAR. See '%s' for details.",
+			len(failures), logFile)
+	}
+}
+
+func TestDecodeMETAR_weather(t *testing.T) {
+	t.Parallel()
+
+	for line, metar := range decodeMETARList(t) {
+		fields := strings.Fields(line)
+		var expectedWeather []string
+
+		// Find sections to know where to stop
+		rmkIndex := -1
+		sectionIndices := []int{}
+
+		// Find all TEMPO, BECMG, and RMK sections
+		for i, part := range fields {
+			if part == "RMK" {
+				rmkIndex = i
+				break // RMK always ends the main section
+			}
+			if part == "TEMPO" || part == "BECMG" || part == "INTER" {
+				sectionIndices = append(sectionIndices, i)
+			}
+		}
+
+		// Find the first section marker
+		endIndex := len(fields)
+		if rmkIndex != -1 {
+			endIndex = rmkIndex
+		}
+
+		// Find the earliest TEMPO or BECMG section
+		for _, idx := range sectionIndices {
+			if idx < endIndex {
+				endIndex = idx
+			}
+		}
+
+		// Collect weather phenomena from original METAR
+		for i := 2; i < endIndex; i++ {
+			if isWeatherCode(fields[i]) {
+				expectedWeather = append(expectedWeather, fields[i])
+			}
+		}
+		expectedWeather = slices.DeleteFunc(expectedWeather, func(s string) bool {
+			return s == "WS"
+		})
+
+		// Check if weather phenomena were parsed correctly
+		if !slices.Equal(expectedWeather, metar.Weather) {
+			t.Run(line, func(t *testing.T) {
+				t.Errorf("Raw METAR: %s\nExpected weather phenomena: %v\nActual weather phenomena: %v\n\n",
+					line, expectedWeather, metar.Weather)
+			})
+		}
+	}
+}
+func TestDecodeMETAR_clouds(t *testing.T) {
+	t.Parallel()
+
+	for line, metar := range decodeMETARList(t) {
+		fields := strings.Fields(line)
+		var expectedClouds []Cloud
+
+		// Find sections to know where to stop
+		rmkIndex := -1
+		sectionIndices := []int{}
+
+		// Find all TEMPO, BECMG, and RMK sections
+		for i, part := range fields {
+			if part == "RMK" {
+				rmkIndex = i
+				break // RMK always ends the main section
+			}
+			if part == "TEMPO" || part == "BECMG" || part == "INTER" {
+				sectionIndices = append(sectionIndices, i)
+			}
+		}
+
+		// Find the first section marker
+		endIndex := len(fields)
+		if rmkIndex != -1 {
+			endIndex = rmkIndex
+		}
+
+		// Find the earliest TEMPO or BECMG section
+		for _, idx := range sectionIndices {
+			if idx < endIndex {
+				endIndex = idx
+			}
+		}
+
+		// Collect cloud data from original METAR
+		for i := 2; i < endIndex; i++ {
+			if cloudRegex.MatchString(fields[i]) {
+				expectedClouds = append(expectedClouds, ParseCloud(fields[i]))
+			}
+		}
+
+		// Check number of cloud layers
+		if len(expectedClouds) != len(metar.Clouds) {
+			t.Run(line, func(t *testing.T) {
+				t.Errorf("Raw METAR: %s\nWrong number of cloud layers - Expected: %d, Got: %d\nExpected clouds: %+v\nActual clouds: %+v\n\n",
+					line, len(expectedClouds), len(metar.Clouds), expectedClouds, metar.Clouds)
+			})
+			continue
+		}
+
+		// Check each cloud layer
+		for i := range expectedClouds {
+			if i < len(metar.Clouds) && expectedClouds[i] != metar.Clouds[i] {
+				t.Run(line, func(t *testing.T) {
+					t.Errorf("Raw METAR: %s\nCloud layer %d mismatch\nExpected: %+v\nActual: %+v\n\n",
+						line, i, expectedClouds[i], metar.Clouds[i])
+				})
+			}
+		}
+	}
+}
+
+func TestDecodeMETAR_temperature(t *testing.T) {
+	t.Parallel()
+
+	for line, metar := range decodeMETARList(t) {
+		fields := strings.Fields(line)
+
+		// Find temperature/dew point in the original METAR
+		for _, field := range fields {
+			if tempRegex.MatchString(field) {
+				matches := tempRegex.FindStringSubmatch(field)
+				expectedTemp, _ := strconv.Atoi(matches[2])
+				if matches[1] == "M" {
+					expectedTemp = -expectedTemp
+				}
+
+				expectedDew, _ := strconv.Atoi(matches[4])
+				if matches[3] == "M" {
+					expectedDew = -expectedDew
+				}
+
+				// Check if temperature is not nil before comparing
+				if metar.Temperature == nil {
+					t.Run(line, func(t *testing.T) {
+						t.Errorf("Raw METAR: %s\nTemperature is nil but expected: %d\n\n",
+							line, expectedTemp)
+					})
+				} else if expectedTemp != *metar.Temperature {
+					t.Run(line, func(t *testing.T) {
+						t.Errorf("Raw METAR: %s\nTemperature mismatch - Expected: %d, Got: %d\n",
+							line, expectedTemp, *metar.Temperature)
+					})
+				}
+
+				// Check if dew point is not nil before comparing
+				if metar.DewPoint == nil {
+					t.Run(line, func(t *testing.T) {
+						t.Errorf("Raw METAR: %s\nDew point is nil but expected: %d\n\n",
+							line, expectedDew)
+					})
+				} else if expectedDew != *metar.DewPoint {
+					t.Run(line, func(t *testing.T) {
+						t.Errorf("Raw METAR: %s\nDew point mismatch - Expected: %d, Got: %d\n\n",
+							line, expectedDew, *metar.DewPoint)
+					})
+				}
+				break
+			}
+		}
+	}
+}
+func TestDecodeMETAR_pressure(t *testing.T) {
+	t.Parallel()
+
+	for line, metar := range decodeMETARList(t) {
+		fields := strings.Fields(line)
+
+		// Skip the test if there's no pressure information
+		if metar.Pressure == 0 {
+			continue
+		}
+
+		// Find sections to know where to stop
+		rmkIndex := -1
+		sectionIndices := []int{}
+
+		// Find all TEMPO, BECMG, and RMK sections
+		for i, part := range fields {
+			if part == "RMK" {
+				rmkIndex = i
+				break // RMK always ends the main section
+			}
+			if part == "TEMPO" || part == "BECMG" || part == "INTER" {
+				sectionIndices = append(sectionIndices, i)
+			}
+		}
+
+		// Find the first section marker
+		endIndex := len(fields)
+		if rmkIndex != -1 {
+			endIndex = rmkIndex
+		}
+
+		// Find the earliest TEMPO or BECMG section
+		for _, idx := range sectionIndices {
+			if idx < endIndex {
+				endIndex = idx
+			}
+		}
+
+		found := false
+
+		// Find the first pressure value in the main section, regardless of format
+		var firstPressureIndex = -1
+		var isPressureQ = false
+		var isPressureA = false
+		var expectedPressure float64
+
+		for i := 2; i < endIndex; i++ {
+			part := fields[i]
+
+			// Check for Q format (hPa/millibars)
+			if len(part) > 1 && part[0] == 'Q' {
+				pressureStr := part[1:]
+				pressureInt, err := strconv.Atoi(pressureStr)
+				if err == nil && firstPressureIndex == -1 {
+					firstPressureIndex = i
+					isPressureQ = true
+					expectedPressure = float64(pressureInt)
+				}
+			}
+
+			// Check for A format (inHg)
+			if pressureRegex.MatchString(part) {
+				matches := pressureRegex.FindStringSubmatch(part)
+				pressureStr := matches[1]
+				pressureInt, err := strconv.Atoi(pressureStr)
+				if err == nil && firstPressureIndex == -1 {
+					firstPressureIndex = i
+					isPressureA = true
+					expectedPressure = float64(pressureInt) / 100.0
+				}
+			}
+		}
+
+		// Now validate based on the first pressure format found
+		if firstPressureIndex != -1 {
+			found = true
+			if isPressureQ {
+				if expectedPressure != metar.Pressure || metar.PressureUnit != "hPa" {
+					t.Run(line, func(t *testing.T) {
+						t.Errorf("Raw METAR: %s\nQ-format pressure mismatch - Expected: %.2f hPa, Got: %.2f %s\n\n",
+							line, expectedPressure, metar.Pressure, metar.PressureUnit)
+					})
+				}
+			} else if isPressureA {
+				if expectedPressure != metar.Pressure || metar.PressureUnit != "inHg" {
+					t.Run(line, func(t *testing.T) {
+						t.Errorf("Raw METAR: %s\nA-format pressure mismatch - Expected: %.2f inHg, Got: %.2f %s\n\n",
+							line, expectedPressure, metar.Pressure, metar.PressureUnit)
+					})
+				}
+			}
+		}
+
+		// Make sure we found and tested a pressure value if there's a non-remark pressure
+		if metar.Pressure > 0 && !found {
+			// Only check for METARs known to have pressure in main section
+			mainSectionHasPressure := false
+			for i := 2; i < endIndex; i++ {
+				part := fields[i]
+				if (len(part) > 1 && part[0] == 'Q') || pressureRegex.MatchString(part) {
+					mainSectionHasPressure = true
+					break
+				}
+			}
+			if mainSectionHasPressure {
+				t.Run(line, func(t *testing.T) {
+					t.Errorf("Raw METAR: %s\nExpected to find pressure in main section but didn't. Decoded pressure: %.2f %s\n\n",
+						line, metar.Pressure, metar.PressureUnit)
+				})
+			}
+		}
+	}
+}
+
+// TestDecodeMETAR_unhandledValues tests that all values in the METAR pre-remark section are handled
+func TestDecodeMETAR_unhandledValues(t *testing.T) {
+	t.Parallel()
+
+	var failedValueCount int
+
+	for line, metar := range decodeMETARList(t) {
+
+		if len(metar.Unhandled) != 0 {
+			failedValueCount++
+			t.Run(line, func(t *testing.T) {
+				t.Errorf("Unknown value:\nMETAR   = %s\nValue = %v", line, metar.Unhandled)
+			})
+		}
+	}
+
+	t.Run("00 metars with failed values", func(t *testing.T) {
+		assert.Zero(t, failedValueCount)
+	})
+}
+
+func TestDecodeTAF_probWithinTempo(t *testing.T) {
+	t.Parallel()
+
+	t.Run("modifier", func(t *testing.T) {
+		// PROB30 without a valid period qualifies conditions inside the TEMPO group
+		taf := DecodeTAF("TAF KDFW 081730Z 0818/0924 20010KT P6SM SCT040 TEMPO 0820/0824 -SHRA PROB30 TSRA BKN030CB FM090200 18008KT P6SM SKC")
+
+		types := make([]string, 0, len(taf.Forecasts))
+		for _, forecast := range taf.Forecasts {
+			types = append(types, forecast.Type)
+		}
+		assert.Equal(t, []string{"BASE", "TEMPO", "FM"}, types)
+
+		tempo := taf.Forecasts[1]
+		assert.Equal(t, 30, tempo.Probability)
+		assert.Equal(t, []string{"-SHRA", "TSRA"}, tempo.Weather)
+		assert.Len(t, tempo.Clouds, 1)
+	})
+
+	t.Run("new period", func(t *testing.T) {
+		// PROB30 followed by a valid period starts its own forecast period
+		taf := DecodeTAF("TAF KDFW 081730Z 0818/0924 20010KT P6SM SCT040 TEMPO 0820/0824 -SHRA PROB30 0900/0904 TSRA BKN030CB FM090200 18008KT P6SM SKC")
+
+		types := make([]string, 0, len(taf.Forecasts))
+		for _, forecast := range taf.Forecasts {
+			types = append(types, forecast.Type)
+		}
+		assert.Equal(t, []string{"BASE", "TEMPO", "PROB30", "FM"}, types)
+
+		assert.Zero(t, taf.Forecasts[1].Probability)
+		assert.Equal(t, []string{"-SHRA"}, taf.Forecasts[1].Weather)
+		assert.Equal(t, 30, taf.Forecasts[2].Probability)
+		assert.Equal(t, []string{"TSRA"}, taf.Forecasts[2].Weather)
+	})
+}
+
+func TestDecodeTAF_prob(t *testing.T) {
+	t.Parallel()
+
+	t.Run("uncommon probability", func(t *testing.T) {
+		taf := DecodeTAF("TAF KDFW 081730Z 0818/0924 20010KT P6SM SCT040 PROB50 0900/0904 TSRA BKN030CB")
+
+		require.Len(t, taf.Forecasts, 2)
+		assert.Equal(t, "PROB50", taf.Forecasts[1].Type)
+		assert.Equal(t, 50, taf.Forecasts[1].Probability)
+		assert.Equal(t, []string{"TSRA"}, taf.Forecasts[1].Weather)
+	})
+
+	t.Run("malformed probability", func(t *testing.T) {
+		// The conditions after PROBXX belong to its own period, not the base forecast or the FM group
+		taf := DecodeTAF("TAF KDFW 081730Z 0818/0924 20010KT P6SM SCT040 PROBXX 0900/0904 TSRA BKN030CB FM090200 18008KT P6SM SKC")
+
+		types := make([]string, 0, len(taf.Forecasts))
+		for _, forecast := range taf.Forecasts {
+			types = append(types, forecast.Type)
+		}
+		assert.Equal(t, []string{"BASE", "PROBXX", "FM"}, types)
+
+		base, prob, fm := taf.Forecasts[0], taf.Forecasts[1], taf.Forecasts[2]
+		assert.Empty(t, base.Weather)
+		assert.Len(t, base.Clouds, 1)
+
+		assert.Zero(t, prob.Probability)
+		assert.Equal(t, 9, prob.From.Day())
+		assert.Equal(t, 4, prob.To.Hour())
+		assert.Equal(t, []string{"TSRA"}, prob.Weather)
+		assert.Len(t, prob.Clouds, 1)
+
+		assert.Empty(t, fm.Weather)
+		assert.Equal(t, "180", fm.Wind.Direction)
+	})
+}
+
+func TestDecodeTAF_amendmentsNotScheduled(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw      string
+		wantFrom string
+		wantTo   string
+	}{
+		{
+			raw: "TAF KPEQ 080532Z 0806/0906 28012KT P6SM SKC FM081500 27019G25KT P6SM BKN250 AMD NOT SKED",
+		},
+		{
+			raw:    "TAF TJBQ 080723Z 0807/0906 04005KT P6SM VCSH SCT020 FM082200 07006KT P6SM FEW025 AMD NOT SKED TIL 081100",
+			wantTo: "08 11:00",
+		},
+		{
+			raw:      "TAF KXXX 080300Z 0803/0903 18005KT P6SM SKC AMD NOT SKED 0804/0809",
+			wantFrom: "08 04:00",
+			wantTo:   "08 09:00",
+		},
+		{
+			raw:      "TAF KXXX 080300Z 0803/0903 18005KT P6SM SKC AMD NOT SKED 0400/1100",
+			wantFrom: "08 04:00",
+			wantTo:   "08 11:00",
+		},
+		{
+			raw:      "TAF KXXX 081130Z 0812/0912 18005KT P6SM SKC AMD NOT SKED 0400/1100",
+			wantFrom: "09 04:00",
+			wantTo:   "09 11:00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			taf := DecodeTAF(tt.raw)
+			assert.True(t, taf.AmendmentsNotScheduled)
+			if tt.wantFrom != "" {
+				assert.Equal(t, tt.wantFrom, taf.AmendmentsFrom.Format("02 15:04"))
+			}
+			if tt.wantTo != "" {
+				assert.Equal(t, tt.wantTo, taf.AmendmentsTo.Format("02 15:04"))
+			}
+
+			// The tail doesn't leak into the last forecast group
+			last := taf.Forecasts[len(taf.Forecasts)-1]
+			assert.NotContains(t, last.Weather, "AMD")
+		})
+	}
+}
+
+func TestDecodeTAF_amendmentsNotScheduledCorpus(t *testing.T) {
+	t.Parallel()
+
+	scanner := testdata.TAF(t)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.Contains(line, " AMD NOT SKED") {
+			continue
+		}
+
+		taf := DecodeTAF(line)
+		assert.True(t, taf.AmendmentsNotScheduled, line)
+		if strings.Contains(line, "AMD NOT SKED TIL ") {
+			assert.False(t, taf.AmendmentsTo.IsZero(), line)
+		}
+		if fields := strings.Fields(line); slices.Contains(fields, "SKED") {
+			if next := slices.Index(fields, "SKED") + 1; next < len(fields) && validRegex.MatchString(fields[next]) {
+				assert.False(t, taf.AmendmentsFrom.IsZero(), line)
+				assert.False(t, taf.AmendmentsTo.IsZero(), line)
+			}
+		}
+	}
+}
+
+func TestDecodeMETARAt_monthBoundary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		now  time.Time
+		raw  string
+		want time.Time
+	}{
+		{
+			name: "earlier today",
+			now:  time.Date(2025, time.March, 15, 12, 10, 0, 0, time.UTC),
+			raw:  "KORD 151151Z 27010KT 10SM FEW250 21/09 A3012",
+			want: time.Date(2025, time.March, 15, 11, 51, 0, 0, time.UTC),
+		},
+		{
+			name: "last day of the previous month",
+			now:  time.Date(2025, time.March, 1, 0, 20, 0, 0, time.UTC),
+			raw:  "KORD 282351Z 27010KT 10SM FEW250 21/09 A3012",
+			want: time.Date(2025, time.February, 28, 23, 51, 0, 0, time.UTC),
+		},
+		{
+			// A day ahead of today (e.g. a clock behind UTC midnight) is never placed in the future
+			name: "day ahead of now",
+			now:  time.Date(2025, time.March, 15, 23, 58, 0, 0, time.UTC),
+			raw:  "KORD 160005Z 27010KT 10SM FEW250 21/09 A3012",
+			want: time.Date(2025, time.February, 16, 0, 5, 0, 0, time.UTC),
+		},
+		{
+			name: "day missing from the previous month",
+			now:  time.Date(2025, time.March, 1, 0, 20, 0, 0, time.UTC),
+			raw:  "KORD 311151Z 27010KT 10SM FEW250 21/09 A3012",
+			want: time.Date(2025, time.January, 31, 11, 51, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metar := DecodeMETARAt(tt.raw, tt.now)
+			assert.Equal(t, tt.want, metar.Time)
+			assert.False(t, metar.Time.After(tt.now))
+		})
+	}
+}
+
+func TestDecodeTAFAt_monthRollover(t *testing.T) {
+	t.Parallel()
+
+	date := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2025, month, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		raw       string
+		issued    time.Time
+		validFrom time.Time
+		validTo   time.Time
+		periods   [][2]time.Time // From and To of each change group
+	}{
+		{
+			name:      "validity into next month",
+			now:       date(time.January, 31, 18),
+			raw:       "TAF KDFW 311730Z 3118/0124 20010KT P6SM SCT040 TEMPO 3120/3124 -SHRA BECMG 0100/0102 22015KT FM010200 18008KT P6SM SKC",
+			issued:    time.Date(2025, time.January, 31, 17, 30, 0, 0, time.UTC),
+			validFrom: date(time.January, 31, 18),
+			validTo:   date(time.February, 1, 24),
+			periods: [][2]time.Time{
+				{date(time.January, 31, 20), date(time.February, 1, 0)},
+				{date(time.February, 1, 0), date(time.February, 1, 2)},
+				{date(time.February, 1, 2), date(time.February, 2, 0)},
+			},
+		},
+		{
+			name:      "decoded after the month turned",
+			now:       date(time.March, 1, 3),
+			raw:       "TAF KDFW 282330Z 0100/0206 20010KT P6SM SCT040 FM010600 18008KT P6SM SKC",
+			issued:    time.Date(2025, time.February, 28, 23, 30, 0, 0, time.UTC),
+			validFrom: date(time.March, 1, 0),
+			validTo:   date(time.March, 2, 6),
+			periods: [][2]time.Time{
+				{date(time.March, 1, 6), date(time.March, 2, 6)},
+			},
+		},
+		{
+			name:      "end of a 30-day month",
+			now:       date(time.April, 30, 12),
+			raw:       "TAF EGLL 301100Z 3012/0118 24012KT 9999 SCT030 PROB30 0102/0106 4000 BR",
+			issued:    time.Date(2025, time.April, 30, 11, 0, 0, 0, time.UTC),
+			validFrom: date(time.April, 30, 12),
+			validTo:   date(time.May, 1, 18),
+			periods: [][2]time.Time{
+				{date(time.May, 1, 2), date(time.May, 1, 6)},
+			},
+		},
+		{
+			name:      "end of year",
+			now:       date(time.December, 31, 12),
+			raw:       "TAF KJFK 311120Z 3112/0118 31012KT P6SM BKN035 FM010000 30008KT P6SM SKC",
+			issued:    time.Date(2025, time.December, 31, 11, 20, 0, 0, time.UTC),
+			validFrom: date(time.December, 31, 12),
+			validTo:   time.Date(2026, time.January, 1, 18, 0, 0, 0, time.UTC),
+			periods: [][2]time.Time{
+				{time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, time.January, 1, 18, 0, 0, 0, time.UTC)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taf := DecodeTAFAt(tt.raw, tt.now)
+			assert.Equal(t, tt.issued, taf.Time)
+			assert.Equal(t, tt.validFrom, taf.ValidFrom)
+			assert.Equal(t, tt.validTo, taf.ValidTo)
+			assert.True(t, taf.ValidTo.After(taf.ValidFrom))
+
+			if assert.Len(t, taf.Forecasts, len(tt.periods)+1) {
+				for i, period := range tt.periods {
+					assert.Equal(t, period[0], taf.Forecasts[i+1].From, "period %d from", i+1)
+					assert.Equal(t, period[1], taf.Forecasts[i+1].To, "period %d to", i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeTAFAt_decoyValidPeriod(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.August, 8, 18, 0, 0, 0, time.UTC)
+	date := func(day, hour int) time.Time {
+		return time.Date(2025, time.August, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		raw       string
+		validFrom time.Time
+		validTo   time.Time
+	}{
+		{
+			name:      "stray token days from the issue time",
+			raw:       "TAF KDFW 081730Z 2612/2618 0818/0924 20010KT P6SM SCT040",
+			validFrom: date(8, 18),
+			validTo:   date(9, 24),
+		},
+		{
+			name:      "stray token running too long",
+			raw:       "TAF KDFW 081730Z 0818/1018 0818/0924 20010KT P6SM SCT040",
+			validFrom: date(8, 18),
+			validTo:   date(9, 24),
+		},
+		{
+			name:      "stray token running backwards",
+			raw:       "TAF KDFW 081730Z 0818/0806 0818/0924 20010KT P6SM SCT040",
+			validFrom: date(8, 18),
+			validTo:   date(9, 24),
+		},
+		{
+			name:      "stray token with an impossible hour",
+			raw:       "TAF KDFW 081730Z 0899/0912 0818/0924 20010KT P6SM SCT040",
+			validFrom: date(8, 18),
+			validTo:   date(9, 24),
+		},
+		{
+			name: "only implausible periods",
+			raw:  "TAF KDFW 081730Z 20010KT P6SM SCT040 RMK 2612/2618",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			taf := DecodeTAFAt(tt.raw, now)
+			assert.Equal(t, tt.validFrom, taf.ValidFrom)
+			assert.Equal(t, tt.validTo, taf.ValidTo)
+		})
+	}
+}
@@ -0,0 +1,566 @@
+package wx
+
+import (
+	"regexp"
+	"time"
+)
+
+// Common weather phenomena mapping used across the application
+var WeatherCodes = map[string]WeatherCode{
+	"WS":  {Description: "wind shear", Position: 1},
+	"VC":  {Description: "in the vicinity", Position: 3},
+	"+":   {Description: "heavy", Position: 0},
+	"-":   {Description: "light", Position: 0},
+	"RE":  {Description: "recent", Position: 0},
+	"MI":  {Description: "shallow", Position: 0},
+	"PR":  {Description: "partial", Position: 0},
+	"BC":  {Description: "patches of", Position: 0},
+	"DR":  {Description: "low drifting", Position: 0},
+	"BL":  {Description: "blowing", Position: 0},
+	"SH":  {Description: "showers", Position: 2},
+	"TS":  {Description: "thunderstorm", Position: 1},
+	"FZ":  {Description: "freezing", Position: 0},
+	"DZ":  {Description: "drizzle", Position: 1},
+	"RA":  {Description: "rain", Position: 1},
+	"SN":  {Description: "snow", Position: 1},
+	"SG":  {Description: "snow grains", Position: 1},
+	"IC":  {Description: "ice crystals", Position: 1},
+	"PL":  {Description: "ice pellets", Position: 1},
+	"GR":  {Description: "hail", Position: 1},
+	"GS":  {Description: "small hail", Position: 1},
+	"UP":  {Description: "unknown precipitation", Position: 1},
+	"BR":  {Description: "mist", Position: 1},
+	"FG":  {Description: "fog", Position: 1},
+	"FU":  {Description: "smoke", Position: 1},
+	"VA":  {Description: "volcanic ash", Position: 1},
+	"DU":  {Description: "widespread dust", Position: 1},
+	"SA":  {Description: "sand", Position: 1},
+	"HZ":  {Description: "haze", Position: 1},
+	"PY":  {Description: "spray", Position: 1},
+	"PO":  {Description: "dust whirls", Position: 1},
+	"SQ":  {Description: "squalls", Position: 1},
+	"FC":  {Description: "funnel cloud", Position: 1},
+	"+FC": {Description: "tornado/waterspout", Position: 1},
+	"SS":  {Description: "sandstorm", Position: 1},
+	"DS":  {Description: "duststorm", Position: 1},
+}
+
+// Common cloud coverage mapping
+var CloudCoverage = map[string]string{
+	"SKC": "sky clear",
+	"CLR": "slear",
+	"FEW": "few clouds",
+	"SCT": "scattered clouds",
+	"BKN": "broken clouds",
+	"OVC": "overcast",
+}
+
+// Common cloud type mapping
+var CloudTypes = map[string]string{
+	"CB":  "cumulonimbus",
+	"TCU": "towering cumulus",
+}
+
+// Special aerodrome conditions
+var SpecialConditions = map[string]string{
+	"NOSIG": "no significant changes expected",
+	"AUTO":  "automated observation",
+	"COR":   "corrected report",
+	"CCA":   "corrected report",
+	"NSC":   "no significant clouds",
+	"NCD":   "no clouds detected",
+	"CAVOK": "ceiling and visibility OK",
+	"RTD":   "routine delayed (late) observation",
+}
+
+// TAF forecast types
+var forecastTypes = map[string]string{
+	"FM":     "from",
+	"BECMG":  "becoming",
+	"TEMPO":  "temporary",
+	"PROB30": "30% probability of",
+	"PROB40": "40% probability of",
+	"INTER":  "intermittent",
+}
+
+// Qualitative runway braking action levels used in remarks
+var brakingActionLevels = map[string]string{
+	"GOOD":   "good",
+	"MEDIUM": "medium",
+	"MED":    "medium",
+	"FAIR":   "fair",
+	"POOR":   "poor",
+	"NIL":    "nil",
+}
+
+// beginEndPhenomenon matches the weather in a begin/end remark: an optional descriptor
+// followed by up to three phenomena (e.g., "TS", "SHRA", "FZRAPL")
+const beginEndPhenomenon = `(?:SH|FZ|BL|DR|MI|BC|PR)?(?:TS|RA|SN|DZ|GR|GS|PE|IC|PL|SG|UP|FG|FU|VA|DU|SA|HZ|PY|BR|SQ|FC|SS|DS|PO){1,3}`
+
+// Weather phenomena named in begin/end remarks
+var beginEndPhenomena = map[string]string{
+	"RA":   "rain",
+	"SN":   "snow",
+	"DZ":   "drizzle",
+	"GR":   "hail",
+	"GS":   "small hail",
+	"PE":   "ice pellets",
+	"IC":   "ice crystals",
+	"PL":   "ice pellets",
+	"SG":   "snow grains",
+	"TS":   "thunderstorm",
+	"FG":   "fog",
+	"FU":   "smoke",
+	"VA":   "volcanic ash",
+	"DU":   "dust",
+	"SA":   "sand",
+	"HZ":   "haze",
+	"PY":   "spray",
+	"BR":   "mist",
+	"SHSN": "snow shower",
+	"SHRA": "rain shower",
+	"SHPE": "ice pellet shower",
+	"SHPL": "ice pellet shower",
+	"SHGR": "hail shower",
+	"SHGS": "small hail shower",
+}
+
+// Runway deposit types used in runway state groups
+var runwayDeposits = map[string]string{
+	"0": "clear and dry",
+	"1": "damp",
+	"2": "wet or water patches",
+	"3": "rime or frost",
+	"4": "dry snow",
+	"5": "wet snow",
+	"6": "slush",
+	"7": "ice",
+	"8": "compacted or rolled snow",
+	"9": "frozen ruts or ridges",
+}
+
+// Extent of runway contamination used in runway state groups
+var runwayContaminationExtents = map[string]string{
+	"0": "0%",
+	"1": "10% or less",
+	"2": "11–25%",
+	"5": "26–50%",
+	"9": "51–100%",
+}
+
+// Runway deposit depths above 90 mm used in runway state groups
+var runwayDepositDepths = map[string]string{
+	"92": "10 cm",
+	"93": "15 cm",
+	"94": "20 cm",
+	"95": "25 cm",
+	"96": "30 cm",
+	"97": "35 cm",
+	"98": "40 cm or more",
+	"99": "runway not operational",
+}
+
+// Estimated braking actions used in runway state groups instead of a friction coefficient
+var runwayBrakingActions = map[string]string{
+	"91": "braking action poor",
+	"92": "braking action medium/poor",
+	"93": "braking action medium",
+	"94": "braking action medium/good",
+	"95": "braking action good",
+	"99": "friction unreliable",
+}
+
+// Plain-language remark phrases spanning several tokens, keyed by the space-joined phrase
+var remarkPhrases = map[string]string{
+	"NO SPECI":       "no special reports taken",
+	"PATCHY SNW":     "patchy snow",
+	"DRIFTING SNW":   "drifting snow",
+	"SNW COVERED":    "snow covered",
+	"DUST DEVIL":     "dust devil",
+	"DUST DEVILS":    "dust devils",
+	"DUST WHIRLS":    "dust whirls",
+	"SAND WHIRLS":    "sand whirls",
+	"BLOWING DUST":   "blowing dust",
+	"BLOWING SAND":   "blowing sand",
+	"DUST STORM":     "duststorm",
+	"SAND STORM":     "sandstorm",
+	"DUST HAZE":      "dust haze",
+	"NO AMD":         "no amendments",
+	"NO AMDS":        "no amendments",
+	"CLR ICE":        "clear icing",
+	"OBS ENCRYPTED":  "observation encrypted",
+	"OBS INCOMPLETE": "observation incomplete",
+}
+
+// Verbose remark spellings and the code they stand for
+var remarkAliases = map[string]string{
+	"VSBY": "VIS",
+	"DD":   "PO", // Dust devil
+}
+
+// Keywords that introduce a time in part-time station schedule remarks (e.g., AFT 0400, NEXT 151100)
+var scheduleKeywords = map[string]string{
+	"AFT":  "after",
+	"NEXT": "next report",
+	"TIL":  "until",
+}
+
+// Report elements named in missing and estimated data remarks (e.g., CLD MISG, ESTMD WND)
+var remarkElements = map[string]string{
+	"CLD":   "cloud",
+	"ICE":   "ice accretion",
+	"T":     "temperature",
+	"DP":    "dew point",
+	"ALT":   "altimeter",
+	"ALSTG": "altimeter setting",
+	"ALTSG": "altimeter setting",
+	"PRES":  "pressure",
+	"SLP":   "sea level pressure",
+	"WX":    "weather",
+	"WIND":  "wind",
+	"WND":   "wind",
+	"VIS":   "visibility",
+	"PCPN":  "precipitation",
+}
+
+// Convective clouds located in remarks (e.g. CB DSNT NW MOV E)
+var convectiveCloudTypes = map[string]string{
+	"CB":    "cumulonimbus",
+	"CBMAM": "cumulonimbus mammatus",
+	"TCU":   "towering cumulus",
+}
+
+// CloudRemarkQualifiers describes how far away convective clouds in a remark are
+var CloudRemarkQualifiers = map[string]string{
+	"DSNT":  "distant",
+	"VC":    "in the vicinity",
+	"OHD":   "overhead",
+	"ALQDS": "in all quadrants",
+}
+
+// Compass points used for locations and movement in remarks
+var compassDirections = map[string]string{
+	"N":  "north",
+	"NE": "northeast",
+	"E":  "east",
+	"SE": "southeast",
+	"S":  "south",
+	"SW": "southwest",
+	"W":  "west",
+	"NW": "northwest",
+}
+
+// Cloud genera reported with their oktas in Canadian cloud-layer remarks (e.g. SC5AC2)
+var cloudGenera = map[string]string{
+	"CI":  "cirrus",
+	"CC":  "cirrocumulus",
+	"CS":  "cirrostratus",
+	"AC":  "altocumulus",
+	"ACC": "altocumulus castellanus",
+	"AS":  "altostratus",
+	"NS":  "nimbostratus",
+	"SC":  "stratocumulus",
+	"ST":  "stratus",
+	"SF":  "stratus fractus",
+	"CU":  "cumulus",
+	"CF":  "cumulus fractus",
+	"TCU": "towering cumulus",
+	"CB":  "cumulonimbus",
+}
+
+// cloudLayerElement matches one cloud genus or obscuring phenomenon and its oktas
+const cloudLayerElement = `(ACC|TCU|BLSN|DRSN|BLDU|BLSA|CI|CC|CS|AC|AS|NS|SC|ST|SF|CU|CF|CB|FG|BR|HZ|FU|SN|DZ|RA|IC|DU|SA|VA)(\d)`
+
+// Maintenance and sensor status remarks, summarized in a single advisory line
+var SensorStatusAdvisories = map[string]string{
+	"$":      "equipment maintenance required",
+	"RVRNO":  "RVR not available",
+	"PWINO":  "precipitation type sensor not available",
+	"PNO":    "precipitation amount not available",
+	"FZRANO": "freezing rain sensor not available",
+	"TSNO":   "lightning detection not available",
+	"SLPNO":  "sea level pressure not available",
+	"VISNO":  "second-location visibility not available",
+	"CHINO":  "second-location cloud height not available",
+}
+
+// NATO color states, from best to worst flying conditions
+var colorStates = map[string]string{
+	"BLU":  "blue",
+	"WHT":  "white",
+	"GRN":  "green",
+	"YLO":  "yellow",
+	"YLO1": "yellow 1",
+	"YLO2": "yellow 2",
+	"AMB":  "amber",
+	"RED":  "red",
+}
+
+// Cloud base and visibility minimums of each NATO color state
+var colorStateMinimumDescriptions = map[string]string{
+	"BLU":  "≥2500 ft ceiling and ≥8 km visibility",
+	"WHT":  "≥1500 ft ceiling and ≥5 km visibility",
+	"GRN":  "≥700 ft ceiling and ≥3.7 km visibility",
+	"YLO":  "≥300 ft ceiling and ≥1.6 km visibility",
+	"YLO1": "≥500 ft ceiling and ≥2.5 km visibility",
+	"YLO2": "≥300 ft ceiling and ≥1.6 km visibility",
+	"AMB":  "≥200 ft ceiling and ≥800 m visibility",
+	"RED":  "<200 ft ceiling or <800 m visibility",
+}
+
+// UnicodeFractions maps fractions to their single-glyph forms
+var UnicodeFractions = map[string]string{
+	"1/2": "½",
+	"1/4": "¼",
+	"3/4": "¾",
+	"1/8": "⅛",
+	"3/8": "⅜",
+	"5/8": "⅝",
+	"7/8": "⅞",
+}
+
+// visRemarkValue matches a visibility value in remarks
+const visRemarkValue = `(\d{4}|M?\d{1,2} \d/\d{1,2}|M?\d/\d{1,2}|M?\d{1,2})`
+
+// Commonly used regular expressions
+var (
+	timeRegex         = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})Z$`)
+	windRegex         = regexp.MustCompile(`^(VRB|\d{3})(\d{2,3})(G(\d{2,3}))?KT$|^(0+)(G\d{2})?KT$`)
+	windRegexMPS      = regexp.MustCompile(`^(VRB|\d{3})(\d{2,3})(G(\d{2,3}))?MPS$|^(0+)(G\d{2})?MPS$`)
+	windRegexKMH      = regexp.MustCompile(`^(VRB|\d{3})(\d{2,3})(G(\d{2,3}))?KMH$|^(0+)(G\d{2})?KMH$`)
+	windVarRegex      = regexp.MustCompile(`^(\d{3})V(\d{3})$`)
+	windShearAltRegex = regexp.MustCompile(`^WS(\d{3})/(\d{3})(\d{2,3})(G(\d{2,3}))?KT$`)
+	windShearRwyRegex = regexp.MustCompile(`^WS(\s+(TKOF|LDG|ALL)\s+RWY(\d{2}[LCR]?)?|\s+R(\d{2}[LCR]?)?)$`)
+	visRegexM         = regexp.MustCompile(`^M?(\d+(?:/\d+)?)SM$`)
+	visRegexP         = regexp.MustCompile(`^(\d+(?:/\d+)?|M|P)(\d+)SM$`)
+	visRegexNum       = regexp.MustCompile(`^\d{4}$`)
+	visRegexDir       = regexp.MustCompile(`^(\d{4})([NESW]{1,2})$`)
+	cloudRegex        = regexp.MustCompile(`^(SKC|CLR|FEW|SCT|BKN|OVC)(\d{3})?(CB|TCU)?$`)
+	tempRegex         = regexp.MustCompile(`^(M?)(\d{1,2})/(M?)(\d{1,2})$`)
+	tempOnlyRegex     = regexp.MustCompile(`^(M?)(\d{2})/$`)
+	pressureRegex     = regexp.MustCompile(`^A(\d{4})$`)
+	// Peak wind remarks (e.g., PK WND 28045/15) and hourly precipitation amounts (P0009)
+	peakWindRegex     = regexp.MustCompile(`^PK\s+WND\s+(\d{3})(\d{2,3})/(\d{2})(\d{2})$`)
+	precipAmountRegex = regexp.MustCompile(`^P(\d{4})$`)
+	// Wind direction variation in remarks (e.g., WND 020V040)
+	windVarRemarkRegex = regexp.MustCompile(`^WND (\d{2,3})V(\d{2,3})$`)
+	// Hail size remarks: GR 1 3/4, GR 1/2, GR M1/4 (less than 1/4 inch) or GS 1/4
+	hailSizeRegex = regexp.MustCompile(`^(GR|GS) (M)?(\d+ \d/\d|\d/\d|\d+)$`)
+	// Temperature and dew point in tenths of degrees in remarks (e.g. T02170183), with the
+	// dew point slashed out when it's missing (T0217////)
+	preciseTempRegex = regexp.MustCompile(`^T([01])(\d{3})(?:([01])(\d{3})|////)$`)
+	// Altimeter settings repeated in remarks: A3028, QNH2998INS, QNH1013 or Q1013
+	remarkAltimeterRegex = regexp.MustCompile(`^(?:A(\d{4})|QNH(\d{4})INS|(?:QNH|Q)(\d{4}))$`)
+	trendTimeRegex       = regexp.MustCompile(`^(FM|TL|AT)(\d{2})(\d{2})$`)
+	validRegex           = regexp.MustCompile(`^(\d{2})(\d{2})/(\d{2})(\d{2})$`)
+	probRegex            = regexp.MustCompile(`^PROB(\d{2})$`)
+	cavokRegex           = regexp.MustCompile(`^CAVOK$`)
+	RVRRegex             = regexp.MustCompile(`^R(\d{2}[CLR]?)/([MP]?\d+)([DNU])?$`)
+	// Enhanced runway condition regex that handles variable values, peak values and trend indicator
+	// Updated to correctly capture trend indicator both with and without a preceding slash
+	runwayCondRegex = regexp.MustCompile(`^R(\d{2}[CLR]?)/(([MP]?\d+)(V([MP]?\d+))?(FT)?)(/(U|D|N)|U|D|N)?$`)
+	// Regex for cleared runway condition (e.g., R24C/CLRD62)
+	runwayClearedRegex = regexp.MustCompile(`^R(\d{2}[CLR]?)/CLRD(\d{2}|//)$`)
+	// Begin/end remarks (e.g., SNB20, -SHSNB20, TSB24E51, SNE0659B35, RASNE33SNB34), and
+	// their phenomenon groups with any intensity, and individual times
+	beginEndRegex      = regexp.MustCompile(`^(?:[-+]?` + beginEndPhenomenon + `(?:[BE](?:\d{4}|\d{2}))+)+$`)
+	beginEndGroupRegex = regexp.MustCompile(`([-+]?)(` + beginEndPhenomenon + `)((?:[BE](?:\d{4}|\d{2}))+)`)
+	beginEndEventRegex = regexp.MustCompile(`([BE])(\d{4}|\d{2})`)
+	// Regex for runway state groups (e.g., R24L/590155, R06/5//0//): deposit, extent, depth
+	// and friction, where any of them may be slashed out as not reported
+	runwayStateRegex  = regexp.MustCompile(`^R(\d{2}[CLR]?)/([\d/])([\d/])(\d{2}|//)(\d{2}|//)$`)
+	vvRegex           = regexp.MustCompile(`^VV(\d{3})$`)
+	ndvRegex          = regexp.MustCompile(`^(\d{4,5})NDV$`)
+	eWindRegex        = regexp.MustCompile(`^E(\d{3})(\d{2,3})(G(\d{2,3}))?KT$`)
+	extCloudRegex     = regexp.MustCompile(`^(FEW|SCT|BKN|OVC)(CB|TCU)(\d{3})$`)
+	specialRegex      = regexp.MustCompile(`^(NOSIG|AUTO|COR|CCA|NSC|NCD|RTD)$`)
+	runwayNumberRegex = regexp.MustCompile(`^\d{2}[LCR]?$`)
+	remarkTimeRegex   = regexp.MustCompile(`^(?:(\d{2})(\d{2})(\d{2})|(\d{2})Z|\d{4})$`)
+	dustSandRegex     = regexp.MustCompile(`^[-+]?(?:BL|DR)?(?:PO|DU|SA|SS|DS)$`)
+	vicinityRegex     = regexp.MustCompile(`^[-+]?VC[A-Z]{2,6}$`)
+	partialFogRegex   = regexp.MustCompile(`^(?:MI|PR|BC)FG$`)
+	obscurationRegex  = regexp.MustCompile(`^(?:FU|HZ|VA)$`)
+	// Coded remark groups that end a free-text note: sea level pressure, precise temperature,
+	// 6- and 24-hour temperatures, pressure tendency and precipitation amounts
+	codedRemarkRegex = regexp.MustCompile(`^(?:SLP\d{3}|T[01]\d{3}(?:[01]\d{3})?|[12][01]\d{3}|4[01]\d{3}[01]\d{3}|5[0-8]\d{3}|[67]\d{4}|P\d{4})$`)
+	// Ceiling remarks: CIG 005V010, CIG BLW 010, CIG 005 RWY11, and the Canadian CIG VRB 9-15 and CIG RAG
+	ceilingRemarkRegex = regexp.MustCompile(`^CIG (?:VRB (\d{1,3})-(\d{1,3})|(RAG)|(?:(BLW|ABV) )?(\d{3})(?:V(\d{3}))?(?: RWY ?(\d{2}[LCR]?))?)(?: |$)`)
+	colorStateRegex    = regexp.MustCompile(`^(BLACK)?(BLU|WHT|GRN|YLO[12]?|AMB|RED)(\+)?$`)
+	recentColorRegex   = regexp.MustCompile(`^((?:BLACK)?(?:BLU|WHT|GRN|YLO[12]?|AMB|RED)\+?)/((?:BLACK)?(?:BLU|WHT|GRN|YLO[12]?|AMB|RED)\+?)$`)
+	cloudLayerRegex    = regexp.MustCompile(cloudLayerElement)
+	cloudLayersRegex   = regexp.MustCompile(`^(?:` + cloudLayerElement + `)+$`)
+	// Visibility remarks; values are statute miles (e.g. "1 1/2", "M1/4") or four-digit meters
+	visRemarkMinRegex      = regexp.MustCompile(`^VIS MIN (\d{4})([NESW]{1,2})?(?: |$)`)
+	visRemarkVariableRegex = regexp.MustCompile(`^VIS (?:VRB )?` + visRemarkValue + `[V-]` + visRemarkValue + `(?: |$)`)
+	visRemarkLowerRegex    = regexp.MustCompile(`^VIS LWR ([NESW]{1,2}(?:-[NESW]{1,2})?)(?: |$)`)
+	visRemarkSectorRegex   = regexp.MustCompile(`^VIS ([NESW]{1,2}(?:-[NESW]{1,2})?) ` + visRemarkValue + `(?: |$)`)
+	visRemarkRegex         = regexp.MustCompile(`^VIS ` + visRemarkValue + `(?: RWY(\d{2}[LCR]?))?(?: |$)`)
+)
+
+// WeatherData contains common fields for different weather reports
+type WeatherData struct {
+	Raw       string    `json:"raw"`
+	Station   string    `json:"station"`
+	Time      time.Time `json:"time"`
+	FetchedAt time.Time `json:"fetched_at,omitzero"` // When the report was fetched; zero for reports that weren't (e.g. piped input)
+}
+
+// Wind represents wind information in a weather report
+type Wind struct {
+	Direction string `json:"direction"`
+	Speed     *int   `json:"speed"`
+	Gust      int    `json:"gust"`
+	Unit      string `json:"unit"`
+}
+
+// WindShear represents wind shear information in a weather report
+type WindShear struct {
+	Type     string `json:"type"`     // "RWY" for runway or "ALT" for altitude
+	Runway   string `json:"runway"`   // Runway identifier (e.g., "12", "30L")
+	Phase    string `json:"phase"`    // "TKOF", "LDG", or "ALL"
+	Altitude int    `json:"altitude"` // Altitude in hundreds of feet (only for altitude type)
+	Wind     Wind   `json:"wind"`     // Wind information at the shear level (only for altitude type)
+	Raw      string `json:"raw"`      // Original raw string
+}
+
+// Visibility represents a prevailing visibility value in a weather report
+type Visibility struct {
+	Meters       int     `json:"meters"`        // Visibility in meters (converted when reported in statute miles)
+	StatuteMiles float64 `json:"statute_miles"` // Visibility in statute miles (converted when reported in meters)
+	Unit         string  `json:"unit"`          // Reported unit: "SM" for statute miles or "M" for meters
+	Unlimited    bool    `json:"unlimited"`     // 10 km or more (9999 or CAVOK)
+	LessThan     bool    `json:"less_than"`     // Below the reported value (M prefix, or 0000 for less than 50 meters)
+	MoreThan     bool    `json:"more_than"`     // Above the reported value (P prefix, e.g. P6SM)
+	Direction    string  `json:"direction"`     // Direction the value applies to (e.g., "NE"), or "NDV" for no directional variation
+	Raw          string  `json:"raw"`           // Original raw string (e.g., "1 1/2SM", "4000NE", "CAVOK")
+}
+
+// Cloud represents cloud information in a weather report
+type Cloud struct {
+	Coverage string `json:"coverage"`
+	Height   int    `json:"height"`
+	Type     string `json:"type"`               // CB, TCU, etc.
+	Opacity  int    `json:"opacity"`            // Oktas from a Canadian cloud-layer remark, 0 when not reported
+	Location string `json:"location,omitempty"` // Location and movement from a convective cloud remark (e.g. "distant NW, moving E")
+}
+
+// ConvectiveCloudRemark is a remark locating convective clouds, such as CB DSNT NW MOV E
+type ConvectiveCloudRemark struct {
+	Type      string   `json:"type"`                // CB, CBMAM or TCU
+	Qualifier string   `json:"qualifier,omitempty"` // DSNT, VC, OHD or ALQDS
+	Location  []string `json:"location,omitempty"`  // Compass points or ranges (e.g. "NW", "N-E")
+	Movement  string   `json:"movement,omitempty"`  // Compass point the clouds are moving toward, or STNRY
+}
+
+// CloudLayerRemark is one entry of a Canadian cloud-layer remark (e.g. SC5 in SC5AC2):
+// a cloud genus or obscuring phenomenon and the oktas of sky it covers
+type CloudLayerRemark struct {
+	Type        string `json:"type"` // Cloud genus (e.g. "SC") or obscuring weather (e.g. "FG")
+	Oktas       int    `json:"oktas"`
+	Obscuration bool   `json:"obscuration"` // Weather obscuring the sky rather than a cloud layer
+}
+
+// Remark represents a decoded remark from the RMK section
+type Remark struct {
+	Raw         string `json:"raw"`
+	Description string `json:"description"`
+}
+
+// UnknownRemarkDescription describes a remark the decoder doesn't recognize
+const UnknownRemarkDescription = "unknown remark code"
+
+// SiteInfo represents the location information for a station
+type SiteInfo struct {
+	Name      string  `json:"name"`
+	State     string  `json:"state"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"latitude"` // Station coordinates, when known
+	Longitude float64 `json:"longitude"`
+}
+
+// RunwayCondition represents runway visual range and surface conditions information
+type RunwayCondition struct {
+	Runway      string       `json:"runway"`          // Runway identifier (e.g., "21", "24C", "27")
+	Visibility  int          `json:"visibility"`      // Visibility in feet or meters
+	VisMin      int          `json:"vis_min"`         // For variable visibility - minimum value
+	VisMax      int          `json:"vis_max"`         // For variable visibility - maximum value
+	Trend       string       `json:"trend"`           // Trend indicator: "U" (upward), "D" (downward), or "N" (no change)
+	Unit        string       `json:"unit"`            // "FT" for feet or "" for meters
+	Prefix      string       `json:"prefix"`          // Prefix if any: "P" (more than) or "M" (less than)
+	MaxPrefix   string       `json:"max_prefix"`      // Prefix of the maximum value for variable visibility: "P" or "M"
+	Cleared     bool         `json:"cleared"`         // Whether the runway is cleared
+	ClearedTime int          `json:"cleared_time"`    // The two digits of a CLRD group (its friction code), or 0 when slashed out
+	State       *RunwayState `json:"state,omitempty"` // Surface state, for runway state groups, or the friction of a CLRD group
+	Raw         string       `json:"raw"`             // Original raw string
+}
+
+// RunwayState represents the decoded surface state from a runway state group. Each field
+// holds a description and is empty when the group slashes it out as not reported.
+type RunwayState struct {
+	Deposit  string `json:"deposit"`  // Type of deposit (e.g., "wet snow")
+	Extent   string `json:"extent"`   // Share of the runway covered (e.g., "11–25%")
+	Depth    string `json:"depth"`    // Depth of the deposit (e.g., "5 mm")
+	Friction string `json:"friction"` // Friction coefficient or estimated braking action
+}
+
+// METAR represents a decoded METAR weather report
+type METAR struct {
+	WeatherData
+	SiteInfo           SiteInfo                `json:"site_info"`
+	Wind               Wind                    `json:"wind"`
+	WindShear          []WindShear             `json:"wind_shear"`
+	WindVariation      string                  `json:"wind_variation"` // Wind direction variation (e.g., "360V040")
+	Visibility         Visibility              `json:"visibility"`
+	TowerVisibility    Visibility              `json:"tower_visibility"`   // Visibility from a TWR VIS remark
+	SurfaceVisibility  Visibility              `json:"surface_visibility"` // Visibility from a SFC VIS remark
+	Weather            []string                `json:"weather"`
+	Clouds             []Cloud                 `json:"clouds"`
+	CloudLayers        []CloudLayerRemark      `json:"cloud_layers"`       // Canadian cloud-layer remark, one entry per layer
+	ConvectiveClouds   []ConvectiveCloudRemark `json:"convective_clouds"`  // Remarks locating CB and TCU (e.g. CB DSNT NW MOV E)
+	ColorStateCode     string                  `json:"color_state_code"`   // Reported NATO color state (e.g. "GRN", "BLACKBLU"), from the body or remarks
+	RecentColorState   string                  `json:"recent_color_state"` // Earlier color state reported after a slash (the WHT of "BLU/WHT")
+	VertVis            int                     `json:"vert_vis"`           // Vertical visibility in hundreds of feet
+	Temperature        *int                    `json:"temperature"`        // Changed to pointer to represent missing value
+	DewPoint           *int                    `json:"dew_point"`          // Using pointer to represent missing dew point
+	Pressure           float64                 `json:"pressure"`
+	PressureUnit       string                  `json:"pressure_unit"`        // "hPa" or "inHg"
+	RemarkPressure     float64                 `json:"remark_pressure"`      // Altimeter setting repeated in remarks (e.g. A3028 alongside Q1025)
+	RemarkPressureUnit string                  `json:"remark_pressure_unit"` // "hPa" or "inHg"
+	Remarks            []Remark                `json:"remarks"`
+	RunwayConditions   []RunwayCondition       `json:"runway_conditions"` // Detailed runway visual range and conditions
+	RVR                []string                `json:"rvr"`               // Legacy RVR field (maintained for compatibility)
+	SpecialCodes       []string                `json:"special_codes"`     // Special codes like AUTO, COR, etc.
+	Trends             []Forecast              `json:"trends"`            // Trend forecasts after the observation (NOSIG, BECMG, TEMPO)
+	Unhandled          []string                `json:"unhandled"`
+}
+
+// Forecast represents a single forecast period within a TAF
+type Forecast struct {
+	Type          string      `json:"type"`        // FM (from), TEMPO (temporary), BECMG (becoming), PROB30, PROB40, etc.
+	Probability   int         `json:"probability"` // For PROB forecasts, the probability value (30, 40, etc.)
+	From          time.Time   `json:"from"`        // Start time of this forecast period
+	To            time.Time   `json:"to"`          // End time of this forecast period (if applicable)
+	Wind          Wind        `json:"wind"`
+	WindVariation string      `json:"wind_variation"` // Wind direction variation (e.g., "360V040")
+	WindShear     []WindShear `json:"wind_shear"`
+	Visibility    Visibility  `json:"visibility"`
+	Weather       []string    `json:"weather"`
+	Clouds        []Cloud     `json:"clouds"`
+	VertVis       int         `json:"vert_vis"` // Vertical visibility in hundreds of feet
+	Raw           string      `json:"raw"`      // Raw text for this forecast period
+}
+
+// TAF represents a decoded Terminal Aerodrome Forecast
+type TAF struct {
+	WeatherData
+	SiteInfo  SiteInfo   `json:"site_info"`
+	ValidFrom time.Time  `json:"valid_from"`
+	ValidTo   time.Time  `json:"valid_to"`
+	Forecasts []Forecast `json:"forecasts"`
+
+	// AMD NOT SKED: amendments are not scheduled, optionally only within a window.
+	// A zero AmendmentsFrom or AmendmentsTo leaves that end of the window open.
+	AmendmentsNotScheduled bool      `json:"amendments_not_scheduled"`
+	AmendmentsFrom         time.Time `json:"amendments_from"`
+	AmendmentsTo           time.Time `json:"amendments_to"`
+}
@@ -0,0 +1,8 @@
+// Package wx decodes METAR and TAF aviation weather reports into structs.
+//
+// It has no network or terminal dependencies: fetching reports and formatting them for
+// display is left to the caller (the wxcraft command does both).
+//
+//	metar := wx.DecodeMETAR("KORD 081551Z 27010KT 10SM FEW250 21/09 A3012 RMK AO2")
+//	fmt.Println(*metar.Wind.Speed, metar.Visibility.StatuteMiles)
+package wx
@@ -0,0 +1,24 @@
+package wx_test
+
+import (
+	"fmt"
+
+	"github.com/rmitchellscott/WxCraft/wx"
+)
+
+func ExampleDecodeMETAR() {
+	metar := wx.DecodeMETAR("KORD 081551Z 27010KT 10SM FEW250 21/09 A3012 RMK AO2 SLP199")
+
+	fmt.Println(metar.Station, metar.Wind.Direction, *metar.Wind.Speed, metar.Wind.Unit)
+	fmt.Println(metar.Visibility.StatuteMiles, metar.Clouds[0].Coverage, metar.Clouds[0].Height)
+	fmt.Println(*metar.Temperature, *metar.DewPoint, metar.Pressure)
+	for _, remark := range metar.Remarks {
+		fmt.Printf("%s: %s\n", remark.Raw, remark.Description)
+	}
+	// Output:
+	// KORD 270 10 KT
+	// 10 FEW 25000
+	// 21 9 30.12
+	// AO2: automated station with precipitation sensor
+	// SLP199: sea level pressure 1019.9 hPa
+}
@@ -0,0 +1,237 @@
+package wx
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// // FormatWeather converts a slice of weather strings to a human-readable format
+// func FormatWeather(weather []string) string {
+// 	if len(weather) == 0 {
+// 		return ""
+// 	}
+
+// 	var weatherStrs []string
+// 	for _, wx := range weather {
+// 		if desc, ok := weatherDescriptions[wx]; ok {
+// 			weatherStrs = append(weatherStrs, desc)
+// 		} else {
+// 			weatherStrs = append(weatherStrs, wx)
+// 		}
+// 	}
+
+//		return strings.Join(weatherStrs, ", ")
+//	}
+//
+// WeatherCode represents a weather code and its properties
+type WeatherCode struct {
+	Description string
+	Position    int
+}
+
+// FormatWeather converts weather code strings into human-readable descriptions
+func FormatWeather(weather []string) string {
+	if len(weather) == 0 {
+		return ""
+	}
+
+	var formattedWeather []string
+
+	for _, wxCode := range weather {
+		// Split the weather code by spaces to handle multiple elements
+		elements := strings.Fields(wxCode)
+
+		if len(elements) == 1 {
+			// Single code with no spaces (like "VCHZ")
+			formattedWeather = append(formattedWeather, formatWeatherElement(wxCode))
+		} else {
+			// Multiple codes separated by spaces
+			var elementDescriptions []string
+
+			for _, element := range elements {
+				elementDescriptions = append(elementDescriptions, formatWeatherElement(element))
+			}
+
+			formattedWeather = append(formattedWeather, strings.Join(elementDescriptions, ", "))
+		}
+	}
+
+	return strings.Join(formattedWeather, ", ")
+}
+
+// formatWeatherElement handles a single weather element, with or without combined codes
+func formatWeatherElement(code string) string {
+	// First check if this is a simple code we already know
+	if wc, ok := WeatherCodes[code]; ok {
+		return wc.Description
+	}
+
+	// If not a simple code, try to break it down into components
+	type ParsedPart struct {
+		Description string
+		Position    int
+	}
+
+	var parts []ParsedPart
+	remainingCode := code
+
+	// Process the code by looking for known two-letter and one-letter codes
+	for len(remainingCode) > 0 {
+		found := false
+
+		// Try to match 2-letter codes first
+		if len(remainingCode) >= 2 {
+			twoLetters := remainingCode[:2]
+			if wc, ok := WeatherCodes[twoLetters]; ok {
+				found = true
+				parts = append(parts, ParsedPart{
+					Description: wc.Description,
+					Position:    wc.Position,
+				})
+				remainingCode = remainingCode[2:]
+				continue
+			}
+		}
+
+		// If no 2-letter code matched, try 1-letter codes (like "+" or "-")
+		if len(remainingCode) >= 1 {
+			oneLetter := remainingCode[:1]
+			if wc, ok := WeatherCodes[oneLetter]; ok {
+				found = true
+				parts = append(parts, ParsedPart{
+					Description: wc.Description,
+					Position:    wc.Position,
+				})
+				remainingCode = remainingCode[1:]
+				continue
+			}
+		}
+
+		// If we didn't find a match, we can't completely parse this code
+		if !found {
+			// If we parsed at least part of the code, add the remaining as a main phenomenon
+			if len(parts) > 0 {
+				parts = append(parts, ParsedPart{
+					Description: remainingCode,
+					Position:    1, // Treat unparsed remainder as main phenomenon
+				})
+			} else {
+				// If we couldn't parse anything, return the original code
+				return code
+			}
+			break
+		}
+	}
+
+	// If we parsed the whole code but didn't find any main phenomenon (position 1),
+	// promote the first modifier to main phenomenon if available
+	hasMainPhenomenon := false
+	for _, part := range parts {
+		if part.Position == 1 {
+			hasMainPhenomenon = true
+			break
+		}
+	}
+
+	if !hasMainPhenomenon && len(parts) > 0 {
+		// Look for modifiers to promote
+		for i, part := range parts {
+			if part.Position == 2 { // Modifier
+				parts[i].Position = 1 // Promote to main phenomenon
+				hasMainPhenomenon = true
+				break
+			}
+		}
+
+		// If still no main phenomenon, promote the last prefix
+		if !hasMainPhenomenon {
+			for i := len(parts) - 1; i >= 0; i-- {
+				if parts[i].Position == 0 { // Prefix
+					parts[i].Position = 1 // Promote to main phenomenon
+					break
+				}
+			}
+		}
+	}
+
+	// Sort parts by position
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].Position < parts[j].Position
+	})
+
+	// Build the final description
+	var descriptions []string
+	for _, part := range parts {
+		descriptions = append(descriptions, part.Description)
+	}
+
+	// If we couldn't parse anything meaningful, return the original code
+	if len(descriptions) == 0 {
+		return code
+	}
+
+	return strings.Join(descriptions, " ")
+}
+
+// FormatNumberWithCommas adds thousands separators to a number
+func FormatNumberWithCommas(n int) string {
+	// Convert to string first
+	numStr := strconv.Itoa(n)
+
+	// Add commas for thousands
+	result := ""
+	for i, c := range numStr {
+		if i > 0 && (len(numStr)-i)%3 == 0 {
+			result += ","
+		}
+		result += string(c)
+	}
+
+	return result
+}
+
+// FormatSiteInfo returns a formatted string with the site information
+func (m METAR) FormatSiteInfo() string {
+	parts := []string{}
+
+	if m.SiteInfo.Name != "" {
+		parts = append(parts, m.SiteInfo.Name)
+	}
+
+	if m.SiteInfo.State != "" {
+		parts = append(parts, m.SiteInfo.State)
+	}
+
+	if m.SiteInfo.Country != "" {
+		parts = append(parts, m.SiteInfo.Country)
+	}
+
+	if len(parts) == 0 {
+		return m.Station // Fallback to station code if no site info
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func (t TAF) FormatSiteInfo() string {
+	parts := []string{}
+
+	if t.SiteInfo.Name != "" {
+		parts = append(parts, t.SiteInfo.Name)
+	}
+
+	if t.SiteInfo.State != "" {
+		parts = append(parts, t.SiteInfo.State)
+	}
+
+	if t.SiteInfo.Country != "" {
+		parts = append(parts, t.SiteInfo.Country)
+	}
+
+	if len(parts) == 0 {
+		return t.Station // Fallback to station code if no site info
+	}
+
+	return strings.Join(parts, ", ")
+}
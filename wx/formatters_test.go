@@ -0,0 +1,30 @@
+package wx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatWeather_fog(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		metar string
+		want  string
+	}{
+		{metar: "KBOS 081554Z 27010KT 1/2SM MIFG FEW010 10/09 A3012", want: "shallow fog"},
+		{metar: "KBOS 081554Z 27010KT 3SM PRFG FEW010 10/09 A3012", want: "partial fog"},
+		{metar: "KBOS 081554Z 27010KT 3SM BCFG FEW010 10/09 A3012", want: "patches of fog"},
+		{metar: "KBOS 081554Z 27010KT 10SM VCFG FEW010 10/09 A3012", want: "fog in the vicinity"},
+		{metar: "KBOS 081554Z 27010KT 10SM VCBR FEW010 10/09 A3012", want: "mist in the vicinity"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			metar := DecodeMETAR(tt.metar)
+			assert.Empty(t, metar.Unhandled)
+			assert.Equal(t, tt.want, FormatWeather(metar.Weather))
+		})
+	}
+}
@@ -0,0 +1,595 @@
+package wx
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/utils/ptr"
+)
+
+// parseTime parses an observation or issue time in the format "DDHHMM"Z. Reports are
+// never from a later day than now, so a day ahead of today is last month's (e.g. the 31st
+// seen on the 1st), even when clock skew or a station past midnight makes it look recent.
+func parseTime(timeStr string, now time.Time) (time.Time, error) {
+	matches := timeRegex.FindStringSubmatch(timeStr)
+	if matches == nil {
+		return time.Time{}, fmt.Errorf("invalid time format: %s", timeStr)
+	}
+
+	day, _ := strconv.Atoi(matches[1])
+	hour, _ := strconv.Atoi(matches[2])
+	minute, _ := strconv.Atoi(matches[3])
+
+	return latestDayTime(now.UTC(), day, hour, minute), nil
+}
+
+// latestDayTime returns the time on the given day of the month in the latest month, ref's
+// or one of the two before it, in which that day is not after ref's day
+func latestDayTime(ref time.Time, day, hour, minute int) time.Time {
+	for offset := 0; offset >= -2; offset-- {
+		if offset == 0 && day > ref.Day() {
+			continue
+		}
+		// Skip months that don't have this day (e.g. the 31st in April)
+		date := time.Date(ref.Year(), ref.Month()+time.Month(offset), day, 0, 0, 0, 0, time.UTC)
+		if date.Day() != day {
+			continue
+		}
+		return date.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	return time.Time{}
+}
+
+// nearestDayTime returns the time on the given day of the month, in ref's month or the
+// month before or after, that is nearest to ref. Hour 24 means midnight at the end of the day.
+func nearestDayTime(ref time.Time, day, hour, minute int) time.Time {
+	var nearest time.Time
+	for offset := -1; offset <= 1; offset++ {
+		// Skip months that don't have this day (e.g. the 31st in April)
+		date := time.Date(ref.Year(), ref.Month()+time.Month(offset), day, 0, 0, 0, 0, time.UTC)
+		if date.Day() != day {
+			continue
+		}
+
+		candidate := date.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+		if nearest.IsZero() || candidate.Sub(ref).Abs() < nearest.Sub(ref).Abs() {
+			nearest = candidate
+		}
+	}
+	return nearest
+}
+
+// ParseWind parses a wind string in the format "DDDSSKT", "DDDSSGGKT", "DDDSSMPS", "DDDSSGGMPS",
+// "DDDSSKMH" or "DDDSSGGKMH"
+func ParseWind(windStr string) Wind {
+	// Check for special cases where wind speed consists of zeros
+	zeroKTRegex := regexp.MustCompile(`^(VRB|\d{3})(0+)KT$`)
+	zeroMPSRegex := regexp.MustCompile(`^(VRB|\d{3})(0+)MPS$`)
+
+	// Handle zero wind speed cases
+	if matches := zeroKTRegex.FindStringSubmatch(windStr); matches != nil {
+		return Wind{
+			Direction: matches[1],
+			Speed:     ptr.To(0),
+			Unit:      "KT",
+		}
+	}
+
+	if matches := zeroMPSRegex.FindStringSubmatch(windStr); matches != nil {
+		return Wind{
+			Direction: matches[1],
+			Speed:     ptr.To(0),
+			Unit:      "MPS",
+		}
+	}
+
+	// Try to match KT format
+	matches := windRegex.FindStringSubmatch(windStr)
+	if matches != nil {
+		wind := Wind{
+			Direction: matches[1],
+			Unit:      "KT",
+		}
+		speed, _ := strconv.Atoi(matches[2])
+		wind.Speed = &speed
+		if matches[4] != "" {
+			wind.Gust, _ = strconv.Atoi(matches[4])
+		}
+
+		return wind
+	}
+
+	// Try to match MPS format
+	matches = windRegexMPS.FindStringSubmatch(windStr)
+	if matches != nil {
+		wind := Wind{
+			Direction: matches[1],
+			Unit:      "MPS",
+		}
+
+		speed, _ := strconv.Atoi(matches[2])
+		wind.Speed = &speed
+		if matches[4] != "" {
+			wind.Gust, _ = strconv.Atoi(matches[4])
+		}
+
+		return wind
+	}
+
+	// Try to match KMH format
+	matches = windRegexKMH.FindStringSubmatch(windStr)
+	if matches != nil {
+		wind := Wind{
+			Direction: matches[1],
+			Unit:      "KMH",
+		}
+
+		speed, _ := strconv.Atoi(matches[2])
+		wind.Speed = &speed
+		if matches[4] != "" {
+			wind.Gust, _ = strconv.Atoi(matches[4])
+		}
+
+		return wind
+	}
+
+	return Wind{}
+}
+
+// parseWindVariation parses a wind variation string in the format "DDDVDDD"
+func parseWindVariation(varStr string) string {
+	matches := windVarRegex.FindStringSubmatch(varStr)
+	if matches == nil {
+		return ""
+	}
+
+	// The regex already ensures these are valid directional values
+	// No need to perform additional validation, return the formatted string
+	return varStr
+}
+
+// metersPerStatuteMile is used to convert between visibility units
+const metersPerStatuteMile = 1609.344
+
+// ParseVisibility parses a prevailing visibility value such as "10SM", "1 1/2SM", "M1/4SM",
+// "P6SM", "4000", "9999", "2000NE", "4000NDV" or "CAVOK". Values that can't be parsed keep
+// only their Raw form.
+func ParseVisibility(visStr string) Visibility {
+	vis := Visibility{Raw: visStr}
+
+	switch {
+	case visStr == "":
+		return vis
+
+	case visStr == "CAVOK":
+		vis.Unit = "M"
+		vis.Meters = 10000
+		vis.Unlimited = true
+
+	case strings.HasSuffix(visStr, "SM"):
+		value := strings.TrimSuffix(visStr, "SM")
+		if rest, ok := strings.CutPrefix(value, "M"); ok {
+			vis.LessThan = true
+			value = rest
+		} else if rest, ok := strings.CutPrefix(value, "P"); ok {
+			vis.MoreThan = true
+			value = rest
+		}
+
+		miles, ok := parseStatuteMiles(value)
+		if !ok {
+			return Visibility{Raw: visStr}
+		}
+		vis.Unit = "SM"
+		vis.StatuteMiles = miles
+		vis.Meters = int(math.Round(miles * metersPerStatuteMile))
+		return vis
+
+	case visRegexNum.MatchString(visStr):
+		vis.Unit = "M"
+		vis.Meters, _ = strconv.Atoi(visStr)
+
+	case visRegexDir.MatchString(visStr):
+		matches := visRegexDir.FindStringSubmatch(visStr)
+		vis.Unit = "M"
+		vis.Meters, _ = strconv.Atoi(matches[1])
+		vis.Direction = matches[2]
+
+	case ndvRegex.MatchString(visStr):
+		matches := ndvRegex.FindStringSubmatch(visStr)
+		vis.Unit = "M"
+		vis.Meters, _ = strconv.Atoi(matches[1])
+		vis.Direction = "NDV"
+
+	default:
+		return vis
+	}
+
+	// Meter values: 0000 means less than 50 meters and 9999 means 10 km or more
+	switch vis.Meters {
+	case 0:
+		vis.LessThan = true
+	case 9999:
+		vis.Unlimited = true
+	}
+	vis.StatuteMiles = float64(vis.Meters) / metersPerStatuteMile
+
+	return vis
+}
+
+// parseStatuteMiles parses a statute mile value such as "10", "3/4" or "1 1/2"
+func parseStatuteMiles(value string) (float64, bool) {
+	var total float64
+	for _, field := range strings.Fields(value) {
+		if num, den, isFraction := strings.Cut(field, "/"); isFraction {
+			n, err1 := strconv.Atoi(num)
+			d, err2 := strconv.Atoi(den)
+			if err1 != nil || err2 != nil || d == 0 {
+				return 0, false
+			}
+			total += float64(n) / float64(d)
+		} else {
+			whole, err := strconv.Atoi(field)
+			if err != nil {
+				return 0, false
+			}
+			total += float64(whole)
+		}
+	}
+	return total, value != ""
+}
+
+// ParseCloud parses a cloud string in the format "CCCHHH" or "CCCHHHTTT"
+func ParseCloud(cloudStr string) Cloud {
+	matches := cloudRegex.FindStringSubmatch(cloudStr)
+	if matches == nil {
+		return Cloud{}
+	}
+
+	cloud := Cloud{
+		Coverage: matches[1],
+		Type:     matches[3],
+	}
+
+	// Only try to parse height if it exists
+	if matches[2] != "" && len(matches[2]) > 0 {
+		height, _ := strconv.Atoi(matches[2])
+		cloud.Height = height * 100
+	}
+
+	return cloud
+}
+
+// ParseWindShear parses a wind shear string into a WindShear struct
+func ParseWindShear(wsStr string) WindShear {
+	ws := WindShear{Raw: wsStr}
+
+	// Direct handling for common patterns
+	// 1. "WS ALL RWY"
+	if wsStr == "WS ALL RWY" {
+		ws.Type = "RWY"
+		ws.Phase = "ALL"
+		return ws
+	}
+
+	// 2. "WS R##" pattern (e.g. "WS R20")
+	if len(wsStr) > 3 && wsStr[:3] == "WS " && wsStr[3] == 'R' && len(wsStr) >= 5 {
+		runway := wsStr[4:]
+		ws.Type = "RWY"
+		ws.Runway = runway
+		return ws
+	}
+
+	// Try runway wind shear format
+	if matches := windShearRwyRegex.FindStringSubmatch(wsStr); matches != nil {
+		ws.Type = "RWY"
+		ws.Phase = matches[1]  // TKOF, LDG, or ALL
+		ws.Runway = matches[2] // Runway identifier (may be empty for ALL RWY)
+		return ws
+	}
+
+	// Try altitude wind shear format
+	if matches := windShearAltRegex.FindStringSubmatch(wsStr); matches != nil {
+		ws.Type = "ALT"
+		altitude, _ := strconv.Atoi(matches[1])
+		ws.Altitude = altitude
+
+		// Parse wind at the shear level
+		wind := Wind{
+			Direction: matches[2],
+			Unit:      "KT",
+		}
+		speed, _ := strconv.Atoi(matches[3])
+		wind.Speed = &speed
+
+		if matches[5] != "" {
+			gust, _ := strconv.Atoi(matches[5])
+			wind.Gust = gust
+		}
+
+		ws.Wind = wind
+		return ws
+	}
+
+	return ws
+}
+
+// ParseRunwayCondition parses a runway condition string into a RunwayCondition struct
+func ParseRunwayCondition(condStr string) RunwayCondition {
+	// Create a RunwayCondition with the raw string
+	cond := RunwayCondition{Raw: condStr}
+
+	// First check for CLRD (cleared) format
+	if runwayClearedRegex.MatchString(condStr) {
+		matches := runwayClearedRegex.FindStringSubmatch(condStr)
+		if matches == nil || len(matches) < 3 {
+			return cond
+		}
+
+		cond.Runway = matches[1]
+		cond.Cleared = true
+		clearedTime, _ := strconv.Atoi(matches[2])
+		cond.ClearedTime = clearedTime
+		// The two digits are in the friction position, slashed out when not reported
+		if state, ok := parseRunwayState("/", "/", "//", matches[2]); ok {
+			cond.State = &state
+		}
+		return cond
+	}
+
+	// Runway state groups are all digits too, so check them before visual range
+	if matches := runwayStateRegex.FindStringSubmatch(condStr); matches != nil {
+		if state, ok := parseRunwayState(matches[2], matches[3], matches[4], matches[5]); ok {
+			cond.Runway = matches[1]
+			cond.State = &state
+			return cond
+		}
+	}
+
+	// Then try the standard format
+	matches := runwayCondRegex.FindStringSubmatch(condStr)
+	// Needs at least runway and visibility value
+	if matches == nil || len(matches) < 4 {
+		return cond
+	}
+
+	// Extract runway identifier
+	cond.Runway = matches[1]
+
+	// Extract visibility and prefix
+	visStr := matches[3]
+	if len(visStr) > 0 {
+		// Check for prefix (P for more than, M for less than)
+		if visStr[0] == 'P' {
+			cond.Prefix = "P"
+			visStr = visStr[1:]
+		} else if visStr[0] == 'M' {
+			cond.Prefix = "M"
+			visStr = visStr[1:]
+		}
+
+		// Parse visibility value
+		vis, _ := strconv.Atoi(visStr)
+		cond.Visibility = vis
+	}
+
+	// Check for variable visibility
+	if matches[4] != "" {
+		varVisStr := matches[5]
+
+		// Handle prefixes in variable part (e.g. "P6000" when the maximum exceeds the sensor range)
+		if len(varVisStr) > 0 && (varVisStr[0] == 'P' || varVisStr[0] == 'M') {
+			cond.MaxPrefix = varVisStr[:1]
+			varVisStr = varVisStr[1:]
+		}
+
+		// Store min and max values
+		cond.VisMin = cond.Visibility
+		visMax, _ := strconv.Atoi(varVisStr)
+		cond.VisMax = visMax
+	}
+
+	// Check for unit (FT for feet or nothing for meters)
+	if matches[6] == "FT" {
+		cond.Unit = "FT"
+	}
+
+	// Parse trend indicator
+	if len(matches) > 7 && matches[7] != "" {
+		// Extract the trend from either the full match or just the character
+		if strings.HasPrefix(matches[7], "/") {
+			// Format with slash: R21/1800V2000/U
+			cond.Trend = matches[7][1:]
+		} else {
+			// Format without slash: R21/1800V2000U
+			cond.Trend = matches[7]
+		}
+	}
+
+	return cond
+}
+
+// parseRunwayState decodes the deposit, extent, depth and friction codes of a runway state
+// group. Slashed-out codes are left empty; it reports false if any other code is invalid.
+func parseRunwayState(deposit, extent, depth, friction string) (RunwayState, bool) {
+	var state RunwayState
+	var ok bool
+
+	if deposit != "/" {
+		if state.Deposit, ok = runwayDeposits[deposit]; !ok {
+			return RunwayState{}, false
+		}
+	}
+
+	if extent != "/" {
+		if state.Extent, ok = runwayContaminationExtents[extent]; !ok {
+			return RunwayState{}, false
+		}
+	}
+
+	if depth != "//" {
+		switch mm, _ := strconv.Atoi(depth); {
+		case mm == 0:
+			state.Depth = "less than 1 mm"
+		case mm <= 90:
+			state.Depth = fmt.Sprintf("%d mm", mm)
+		default:
+			if state.Depth, ok = runwayDepositDepths[depth]; !ok {
+				return RunwayState{}, false
+			}
+		}
+	}
+
+	if friction != "//" {
+		if coefficient, _ := strconv.Atoi(friction); coefficient >= 1 && coefficient <= 90 {
+			state.Friction = fmt.Sprintf("friction coefficient 0.%02d", coefficient)
+		} else if state.Friction, ok = runwayBrakingActions[friction]; !ok {
+			return RunwayState{}, false
+		}
+	}
+
+	return state, true
+}
+
+// parseCompassDirection parses a compass point or range of points (e.g. "NE", "S-W")
+func parseCompassDirection(s string) (string, bool) {
+	from, to, isRange := strings.Cut(s, "-")
+
+	fromDesc, ok := compassDirections[from]
+	if !ok {
+		return "", false
+	}
+	if !isRange {
+		return fromDesc, true
+	}
+
+	toDesc, ok := compassDirections[to]
+	if !ok {
+		return "", false
+	}
+	return fromDesc + " through " + toDesc, true
+}
+
+// parseMovement parses a movement group starting at parts[i] (e.g. "MOV E", "MOVG NE", "STNRY").
+// It returns the description and the number of tokens consumed (0 if there is no movement group).
+func parseMovement(parts []string, i int) (string, int) {
+	if i >= len(parts) {
+		return "", 0
+	}
+
+	switch parts[i] {
+	case "STNR", "STNRY":
+		return "stationary", 1
+	case "MOV", "MOVG", "MOVD":
+		if i+1 < len(parts) {
+			if dir, ok := parseCompassDirection(parts[i+1]); ok {
+				return "moving " + dir, 2
+			}
+		}
+	}
+
+	return "", 0
+}
+
+// parseLocationAndMovement parses an optional location (compass points, possibly joined by AND)
+// followed by an optional movement group starting at parts[i], e.g. "W AND NE MOV E".
+// It returns a description suffix (e.g. " to the west and northeast, moving east") and the tokens consumed.
+func parseLocationAndMovement(parts []string, i int) (string, int) {
+	var description string
+	next := i
+
+	// Location, possibly several points joined by AND (e.g., W AND NE)
+	var locations []string
+	for next < len(parts) {
+		dir, ok := parseCompassDirection(parts[next])
+		if !ok {
+			break
+		}
+		locations = append(locations, dir)
+		next++
+		if next+1 < len(parts) && parts[next] == "AND" {
+			if _, ok := parseCompassDirection(parts[next+1]); ok {
+				next++
+			}
+		}
+	}
+	if len(locations) > 0 {
+		description += " to the " + strings.Join(locations, " and ")
+	}
+
+	if movement, n := parseMovement(parts, next); n > 0 {
+		description += ", " + movement
+		next += n
+	}
+
+	return description, next - i
+}
+
+// parseForecastElement parses a single element of a forecast
+func parseForecastElement(forecast *Forecast, part string) {
+	// Wind - check KT, MPS and KMH formats
+	if windRegex.MatchString(part) || windRegexMPS.MatchString(part) || windRegexKMH.MatchString(part) {
+		forecast.Wind = ParseWind(part)
+		return
+	}
+	// Wind direction variation
+	if windVarRegex.MatchString(part) {
+		forecast.WindVariation = parseWindVariation(part)
+		return
+	}
+	// Wind shear
+	if strings.HasPrefix(part, "WS") {
+		ws := ParseWindShear(part)
+		forecast.WindShear = append(forecast.WindShear, ws)
+		return
+	}
+
+	// Visibility in statute miles
+	if visRegexP.MatchString(part) || part == "P6SM" {
+		forecast.Visibility = ParseVisibility(part)
+		return
+	}
+
+	// Visibility in meters
+	if isVisibilityInMeters(part) {
+		forecast.Visibility = ParseVisibility(part)
+		return
+	}
+
+	// Vertical visibility (VV)
+	if isVerticalVisibility(part) {
+		// Extract the height value
+		matches := vvRegex.FindStringSubmatch(part)
+		if len(matches) > 1 {
+			vertVis, _ := strconv.Atoi(matches[1])
+			forecast.VertVis = vertVis
+		}
+		return
+	}
+
+	// Clouds - check this BEFORE weather phenomena and make sure it takes priority
+	// over weather code detection
+	if cloudRegex.MatchString(part) {
+		cloud := ParseCloud(part)
+		forecast.Clouds = append(forecast.Clouds, cloud)
+		return
+	}
+
+	// Weather phenomena - check for weather codes, but not if it looks like a cloud code
+	if isWeatherCode(part) {
+		// Additional check to avoid misclassifying cloud patterns as weather codes
+		if !strings.HasPrefix(part, "SKC") &&
+			!strings.HasPrefix(part, "CLR") &&
+			!strings.HasPrefix(part, "FEW") &&
+			!strings.HasPrefix(part, "SCT") &&
+			!strings.HasPrefix(part, "BKN") &&
+			!strings.HasPrefix(part, "OVC") {
+			forecast.Weather = append(forecast.Weather, part)
+			return
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package wx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRunwayCondition(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw  string
+		want RunwayCondition
+	}{
+		{
+			raw: "R28/6000VP6000FT/U",
+			want: RunwayCondition{
+				Runway: "28", Visibility: 6000, VisMin: 6000, VisMax: 6000,
+				MaxPrefix: "P", Unit: "FT", Trend: "U", Raw: "R28/6000VP6000FT/U",
+			},
+		},
+		{
+			raw: "R09L/M0600VP2000FTD",
+			want: RunwayCondition{
+				Runway: "09L", Visibility: 600, VisMin: 600, VisMax: 2000,
+				Prefix: "M", MaxPrefix: "P", Unit: "FT", Trend: "D", Raw: "R09L/M0600VP2000FTD",
+			},
+		},
+		{
+			raw: "R21/1800V2000/N",
+			want: RunwayCondition{
+				Runway: "21", Visibility: 1800, VisMin: 1800, VisMax: 2000,
+				Trend: "N", Raw: "R21/1800V2000/N",
+			},
+		},
+		{
+			raw: "R06/P6000FT",
+			want: RunwayCondition{
+				Runway: "06", Visibility: 6000, Prefix: "P", Unit: "FT", Raw: "R06/P6000FT",
+			},
+		},
+		{
+			raw: "R24L/590155",
+			want: RunwayCondition{
+				Runway: "24L", Raw: "R24L/590155",
+				State: &RunwayState{Deposit: "wet snow", Extent: "51–100%", Depth: "1 mm", Friction: "friction coefficient 0.55"},
+			},
+		},
+		{
+			raw: "R32/01//70",
+			want: RunwayCondition{
+				Runway: "32", Raw: "R32/01//70",
+				State: &RunwayState{Deposit: "clear and dry", Extent: "10% or less", Friction: "friction coefficient 0.70"},
+			},
+		},
+		{
+			raw: "R06/5///93",
+			want: RunwayCondition{
+				Runway: "06", Raw: "R06/5///93",
+				State: &RunwayState{Deposit: "wet snow", Friction: "braking action medium"},
+			},
+		},
+		{
+			raw: "R27///////",
+			want: RunwayCondition{
+				Runway: "27", Raw: "R27///////", State: &RunwayState{},
+			},
+		},
+		{
+			// Extent 3 isn't a valid code
+			raw:  "R27/53//95",
+			want: RunwayCondition{Raw: "R27/53//95"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseRunwayCondition(tt.raw))
+		})
+	}
+}
+
+func TestParseVisibility(t *testing.T) {
+	t.Parallel()
+
+	milesFromMeters := func(meters int) float64 {
+		return float64(meters) / metersPerStatuteMile
+	}
+
+	tests := []struct {
+		raw  string
+		want Visibility
+	}{
+		{raw: "10SM", want: Visibility{Meters: 16093, StatuteMiles: 10, Unit: "SM", Raw: "10SM"}},
+		{raw: "1 1/2SM", want: Visibility{Meters: 2414, StatuteMiles: 1.5, Unit: "SM", Raw: "1 1/2SM"}},
+		{raw: "M1/4SM", want: Visibility{Meters: 402, StatuteMiles: 0.25, Unit: "SM", LessThan: true, Raw: "M1/4SM"}},
+		{raw: "P6SM", want: Visibility{Meters: 9656, StatuteMiles: 6, Unit: "SM", MoreThan: true, Raw: "P6SM"}},
+		{raw: "9999", want: Visibility{Meters: 9999, StatuteMiles: milesFromMeters(9999), Unit: "M", Unlimited: true, Raw: "9999"}},
+		{raw: "0000", want: Visibility{Unit: "M", LessThan: true, Raw: "0000"}},
+		{raw: "2000NE", want: Visibility{Meters: 2000, StatuteMiles: milesFromMeters(2000), Unit: "M", Direction: "NE", Raw: "2000NE"}},
+		{raw: "4000NDV", want: Visibility{Meters: 4000, StatuteMiles: milesFromMeters(4000), Unit: "M", Direction: "NDV", Raw: "4000NDV"}},
+		{raw: "CAVOK", want: Visibility{Meters: 10000, StatuteMiles: milesFromMeters(10000), Unit: "M", Unlimited: true, Raw: "CAVOK"}},
+		{raw: "XSM", want: Visibility{Raw: "XSM"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseVisibility(tt.raw))
+		})
+	}
+}
@@ -0,0 +1,1159 @@
+package wx
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+func newRemarkCodes() map[string]string {
+	// Common remark codes and their descriptions
+	codes := map[string]string{
+		"AO1":       "automated station without precipitation sensor",
+		"AO2":       "automated station with precipitation sensor",
+		"AO1A":      "automated station without precipitation sensor",
+		"AO2A":      "automated station with precipitation sensor",
+		"SLP":       "sea level pressure",
+		"SLPNO":     "sea level pressure information not available",
+		"FZRANO":    "freezing rain information not available",
+		"TSNO":      "thunderstorm information not available",
+		"RMK":       "remarks indicator",
+		"PRESRR":    "pressure rising rapidly",
+		"PRESFR":    "pressure falling rapidly",
+		"NOSIG":     "no significant changes expected",
+		"TEMPO":     "temporary",
+		"BECMG":     "becoming",
+		"VIRGA":     "precipitation not reaching ground",
+		"FROPA":     "frontal passage",
+		"CONTRAILS": "condensation trails observed",
+		"LAST":      "last observation before the station closes",
+		"FIRST":     "first observation after the station opens",
+		"RIME":      "rime icing",
+		"GLAZE":     "glaze icing",
+		"$":         "weather observing equipment requires maintenance",
+
+		// Sensor status
+		"RVRNO": "runway visual range not available",
+		"PWINO": "precipitation identifier information not available",
+		"PNO":   "precipitation amount not available",
+		"VISNO": "visibility at second location not available",
+		"CHINO": "cloud height at second location not available",
+
+		// Administrative codes
+		"NOSPECI": "no special reports taken",
+		"SPECI":   "special report",
+		"COR":     "corrected report",
+	}
+
+	// Fix invalid remark codes starting with "A0"
+	for k, v := range codes {
+		if strings.HasPrefix(k, "AO") {
+			codes["A0"+k[2:]] = v
+		}
+	}
+
+	return codes
+}
+
+// ProcessRemarks processes the remarks section of a METAR
+func ProcessRemarks(remarkParts []string) []Remark {
+	remarks := []Remark{}
+
+	remarkCodes := newRemarkCodes()
+	remarkParts = trimRemarkPunctuation(remarkParts, remarkCodes)
+
+	// Only the first valid sea level pressure is kept, like the body pressure, should a
+	// malformed remarks section repeat it
+	slpFound := false
+
+	// Process individual remarks or groups of related remarks
+	i := 0
+	for i < len(remarkParts) {
+		part := remarkParts[i]
+
+		// Treat verbose spellings like the code they stand for (e.g., VSBY as VIS)
+		if alias, ok := remarkAliases[part]; ok {
+			part = alias
+		}
+
+		// Handle altimeter setting in remarks (format A2994, QNH2994INS or Q1013)
+		if pressure, unit, ok := parseRemarkAltimeter(part); ok {
+			desc := fmt.Sprintf("altimeter setting %.2f inHg", pressure)
+			if unit == "hPa" {
+				desc = fmt.Sprintf("altimeter setting %.0f hPa", pressure)
+			}
+
+			remarks = append(remarks, Remark{
+				Raw:         part,
+				Description: desc,
+			})
+			i++
+			continue
+		}
+
+		// Handle peak wind
+		if strings.HasPrefix(part, "PK") && i+2 < len(remarkParts) {
+			if peakWindRegex.MatchString(strings.Join(remarkParts[i:i+3], " ")) {
+				matches := peakWindRegex.FindStringSubmatch(strings.Join(remarkParts[i:i+3], " "))
+				dir := matches[1]
+				speed := matches[2]
+				hour := matches[3]
+				minute := matches[4]
+
+				remarks = append(remarks, Remark{
+					Raw:         strings.Join(remarkParts[i:i+3], " "),
+					Description: fmt.Sprintf("peak wind %s° at %s knots at %s:%s", dir, speed, hour, minute),
+				})
+				i += 3
+				continue
+			}
+		}
+
+		// Handle wind direction variation (e.g., WND 020V040), for stations that report it
+		// in remarks rather than after the wind
+		if part == "WND" && i+1 < len(remarkParts) {
+			if desc, ok := parseWindVariationRemark("WND " + remarkParts[i+1]); ok {
+				remarks = append(remarks, Remark{
+					Raw:         strings.Join(remarkParts[i:i+2], " "),
+					Description: desc,
+				})
+				i += 2
+				continue
+			}
+		}
+
+		// Handle hail size (e.g., GR 1 3/4, GR M1/4)
+		if desc, n := parseHailSize(remarkParts[i:]); n > 0 {
+			remarks = append(remarks, Remark{
+				Raw:         strings.Join(remarkParts[i:i+n], " "),
+				Description: desc,
+			})
+			i += n
+			continue
+		}
+
+		// Handle weather beginning/ending, including combined and chained times
+		// (e.g., SNB20, -SHSNB20, TSB24E51, UPB0700E0708SNB0708)
+		if desc, ok := parseBeginEndTimes(part); ok {
+			remarks = append(remarks, Remark{
+				Raw:         part,
+				Description: desc,
+			})
+			i++
+			continue
+		}
+
+		// Handle sea level pressure
+		if strings.HasPrefix(part, "SLP") {
+			slpValue := part[3:] // This gets the value after "SLP"
+
+			// Ignore any sea level pressure after the first valid one
+			if slpFound {
+				i++
+				continue
+			}
+
+			if slpValue == "NO" {
+				// Handle the "SLPNO" case where sea-level pressure is not available
+				remarks = append(remarks, Remark{
+					Raw:         part,
+					Description: "sea level pressure not available",
+				})
+			} else {
+				slp, err := strconv.Atoi(slpValue)
+				if err == nil {
+					// SLP is given in tenths of millibars with an implied leading 10 or 9
+					var prefix float64 = 1000.0
+					if slp >= 500 {
+						prefix = 900.0
+					}
+					slpHpa := prefix + float64(slp)/10
+					remarks = append(remarks, Remark{
+						Raw:         part,
+						Description: fmt.Sprintf("sea level pressure %.1f hPa", slpHpa),
+					})
+					slpFound = true
+				} else {
+					remarks = append(remarks, Remark{
+						Raw:         part,
+						Description: "sea level pressure (invalid format)",
+					})
+				}
+			}
+			i++
+			continue
+		}
+
+		// Handle Temperature/Dew Point in tenths of degrees (format: T02170183, or T0217//// without a dew point)
+		if temp, dew, ok := parsePreciseTemperature(part); ok {
+			desc := fmt.Sprintf("temperature %.1f°C, dew point missing", temp)
+			if dew != nil {
+				desc = fmt.Sprintf("temperature %.1f°C, dew point %.1f°C", temp, *dew)
+			}
+
+			remarks = append(remarks, Remark{
+				Raw:         part,
+				Description: desc,
+			})
+			i++
+			continue
+		}
+
+		// Handle 6-hour maximum temperature (format: 1sTTT)
+		if len(part) == 5 && part[0] == '1' {
+			sign := part[1]
+			tempStr := part[2:]
+			temp, err := strconv.Atoi(tempStr)
+			if err == nil {
+				tempValue := float64(temp) / 10.0 // Convert to degrees
+				if sign == '1' {
+					tempValue = -tempValue // Apply negative sign if needed
+				}
+				remarks = append(remarks, Remark{
+					Raw:         part,
+					Description: fmt.Sprintf("6-hour maximum temperature %.1f°C", tempValue),
+				})
+				i++
+				continue
+			}
+		}
+
+		// Handle 6-hour minimum temperature (format: 2sTTT)
+		if len(part) == 5 && part[0] == '2' {
+			sign := part[1]
+			tempStr := part[2:]
+			temp, err := strconv.Atoi(tempStr)
+			if err == nil {
+				tempValue := float64(temp) / 10.0 // Convert to degrees
+				if sign == '1' {
+					tempValue = -tempValue // Apply negative sign if needed
+				}
+				remarks = append(remarks, Remark{
+					Raw:         part,
+					Description: fmt.Sprintf("6-hour minimum temperature %.1f°C", tempValue),
+				})
+				i++
+				continue
+			}
+		}
+
+		// Handle 24-hour maximum and minimum temperature (format: 4snTxTxTxsnTnTnTn)
+		if len(part) == 9 && part[0] == '4' {
+			maxTempSign := part[1:2]
+			maxTempStr := part[2:5]
+			minTempSign := part[5:6]
+			minTempStr := part[6:9]
+
+			// Parse maximum temperature
+			maxTemp, err1 := strconv.Atoi(maxTempStr)
+			// Parse minimum temperature
+			minTemp, err2 := strconv.Atoi(minTempStr)
+
+			if err1 == nil && err2 == nil {
+				// Convert to degrees Celsius
+				maxValue := float64(maxTemp) / 10.0
+				minValue := float64(minTemp) / 10.0
+
+				// Apply signs
+				if maxTempSign == "1" {
+					maxValue = -maxValue // negative if sign digit is 1
+				}
+				if minTempSign == "1" {
+					minValue = -minValue // negative if sign digit is 1
+				}
+
+				// Calculate Fahrenheit values
+				maxValueF := (maxValue * 9 / 5) + 32
+				minValueF := (minValue * 9 / 5) + 32
+
+				remarks = append(remarks, Remark{
+					Raw: part,
+					Description: fmt.Sprintf("24-hour temperature range: max %.1f°C (%.1f°F), min %.1f°C (%.1f°F)",
+						maxValue, maxValueF, minValue, minValueF),
+				})
+				i++
+				continue
+			}
+		}
+
+		// Handle 3-hour pressure change (format: 3PPPP)
+		if len(part) == 5 && part[0] == '3' {
+			pressStr := part[1:]
+			press, err := strconv.Atoi(pressStr)
+			if err == nil {
+				hpa := float64(press) / 10.0 // Convert to hPa
+				remarks = append(remarks, Remark{
+					Raw:         part,
+					Description: fmt.Sprintf("3-hour pressure change: %.1f hPa", hpa),
+				})
+				i++
+				continue
+			}
+		}
+
+		// Handle pressure tendency (format: 5appp)
+		if len(part) == 5 && part[0] == '5' {
+			tendencyCode := part[1]
+			changeStr := part[2:]
+			change, err := strconv.Atoi(changeStr)
+			if err == nil {
+				changeValue := float64(change) / 10.0 // Convert to hPa
+
+				tendencyDesc := "unknown"
+				switch tendencyCode {
+				case '0':
+					tendencyDesc = "increasing, then decreasing"
+				case '1':
+					tendencyDesc = "increasing, then steady"
+				case '2':
+					tendencyDesc = "increasing steadily"
+				case '3':
+					tendencyDesc = "increasing, then increasing more rapidly"
+				case '4':
+					tendencyDesc = "steady"
+				case '5':
+					tendencyDesc = "decreasing, then increasing"
+				case '6':
+					tendencyDesc = "decreasing, then steady"
+				case '7':
+					tendencyDesc = "decreasing steadily"
+				case '8':
+					tendencyDesc = "decreasing, then decreasing more rapidly"
+				}
+
+				remarks = append(remarks, Remark{
+					Raw:         part,
+					Description: fmt.Sprintf("pressure tendency: %s, %.1f hPa change", tendencyDesc, changeValue),
+				})
+				i++
+				continue
+			}
+		}
+
+		// Handle precipitation amounts
+		if precipAmountRegex.MatchString(part) {
+			matches := precipAmountRegex.FindStringSubmatch(part)
+			precip, _ := strconv.Atoi(matches[1])
+			inches := float64(precip) / 100.0
+
+			remarks = append(remarks, Remark{
+				Raw:         part,
+				Description: fmt.Sprintf("precipitation of %.2f inches in the last hour", inches),
+			})
+			i++
+			continue
+		}
+
+		// Handle 24-hour precipitation (format: 7RRRR)
+		if len(part) == 5 && part[0] == '7' {
+			precipStr := part[1:]
+			precip, err := strconv.Atoi(precipStr)
+			if err == nil {
+				inches := float64(precip) / 100.0 // Convert to inches
+				remarks = append(remarks, Remark{
+					Raw:         part,
+					Description: fmt.Sprintf("24-hour precipitation: %.2f inches", inches),
+				})
+				i++
+				continue
+			}
+		}
+
+		// Handle snow depth on ground (format: 4/sss)
+		if strings.HasPrefix(part, "4/") && len(part) == 5 {
+			snowStr := part[2:]
+			snow, err := strconv.Atoi(snowStr)
+			if err == nil {
+				remarks = append(remarks, Remark{
+					Raw:         part,
+					Description: fmt.Sprintf("snow depth: %d inches", snow),
+				})
+				i++
+				continue
+			}
+		}
+
+		// Handle ice accretion (format: IhVVV, or the shortened IhVV), where h is the
+		// period in hours: 1, 3 or 6
+		if (len(part) == 4 || len(part) == 5) && part[0] == 'I' && (part[1] == '1' || part[1] == '3' || part[1] == '6') {
+			hourDigit := part[1]
+			accretionStr := part[2:]
+			accretion, err := strconv.Atoi(accretionStr)
+			if err == nil {
+				timeframe := fmt.Sprintf("%c-hour", hourDigit)
+				inches := float64(accretion) / 100.0 // Convert to inches
+
+				remarks = append(remarks, Remark{
+					Raw:         part,
+					Description: fmt.Sprintf("%s ice accretion: %.2f inches", timeframe, inches),
+				})
+				i++
+				continue
+			}
+		}
+
+		// Handle recent weather (format: REww)
+		if strings.HasPrefix(part, "RE") && len(part) >= 4 {
+			wxType := part[2:]
+			weatherMap := map[string]string{
+				"RA": "rain",
+				"SN": "snow",
+				"GR": "hail",
+				"GS": "small hail",
+				"TS": "thunderstorm",
+				"FG": "fog",
+				"SQ": "squall",
+				"FC": "funnel cloud",
+			}
+
+			if desc, ok := weatherMap[wxType]; ok {
+				remarks = append(remarks, Remark{
+					Raw:         part,
+					Description: fmt.Sprintf("recent %s", desc),
+				})
+			} else {
+				remarks = append(remarks, Remark{
+					Raw:         part,
+					Description: "recent weather phenomenon",
+				})
+			}
+			i++
+			continue
+		}
+
+		// Handle runway visual range (format: Rrrr/Vvvvft or similar)
+		if strings.HasPrefix(part, "R") && strings.Contains(part, "/") {
+			remarks = append(remarks, Remark{
+				Raw:         part,
+				Description: "runway visual range information",
+			})
+			i++
+			continue
+		}
+
+		// Handle SNOINCR (format: SNINCR int/int)
+		if part == "SNINCR" && i+1 < len(remarkParts) {
+			snowData := remarkParts[i+1]
+			if strings.Contains(snowData, "/") {
+				parts := strings.Split(snowData, "/")
+				if len(parts) == 2 {
+					remarks = append(remarks, Remark{
+						Raw:         part + " " + snowData,
+						Description: fmt.Sprintf("snow increasing rapidly: %s inch within %s hour", parts[0], parts[1]),
+					})
+					i += 2
+					continue
+				}
+			}
+		}
+
+		// Handle ceiling remarks: CIG ddd, CIG BLW/ABV ddd, variable CIG dddVddd,
+		// optionally at a second location (e.g., CIG 005 RWY11)
+		if part == "CIG" {
+			if m := ceilingRemarkRegex.FindStringSubmatch(strings.Join(remarkParts[i:], " ")); m != nil {
+				raw := strings.TrimSpace(m[0])
+				remarks = append(remarks, Remark{
+					Raw:         raw,
+					Description: describeCeilingRemark(m),
+				})
+				i += len(strings.Fields(raw))
+				continue
+			}
+		}
+
+		// Handle runway braking action (format: RWY24 BA POOR or RWY 24 BA POOR)
+		if strings.HasPrefix(part, "RWY") {
+			runway := part[3:]
+			next := i + 1
+			if runway == "" && next < len(remarkParts) && runwayNumberRegex.MatchString(remarkParts[next]) {
+				runway = remarkParts[next]
+				next++
+			}
+			if runwayNumberRegex.MatchString(runway) && next+1 < len(remarkParts) &&
+				(remarkParts[next] == "BA" || remarkParts[next] == "/BA") {
+				if level, ok := brakingActionLevels[remarkParts[next+1]]; ok {
+					remarks = append(remarks, Remark{
+						Raw:         strings.Join(remarkParts[i:next+2], " "),
+						Description: fmt.Sprintf("runway %s braking action %s", runway, level),
+					})
+					i = next + 2
+					continue
+				}
+			}
+		}
+
+		// Handle braking action without a runway (format: BA GOOD or /BA GOOD)
+		if (part == "BA" || part == "/BA") && i+1 < len(remarkParts) {
+			if level, ok := brakingActionLevels[remarkParts[i+1]]; ok {
+				remarks = append(remarks, Remark{
+					Raw:         part + " " + remarkParts[i+1],
+					Description: fmt.Sprintf("braking action %s", level),
+				})
+				i += 2
+				continue
+			}
+		}
+
+		// Handle visibility remarks (e.g., VIS 1/2V2, VIS MIN 2000, VIS NE 2)
+		if part == "VIS" {
+			if desc, n := parseVisibilityRemark(part, remarkParts[i+1:]); n > 0 {
+				remarks = append(remarks, Remark{
+					Raw:         strings.Join(remarkParts[i:i+n], " "),
+					Description: desc,
+				})
+				i += n
+				continue
+			}
+		}
+
+		// Handle tower and surface visibility (e.g., TWR VIS 2, SFC VIS 1 1/2)
+		if (part == "TWR" || part == "SFC") && i+1 < len(remarkParts) && remarkParts[i+1] == "VIS" {
+			if m := visRemarkRegex.FindStringSubmatch(strings.Join(remarkParts[i+1:], " ")); m != nil && m[2] == "" {
+				observer := "tower"
+				if part == "SFC" {
+					observer = "surface"
+				}
+				n := 1 + len(strings.Fields(m[0]))
+				remarks = append(remarks, Remark{
+					Raw:         strings.Join(remarkParts[i:i+n], " "),
+					Description: fmt.Sprintf("%s visibility %s", observer, formatRemarkVisibility(m[1])),
+				})
+				i += n
+				continue
+			}
+		}
+
+		// Handle schedule times (e.g., AFT 0400, NEXT 151100, TIL 1500Z)
+		if prefix, ok := scheduleKeywords[part]; ok && i+1 < len(remarkParts) {
+			if when, n := parseRemarkTime(remarkParts[i+1:]); n > 0 {
+				remarks = append(remarks, Remark{
+					Raw:         strings.Join(remarkParts[i:i+1+n], " "),
+					Description: prefix + " " + when,
+				})
+				i += 1 + n
+				continue
+			}
+		}
+
+		// Handle missing data (e.g., CLD MISG)
+		if i+1 < len(remarkParts) && remarkParts[i+1] == "MISG" {
+			if element, ok := remarkElements[part]; ok {
+				remarks = append(remarks, Remark{
+					Raw:         part + " MISG",
+					Description: fmt.Sprintf("%s data missing", element),
+				})
+				i += 2
+				continue
+			}
+		}
+
+		// Handle estimated data (e.g., ESTMD ALSTG)
+		if part == "ESTMD" && i+1 < len(remarkParts) {
+			if element, ok := remarkElements[remarkParts[i+1]]; ok {
+				remarks = append(remarks, Remark{
+					Raw:         part + " " + remarkParts[i+1],
+					Description: fmt.Sprintf("estimated %s", element),
+				})
+				i += 2
+				continue
+			}
+		}
+
+		// Handle convective clouds with their distance, location and movement (e.g., CB DSNT NW MOV E, TCU NE)
+		if cloud, n := parseConvectiveCloudRemark(remarkParts[i:]); n > 0 {
+			remarks = append(remarks, Remark{
+				Raw:         strings.Join(remarkParts[i:i+n], " "),
+				Description: describeConvectiveCloud(cloud),
+			})
+			i += n
+			continue
+		}
+
+		// Handle smoke, haze and volcanic ash with their distance, location and movement
+		// (e.g., VA DSNT S, FU OHD, HZ ALQDS)
+		if desc, n := parseObscurationRemark(remarkParts[i:]); n > 0 {
+			remarks = append(remarks, Remark{
+				Raw:         strings.Join(remarkParts[i:i+n], " "),
+				Description: desc,
+			})
+			i += n
+			continue
+		}
+
+		// Handle phenomena in the vicinity, shallow, partial or patchy fog and dust/sand phenomena
+		// with optional location and movement (e.g., VCTS NE MOV E, BCFG SW, BLDU W, DD SE MOV NE)
+		if vicinityRegex.MatchString(part) || partialFogRegex.MatchString(part) || dustSandRegex.MatchString(part) {
+			suffix, n := parseLocationAndMovement(remarkParts, i+1)
+			remarks = append(remarks, Remark{
+				Raw:         strings.Join(remarkParts[i:i+1+n], " "),
+				Description: formatWeatherElement(part) + suffix,
+			})
+			i += 1 + n
+			continue
+		}
+
+		// Handle military color states, optionally with a trend (e.g., WHT BECMG BLU, GRN TEMPO YLO1)
+		if colorStateRegex.MatchString(part) {
+			desc := "color state " + DescribeColorState(part)
+			n := 1
+			if i+2 < len(remarkParts) && colorStateRegex.MatchString(remarkParts[i+2]) {
+				switch remarkParts[i+1] {
+				case "BECMG":
+					desc += ", becoming " + DescribeColorState(remarkParts[i+2])
+					n = 3
+				case "TEMPO":
+					desc += ", temporarily " + DescribeColorState(remarkParts[i+2])
+					n = 3
+				}
+			}
+			remarks = append(remarks, Remark{
+				Raw:         strings.Join(remarkParts[i:i+n], " "),
+				Description: desc,
+			})
+			i += n
+			continue
+		}
+
+		// Handle Canadian cloud layer amounts in oktas (e.g., SC5AC2, SF3SC4, FG2SC3)
+		if layers := parseCloudLayerRemark(part); layers != nil {
+			descs := make([]string, 0, len(layers))
+			for _, layer := range layers {
+				name := cloudGenera[layer.Type]
+				if layer.Obscuration {
+					name = formatWeatherElement(layer.Type)
+				}
+				descs = append(descs, fmt.Sprintf("%s %d/8", name, layer.Oktas))
+			}
+			remarks = append(remarks, Remark{
+				Raw:         part,
+				Description: "cloud layers: " + strings.Join(descs, ", "),
+			})
+			i++
+			continue
+		}
+
+		// Check for known multi-token phrases
+		if phrase, desc, n := matchRemarkPhrase(remarkParts, i); n > 0 {
+			remarks = append(remarks, Remark{
+				Raw:         phrase,
+				Description: desc,
+			})
+			i += n
+			continue
+		}
+
+		// Check for known remark codes
+		if desc, ok := remarkCodes[part]; ok {
+			remarks = append(remarks, Remark{
+				Raw:         part,
+				Description: desc,
+			})
+			i++
+			continue
+		}
+
+		// Free text (e.g. "UPCOMING TAXIWY CLOSURES. CHECK NOTAMS") is kept together as
+		// one note rather than one unknown remark per word
+		if n := freeTextLength(remarkParts[i:], remarkCodes); n > 0 {
+			note := strings.Join(remarkParts[i:i+n], " ")
+			remarks = append(remarks, Remark{
+				Raw:         note,
+				Description: "forecaster note: " + note,
+			})
+			i += n
+			continue
+		}
+
+		// Catch-all for unrecognized remarks
+		remarks = append(remarks, Remark{
+			Raw:         part,
+			Description: UnknownRemarkDescription,
+		})
+		i++
+	}
+
+	return remarks
+}
+
+// parseVisibilityRemark decodes a visibility remark made of the VIS code and the tokens following it.
+// It returns the description and the number of tokens consumed, including the VIS code (0 if none).
+func parseVisibilityRemark(code string, rest []string) (string, int) {
+	text := strings.Join(append([]string{code}, rest...), " ")
+	consumed := func(match string) int {
+		return len(strings.Fields(match))
+	}
+
+	if m := visRemarkMinRegex.FindStringSubmatch(text); m != nil {
+		meters, _ := strconv.Atoi(m[1])
+		desc := fmt.Sprintf("minimum visibility %s meters", FormatNumberWithCommas(meters))
+		if dir, ok := parseCompassDirection(m[2]); ok {
+			desc += " to the " + dir
+		}
+		return desc, consumed(m[0])
+	}
+
+	if m := visRemarkVariableRegex.FindStringSubmatch(text); m != nil {
+		low, high := formatRemarkVisibility(m[1]), formatRemarkVisibility(m[2])
+		// Only name the unit once when both ends share it (e.g., "1 to 3 statute miles")
+		if unit := " statute miles"; strings.HasSuffix(low, unit) && strings.HasSuffix(high, unit) && !strings.HasPrefix(low, "less") {
+			low = strings.TrimSuffix(low, unit)
+		}
+		return fmt.Sprintf("variable visibility %s to %s", low, high), consumed(m[0])
+	}
+
+	if m := visRemarkLowerRegex.FindStringSubmatch(text); m != nil {
+		if dir, ok := parseCompassDirection(m[1]); ok {
+			return "visibility lower to the " + dir, consumed(m[0])
+		}
+	}
+
+	if m := visRemarkSectorRegex.FindStringSubmatch(text); m != nil {
+		if dir, ok := parseCompassDirection(m[1]); ok {
+			return fmt.Sprintf("visibility %s to the %s", formatRemarkVisibility(m[2]), dir), consumed(m[0])
+		}
+	}
+
+	if m := visRemarkRegex.FindStringSubmatch(text); m != nil {
+		desc := "visibility " + formatRemarkVisibility(m[1])
+		if m[2] != "" {
+			desc += " at runway " + m[2]
+		}
+		return desc, consumed(m[0])
+	}
+
+	return "", 0
+}
+
+// parseRemarkTime describes a time at the start of parts: DDHHMM, HHMM (optionally followed
+// by UTC) or HHZ, each optionally closed by a slash. It returns the description and tokens
+// consumed (0 if none).
+func parseRemarkTime(parts []string) (string, int) {
+	token := strings.TrimSuffix(parts[0], "/")
+
+	if m := remarkTimeRegex.FindStringSubmatch(token); m != nil {
+		switch {
+		case m[1] != "" && m[2] != "":
+			// DDHHMM
+			return fmt.Sprintf("day %s at %s:%s UTC", m[1], m[2], m[3]), 1
+		case m[4] != "":
+			// HHZ
+			return fmt.Sprintf("%s:00 UTC", m[4]), 1
+		}
+
+		// HHMM
+		hour, _ := strconv.Atoi(token[:2])
+		minute, _ := strconv.Atoi(token[2:])
+		if hour > 24 || minute > 59 {
+			return "", 0
+		}
+		n := 1
+		if len(parts) > 1 && strings.TrimSuffix(parts[1], "/") == "UTC" {
+			n = 2
+		}
+		return fmt.Sprintf("%s:%s UTC", token[:2], token[2:]), n
+	}
+
+	return "", 0
+}
+
+// remarkVisibilityValue converts a visibility value from a remark (e.g., "1 1/2", "1800")
+// to the form used by the report body (e.g., "1 1/2SM", "1800")
+func remarkVisibilityValue(value string) string {
+	if len(value) == 4 && !strings.ContainsAny(value, "/ M") {
+		return value
+	}
+	return value + "SM"
+}
+
+// formatRemarkVisibility describes a visibility value from a remark: four digits
+// are meters, anything else is statute miles with an optional M (less than) prefix
+func formatRemarkVisibility(value string) string {
+	if len(value) == 4 && !strings.ContainsAny(value, "/ M") {
+		meters, _ := strconv.Atoi(value)
+		return FormatNumberWithCommas(meters) + " meters"
+	}
+	if rest, ok := strings.CutPrefix(value, "M"); ok {
+		return "less than " + rest + " statute miles"
+	}
+	return value + " statute miles"
+}
+
+// matchRemarkPhrase finds the longest entry of remarkPhrases starting at index i.
+// It returns the matched phrase, its description and the number of tokens consumed (0 if none).
+func matchRemarkPhrase(parts []string, i int) (string, string, int) {
+	maxLen := 0
+	for phrase := range remarkPhrases {
+		if n := len(strings.Fields(phrase)); n > maxLen {
+			maxLen = n
+		}
+	}
+
+	for n := min(maxLen, len(parts)-i); n >= 2; n-- {
+		phrase := strings.Join(parts[i:i+n], " ")
+		if desc, ok := remarkPhrases[phrase]; ok {
+			return phrase, desc, n
+		}
+	}
+
+	return "", "", 0
+}
+
+// parseCloudLayerRemark splits a Canadian cloud-layer remark such as SC5AC2 into its
+// layers, or returns nil if the token isn't one
+func parseCloudLayerRemark(token string) []CloudLayerRemark {
+	if !cloudLayersRegex.MatchString(token) {
+		return nil
+	}
+
+	var layers []CloudLayerRemark
+	for _, m := range cloudLayerRegex.FindAllStringSubmatch(token, -1) {
+		oktas, _ := strconv.Atoi(m[2])
+		_, isCloud := cloudGenera[m[1]]
+		layers = append(layers, CloudLayerRemark{Type: m[1], Oktas: oktas, Obscuration: !isCloud})
+	}
+	return layers
+}
+
+// parseConvectiveCloudRemark parses a remark at the start of parts locating convective
+// clouds: CB, CBMAM or TCU followed by an optional distance (DSNT, VC, OHD or ALQDS),
+// location and movement, at least one of which must be given (e.g. CB DSNT NW MOV E,
+// TCU NE). It returns the remark and the number of tokens consumed (0 if none).
+func parseConvectiveCloudRemark(parts []string) (ConvectiveCloudRemark, int) {
+	if len(parts) < 2 {
+		return ConvectiveCloudRemark{}, 0
+	}
+	if _, ok := convectiveCloudTypes[parts[0]]; !ok {
+		return ConvectiveCloudRemark{}, 0
+	}
+
+	cloud := ConvectiveCloudRemark{Type: parts[0]}
+	n := 1
+	if _, ok := CloudRemarkQualifiers[parts[n]]; ok {
+		cloud.Qualifier = parts[n]
+		n++
+	}
+
+	// Location, possibly several points joined by AND (e.g., W AND NE, TO SE AND SW)
+	if n+1 < len(parts) && parts[n] == "TO" {
+		if _, ok := parseCompassDirection(parts[n+1]); ok {
+			n++
+		}
+	}
+	for n < len(parts) {
+		if _, ok := parseCompassDirection(parts[n]); !ok {
+			break
+		}
+		cloud.Location = append(cloud.Location, parts[n])
+		n++
+		if n+1 < len(parts) && parts[n] == "AND" {
+			if _, ok := parseCompassDirection(parts[n+1]); ok {
+				n++
+			}
+		}
+	}
+
+	if _, m := parseMovement(parts, n); m > 0 {
+		cloud.Movement = "STNRY"
+		if m == 2 {
+			cloud.Movement = parts[n+1]
+		}
+		n += m
+	}
+
+	if n == 1 {
+		return ConvectiveCloudRemark{}, 0
+	}
+	return cloud, n
+}
+
+// describeConvectiveCloud describes a convective cloud remark, e.g. "cumulonimbus distant
+// to the northwest, moving east"
+func describeConvectiveCloud(cloud ConvectiveCloudRemark) string {
+	desc := convectiveCloudTypes[cloud.Type]
+	if cloud.Qualifier != "" {
+		desc += " " + CloudRemarkQualifiers[cloud.Qualifier]
+	}
+
+	var locations []string
+	for _, point := range cloud.Location {
+		dir, _ := parseCompassDirection(point)
+		locations = append(locations, dir)
+	}
+	if len(locations) > 0 {
+		desc += " to the " + strings.Join(locations, " and ")
+	}
+
+	switch cloud.Movement {
+	case "":
+	case "STNRY":
+		desc += ", stationary"
+	default:
+		dir, _ := parseCompassDirection(cloud.Movement)
+		desc += ", moving " + dir
+	}
+	return desc
+}
+
+// parseObscurationRemark describes a remark at the start of parts locating smoke, haze or
+// volcanic ash: FU, HZ or VA followed by an optional distance (DSNT, VC, OHD or ALQDS),
+// location and movement, at least one of which must be given (e.g. "VA DSNT S" is
+// "volcanic ash distant to the south"). It returns the number of tokens consumed (0 if none).
+func parseObscurationRemark(parts []string) (string, int) {
+	if len(parts) < 2 || !obscurationRegex.MatchString(parts[0]) {
+		return "", 0
+	}
+
+	desc := formatWeatherElement(parts[0])
+	n := 1
+	if qualifier, ok := CloudRemarkQualifiers[parts[n]]; ok {
+		desc += " " + qualifier
+		n++
+	}
+
+	suffix, m := parseLocationAndMovement(parts, n)
+	n += m
+	if n == 1 {
+		return "", 0
+	}
+	return desc + suffix, n
+}
+
+// convectiveCloudRemark parses a decoded remark that is a whole convective cloud remark
+func convectiveCloudRemark(raw string) (ConvectiveCloudRemark, bool) {
+	parts := strings.Fields(raw)
+	cloud, n := parseConvectiveCloudRemark(parts)
+	return cloud, n > 0 && n == len(parts)
+}
+
+// parseWindVariationRemark describes a "WND dddVddd" wind direction variation remark
+func parseWindVariationRemark(s string) (string, bool) {
+	matches := windVarRemarkRegex.FindStringSubmatch(s)
+	if matches == nil {
+		return "", false
+	}
+
+	from, _ := strconv.Atoi(matches[1])
+	to, _ := strconv.Atoi(matches[2])
+	if from > 360 || to > 360 {
+		return "", false
+	}
+	return fmt.Sprintf("wind direction variable between %03d° and %03d°", from, to), true
+}
+
+// parseHailSize describes a hail size remark at the start of parts: GR or GS followed by
+// the size in inches as a whole number, a fraction or both, with an M prefix for "less
+// than". It returns the description and the number of tokens consumed (0 if none).
+func parseHailSize(parts []string) (string, int) {
+	if len(parts) < 2 || (parts[0] != "GR" && parts[0] != "GS") {
+		return "", 0
+	}
+
+	// Try the longest size first so "GR 1 3/4" isn't read as "GR 1"
+	for n := min(len(parts), 3); n >= 2; n-- {
+		m := hailSizeRegex.FindStringSubmatch(strings.Join(parts[:n], " "))
+		if m == nil {
+			continue
+		}
+
+		stone := "hailstone"
+		if m[1] == "GS" {
+			stone = "small hailstone"
+		}
+
+		size := m[3]
+		whole, fraction, hasWhole := strings.Cut(size, " ")
+		if !hasWhole {
+			whole, fraction = "", size
+			if !strings.Contains(size, "/") {
+				whole, fraction = size, ""
+			}
+		}
+		if glyph, ok := UnicodeFractions[fraction]; ok {
+			fraction = glyph
+		}
+
+		unit := "inches"
+		if whole == "" || whole == "1" && fraction == "" {
+			unit = "inch"
+		}
+
+		desc := fmt.Sprintf("largest %s %s%s %s", stone, whole, fraction, unit)
+		if m[2] == "M" {
+			desc = fmt.Sprintf("largest %s less than %s%s %s", stone, whole, fraction, unit)
+		}
+		return desc, n
+	}
+	return "", 0
+}
+
+// parseRemarkAltimeter parses an altimeter setting repeated in remarks and returns
+// its value and unit ("inHg" or "hPa")
+func parseRemarkAltimeter(token string) (float64, string, bool) {
+	m := remarkAltimeterRegex.FindStringSubmatch(token)
+	if m == nil {
+		return 0, "", false
+	}
+
+	if m[3] != "" {
+		hpa, _ := strconv.Atoi(m[3])
+		return float64(hpa), "hPa", true
+	}
+	value, _ := strconv.Atoi(m[1] + m[2])
+	return float64(value) / 100.0, "inHg", true
+}
+
+// parsePreciseTemperature parses a T group of temperature and dew point in tenths of
+// degrees. The dew point is nil when it's slashed out.
+func parsePreciseTemperature(token string) (float64, *float64, bool) {
+	m := preciseTempRegex.FindStringSubmatch(token)
+	if m == nil {
+		return 0, nil, false
+	}
+
+	tenths := func(sign, value string) float64 {
+		v, _ := strconv.Atoi(value)
+		if sign == "1" {
+			v = -v
+		}
+		return float64(v) / 10.0
+	}
+
+	temp := tenths(m[1], m[2])
+	if m[4] == "" {
+		return temp, nil, true
+	}
+	dew := tenths(m[3], m[4])
+	return temp, &dew, true
+}
+
+// freeTextLength returns how many of the remaining remark tokens are free text, or 0
+// if none of them look like free text. Free text runs until the first known remark code
+// or coded group (e.g. $, SLP182 or T02110094), which is decoded on its own.
+func freeTextLength(parts []string, remarkCodes map[string]string) int {
+	n := slices.IndexFunc(parts, func(part string) bool {
+		_, known := remarkCodes[part]
+		return known || codedRemarkRegex.MatchString(part)
+	})
+	if n == -1 {
+		n = len(parts)
+	}
+	if !slices.ContainsFunc(parts[:n], looksLikeFreeText) {
+		return 0
+	}
+	return n
+}
+
+// trimRemarkPunctuation returns the remark tokens with trailing punctuation removed from
+// the ones that are codes rather than prose (e.g. "SLP182." or "AO2,"): those that end in a
+// digit once trimmed, or are known remark codes. Free text such as "CLOSURES." is kept as is.
+func trimRemarkPunctuation(parts []string, remarkCodes map[string]string) []string {
+	trimmed := slices.Clone(parts)
+	for i, part := range trimmed {
+		code := strings.TrimRight(part, ".,;")
+		if code == part || code == "" {
+			continue
+		}
+		if _, known := remarkCodes[code]; known || code[len(code)-1] >= '0' && code[len(code)-1] <= '9' {
+			trimmed[i] = code
+		}
+	}
+	return trimmed
+}
+
+// looksLikeFreeText reports whether a remark token reads as prose rather than a code:
+// it has lowercase letters or ends with sentence punctuation (e.g. "CLOSURES.", "ALQDS,")
+func looksLikeFreeText(token string) bool {
+	if strings.ToUpper(token) != token {
+		return true
+	}
+	return strings.ContainsAny(token[len(token)-1:], ".,;:!?")
+}
+
+// DescribeColorState spells out a color state code such as GRN, BLU+ or BLACKWHT
+func DescribeColorState(code string) string {
+	m := colorStateRegex.FindStringSubmatch(code)
+	if m == nil {
+		return code
+	}
+
+	desc := colorStates[m[2]] + m[3]
+	if m[1] != "" {
+		desc += ", airfield unusable for reasons other than weather (black)"
+	}
+	return desc
+}
+
+// ColorStateMinimums gives the cloud base and visibility minimums of a color state such as
+// BLU or BLACKYLO1 (e.g. "≥2500 ft ceiling and ≥8 km visibility"), or "" if it's unknown
+func ColorStateMinimums(code string) string {
+	m := colorStateRegex.FindStringSubmatch(code)
+	if m == nil {
+		return ""
+	}
+	return colorStateMinimumDescriptions[m[2]]
+}
+
+// describeCeilingRemark describes a ceilingRemarkRegex match
+func describeCeilingRemark(m []string) string {
+	hundreds := func(s string) string {
+		n, _ := strconv.Atoi(s)
+		return FormatNumberWithCommas(n * 100)
+	}
+
+	switch {
+	case m[1] != "":
+		return fmt.Sprintf("variable ceiling between %s and %s feet", hundreds(m[1]), hundreds(m[2]))
+	case m[3] != "":
+		return "ragged ceiling"
+	case m[6] != "":
+		return fmt.Sprintf("variable ceiling between %s and %s feet", hundreds(m[5]), hundreds(m[6]))
+	case m[4] == "BLW":
+		return fmt.Sprintf("ceiling below %s feet", hundreds(m[5]))
+	case m[4] == "ABV":
+		return fmt.Sprintf("ceiling above %s feet", hundreds(m[5]))
+	case m[7] != "":
+		return fmt.Sprintf("ceiling %s feet at runway %s", hundreds(m[5]), m[7])
+	default:
+		height, _ := strconv.Atoi(m[5])
+		return fmt.Sprintf("variable ceiling height: %d feet", height*100)
+	}
+}
+
+// parseBeginEndTimes describes a remark giving the times weather began (B) and ended (E),
+// where each phenomenon may have an intensity and several times and several phenomena may
+// follow each other, e.g. "TSB24E51" as "thunderstorm began at :24, ended at :51". Times
+// are minutes past the hour or, with four digits, hours and minutes.
+func parseBeginEndTimes(part string) (string, bool) {
+	if !beginEndRegex.MatchString(part) {
+		return "", false
+	}
+
+	var descriptions []string
+	for _, group := range beginEndGroupRegex.FindAllStringSubmatch(part, -1) {
+		phenomenon, found := beginEndPhenomena[group[2]]
+		if !found {
+			phenomenon = formatWeatherElement(group[2])
+		}
+		if intensity, ok := WeatherCodes[group[1]]; ok {
+			phenomenon = intensity.Description + " " + phenomenon
+		}
+
+		var events []string
+		for _, event := range beginEndEventRegex.FindAllStringSubmatch(group[3], -1) {
+			action := "began"
+			if event[1] == "E" {
+				action = "ended"
+			}
+
+			at := ":" + event[2]
+			if len(event[2]) == 4 {
+				at = event[2][:2] + ":" + event[2][2:]
+			}
+			events = append(events, fmt.Sprintf("%s at %s", action, at))
+		}
+
+		descriptions = append(descriptions, phenomenon+" "+strings.Join(events, ", "))
+	}
+	return strings.Join(descriptions, "; "), true
+}
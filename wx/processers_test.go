@@ -0,0 +1,879 @@
+package wx
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rmitchellscott/WxCraft/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// remarkTest describes a METAR line and the remark expected to be decoded from it
+type remarkTest struct {
+	metar string
+	raw   string
+	want  string
+}
+
+// runRemarkTests decodes each METAR and checks the expected remark, failing on unknown remarks
+func runRemarkTests(t *testing.T, tests []remarkTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			metar := DecodeMETAR(tt.metar)
+			found := false
+			for _, remark := range metar.Remarks {
+				assert.NotEqual(t, "unknown remark code", remark.Description, "remark %q", remark.Raw)
+				if remark.Raw == tt.raw {
+					found = true
+					assert.Equal(t, tt.want, remark.Description)
+				}
+			}
+			assert.True(t, found, "remark %q not decoded", tt.raw)
+		})
+	}
+}
+
+func TestProcessRemarks_brakingAction(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KMSP 081553Z 34012KT 1SM -SN OVC008 M05/M07 A2990 RMK AO2 RWY24 BA POOR SLP130",
+			raw:   "RWY24 BA POOR",
+			want:  "runway 24 braking action poor",
+		},
+		{
+			metar: "CYYZ 081500Z 32015KT 3SM -SN BKN015 M06/M09 A2985 RMK RWY 06L BA MEDIUM",
+			raw:   "RWY 06L BA MEDIUM",
+			want:  "runway 06L braking action medium",
+		},
+		{
+			metar: "PANC 081553Z 01005KT 10SM FEW050 M12/M17 A3012 RMK AO2 RWY07R /BA NIL",
+			raw:   "RWY07R /BA NIL",
+			want:  "runway 07R braking action nil",
+		},
+		{
+			metar: "KBIS 081552Z 30008KT 10SM CLR M15/M20 A3040 RMK AO2 /BA GOOD",
+			raw:   "/BA GOOD",
+			want:  "braking action good",
+		},
+	})
+}
+
+func TestProcessRemarks_snowPhrases(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KBTV 081554Z 35010KT 2SM -SN OVC012 M08/M10 A2995 RMK AO2 PATCHY SNW SLP148",
+			raw:   "PATCHY SNW",
+			want:  "patchy snow",
+		},
+		{
+			metar: "KFAR 081553Z 32025G35KT 1SM BLSN OVC010 M18/M21 A3002 RMK AO2 DRIFTING SNW",
+			raw:   "DRIFTING SNW",
+			want:  "drifting snow",
+		},
+		{
+			metar: "KDLH 081555Z 30012KT 5SM -SN BKN020 M11/M14 A3001 RMK AO2 SNW COVERED SLP190",
+			raw:   "SNW COVERED",
+			want:  "snow covered",
+		},
+	})
+}
+
+func TestProcessRemarks_icing(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KORD 081551Z 05012KT 3SM -FZRA BR OVC009 M01/M02 A2990 RMK AO2 RIME SLP131",
+			raw:   "RIME",
+			want:  "rime icing",
+		},
+		{
+			metar: "KORD 081551Z 05012KT 3SM -FZRA BR OVC009 M01/M02 A2990 RMK AO2 GLAZE SLP131",
+			raw:   "GLAZE",
+			want:  "glaze icing",
+		},
+		{
+			metar: "KORD 081551Z 05012KT 3SM -FZRA BR OVC009 M01/M02 A2990 RMK AO2 CLR ICE SLP131",
+			raw:   "CLR ICE",
+			want:  "clear icing",
+		},
+		{
+			metar: "KORD 081551Z 05012KT 3SM -FZRA BR OVC009 M01/M02 A2990 RMK AO2 I301 SLP131",
+			raw:   "I301",
+			want:  "3-hour ice accretion: 0.01 inches",
+		},
+	})
+}
+
+func TestProcessRemarks_iceAccretion(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KBUF 081554Z 07010KT 2SM -FZRA BR OVC006 M01/M02 A2985 RMK AO2 I1004 SLP112",
+			raw:   "I1004",
+			want:  "1-hour ice accretion: 0.04 inches",
+		},
+		{
+			metar: "KBUF 081554Z 07010KT 2SM -FZRA BR OVC006 M01/M02 A2985 RMK AO2 I1010 SLP112",
+			raw:   "I1010",
+			want:  "1-hour ice accretion: 0.10 inches",
+		},
+		{
+			metar: "KBUF 081554Z 07010KT 2SM -FZRA BR OVC006 M01/M02 A2985 RMK AO2 I3020 SLP112",
+			raw:   "I3020",
+			want:  "3-hour ice accretion: 0.20 inches",
+		},
+		{
+			metar: "KBUF 081554Z 07010KT 2SM -FZRA BR OVC006 M01/M02 A2985 RMK AO2 I6030 SLP112",
+			raw:   "I6030",
+			want:  "6-hour ice accretion: 0.30 inches",
+		},
+	})
+}
+
+func TestProcessRemarks_iceAccretionInvalidPeriod(t *testing.T) {
+	t.Parallel()
+
+	// Only 1, 3 and 6-hour periods are reported
+	metar := DecodeMETAR("KBUF 081554Z 07010KT 2SM -FZRA BR OVC006 M01/M02 A2985 RMK AO2 I2010 SLP112")
+	for _, remark := range metar.Remarks {
+		assert.NotContains(t, remark.Description, "ice accretion", "remark %q", remark.Raw)
+	}
+}
+
+func TestProcessRemarks_vicinity(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KMIA 081553Z 09012KT 10SM FEW025CB 30/23 A3001 RMK AO2 VCTS MOV E SLP162",
+			raw:   "VCTS MOV E",
+			want:  "thunderstorm in the vicinity, moving east",
+		},
+		{
+			metar: "KTPA 081553Z 27008KT 10SM SCT030 31/24 A2998 RMK AO2 VCSH NE-E MOVG N",
+			raw:   "VCSH NE-E MOVG N",
+			want:  "showers in the vicinity to the northeast through east, moving north",
+		},
+		{
+			metar: "KTPA 081553Z 27008KT 10SM SCT030 31/24 A2998 RMK AO2 VCSH W AND NE",
+			raw:   "VCSH W AND NE",
+			want:  "showers in the vicinity to the west and northeast",
+		},
+		{
+			metar: "KHOU 081553Z 16010KT 10SM BKN035 29/23 A2995 RMK AO2 -VCSH STNRY",
+			raw:   "-VCSH STNRY",
+			want:  "light showers in the vicinity, stationary",
+		},
+		{
+			metar: "KHOU 081553Z 16010KT 10SM BKN035 29/23 A2995 RMK AO2 VCSH",
+			raw:   "VCSH",
+			want:  "showers in the vicinity",
+		},
+	})
+}
+
+func TestProcessRemarks_fog(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KBOS 081554Z 27010KT 10SM FEW010 10/09 A3012 RMK AO2 VCFG E",
+			raw:   "VCFG E",
+			want:  "fog in the vicinity to the east",
+		},
+		{
+			metar: "KBOS 081554Z 27010KT 10SM FEW010 10/09 A3012 RMK AO2 VCBR",
+			raw:   "VCBR",
+			want:  "mist in the vicinity",
+		},
+		{
+			metar: "KBOS 081554Z 27010KT 10SM FEW010 10/09 A3012 RMK AO2 MIFG",
+			raw:   "MIFG",
+			want:  "shallow fog",
+		},
+		{
+			metar: "KBOS 081554Z 27010KT 10SM FEW010 10/09 A3012 RMK AO2 PRFG NE-E",
+			raw:   "PRFG NE-E",
+			want:  "partial fog to the northeast through east",
+		},
+		{
+			metar: "KBOS 081554Z 27010KT 10SM FEW010 10/09 A3012 RMK AO2 BCFG SW",
+			raw:   "BCFG SW",
+			want:  "patches of fog to the southwest",
+		},
+	})
+}
+
+func TestProcessRemarks_administrative(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KNUW 081556Z 18006KT 10SM FEW030 08/02 A3010 RMK NOSPECI",
+			raw:   "NOSPECI",
+			want:  "no special reports taken",
+		},
+		{
+			metar: "KNUW 081556Z 18006KT 10SM FEW030 08/02 A3010 RMK NO SPECI",
+			raw:   "NO SPECI",
+			want:  "no special reports taken",
+		},
+		{
+			metar: "KBIH 081556Z AUTO 18006KT 10SM 08/02 A3010 RMK AO2 CLD MISG",
+			raw:   "CLD MISG",
+			want:  "cloud data missing",
+		},
+		{
+			metar: "KBIH 081556Z AUTO 18006KT 10SM CLR 08/02 A3010 RMK AO2 ESTMD ALSTG",
+			raw:   "ESTMD ALSTG",
+			want:  "estimated altimeter setting",
+		},
+		{
+			metar: "KNKX 081556Z 18006KT 10SM FEW030 08/02 A3010 RMK OBS ENCRYPTED",
+			raw:   "OBS ENCRYPTED",
+			want:  "observation encrypted",
+		},
+		{
+			metar: "KNKX 081556Z 18006KT 10SM FEW030 08/02 A3010 RMK AO2 OBS INCOMPLETE SLP182",
+			raw:   "OBS INCOMPLETE",
+			want:  "observation incomplete",
+		},
+	})
+}
+
+func TestProcessRemarks_trendCodesNotDuplicated(t *testing.T) {
+	t.Parallel()
+
+	metar := DecodeMETAR("KORD 081551Z 27018KT 10SM BKN035 05/M03 A2962 RMK AO2 PRESFR NOSIG SLP034")
+
+	// Codes in the remarks section are only decoded as remarks, not as body special codes
+	assert.Empty(t, metar.SpecialCodes)
+
+	counts := map[string]int{}
+	for _, remark := range metar.Remarks {
+		counts[remark.Raw]++
+	}
+	assert.Equal(t, 1, counts["PRESFR"])
+	assert.Equal(t, 1, counts["NOSIG"])
+}
+
+func TestProcessRemarks_visibility(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KPWM 081551Z 36008KT 2SM BR OVC004 03/02 A2990 RMK AO2 VIS 1 1/2V4 SLP125",
+			raw:   "VIS 1 1/2V4",
+			want:  "variable visibility 1 1/2 to 4 statute miles",
+		},
+		{
+			metar: "KPWM 081551Z 36008KT 2SM BR OVC004 03/02 A2990 RMK AO2 VSBY 1/2V2 SLP125",
+			raw:   "VSBY 1/2V2",
+			want:  "variable visibility 1/2 to 2 statute miles",
+		},
+		{
+			metar: "KPWM 081551Z 36008KT 2SM BR OVC004 03/02 A2990 RMK AO2 VIS VRB 1-3",
+			raw:   "VIS VRB 1-3",
+			want:  "variable visibility 1 to 3 statute miles",
+		},
+		{
+			metar: "ENGM 081550Z 18008KT 9999 FEW030 08/02 Q1012 RMK VIS MIN 2000NE",
+			raw:   "VIS MIN 2000NE",
+			want:  "minimum visibility 2,000 meters to the northeast",
+		},
+		{
+			metar: "KPWM 081551Z 36008KT 6SM BR OVC004 03/02 A2990 RMK AO2 VIS NW-E 2 SLP125",
+			raw:   "VIS NW-E 2",
+			want:  "visibility 2 statute miles to the northwest through east",
+		},
+		{
+			metar: "KPWM 081551Z 36008KT 6SM BR OVC004 03/02 A2990 RMK AO2 VSBY LWR NE-SE",
+			raw:   "VSBY LWR NE-SE",
+			want:  "visibility lower to the northeast through southeast",
+		},
+		{
+			metar: "OAKB 081550Z 18008KT 5000 HZ FEW030 08/02 Q1012 RMK VIS 1800 RWY22",
+			raw:   "VIS 1800 RWY22",
+			want:  "visibility 1,800 meters at runway 22",
+		},
+		{
+			metar: "KEDW 081555Z 18008KT 10SM FEW250 18/02 A3001 RMK AO2 CONTRAILS SLP160",
+			raw:   "CONTRAILS",
+			want:  "condensation trails observed",
+		},
+	})
+}
+
+func TestProcessRemarks_towerSurfaceVisibility(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KJFK 081551Z 27010KT 1 1/2SM BR OVC005 05/04 A2990 RMK AO2 TWR VIS 2 TSB49",
+			raw:   "TWR VIS 2",
+			want:  "tower visibility 2 statute miles",
+		},
+		{
+			metar: "KJFK 081551Z 27010KT 2SM BR OVC005 05/04 A2990 RMK AO2 SFC VIS 1 1/2 SLP097",
+			raw:   "SFC VIS 1 1/2",
+			want:  "surface visibility 1 1/2 statute miles",
+		},
+	})
+}
+
+func TestProcessRemarks_beginEndTimes(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KJFK 081551Z 27010KT 10SM SCT050CB 25/20 A2990 RMK AO2 TSB24E51 SLP125",
+			raw:   "TSB24E51",
+			want:  "thunderstorm began at :24, ended at :51",
+		},
+		{
+			metar: "KORD 081551Z 27010KT 10SM FEW250 05/02 A2990 RMK AO2 RAE06B35E49 SLP125",
+			raw:   "RAE06B35E49",
+			want:  "rain ended at :06, began at :35, ended at :49",
+		},
+		{
+			metar: "KMSP 080751Z 36010KT 2SM -SN OVC010 M02/M04 A2990 RMK AO2 UPB0700E0708SNB0708 SLP125",
+			raw:   "UPB0700E0708SNB0708",
+			want:  "unknown precipitation began at 07:00, ended at 07:08; snow began at 07:08",
+		},
+		{
+			metar: "KBUF 081554Z 27015G25KT 2SM -SHSN OVC020 M02/M05 A2990 RMK AO2 -SHSNB20 SLP125",
+			raw:   "-SHSNB20",
+			want:  "light snow shower began at :20",
+		},
+		{
+			metar: "KBUF 081554Z 27015G25KT 1/2SM +SHSN VV005 M02/M05 A2990 RMK AO2 RAE10+SHSNB10 SLP125",
+			raw:   "RAE10+SHSNB10",
+			want:  "rain ended at :10; heavy snow shower began at :10",
+		},
+		{
+			metar: "KORD 081551Z 27010KT 10SM FEW250 05/02 A2990 RMK AO2 RAE15 SLP125",
+			raw:   "RAE15",
+			want:  "rain ended at :15",
+		},
+		{
+			metar: "KBOS 081551Z 27010KT 5SM -RA OVC020 05/02 A2990 RMK AO2 SHRAB09E19 SLP125",
+			raw:   "SHRAB09E19",
+			want:  "rain shower began at :09, ended at :19",
+		},
+	})
+}
+
+func TestProcessRemarks_beginEndTimesCorpus(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KTPL 080751Z AUTO 15018KT 8SM BKN009 19/18 A2979 RMK AO2 RAE22 CIG 007V010 PRESFR SLP077 P0000 T01890178",
+			raw:   "RAE22",
+			want:  "rain ended at :22",
+		},
+		{
+			metar: "KHIE 080743Z AUTO 28009KT 3SM -SN OVC027 M06/M09 A2938 RMK AO2 SNB0655 P0000 T10611094",
+			raw:   "SNB0655",
+			want:  "snow began at 06:55",
+		},
+		{
+			metar: "KSLK 080751Z AUTO 25007G23KT 10SM BKN024 OVC032 M06/M10 A2939 RMK AO2 SNB09E20 SLP973 P0000 T10561100",
+			raw:   "SNB09E20",
+			want:  "snow began at :09, ended at :20",
+		},
+		{
+			metar: "KALS 080749Z AUTO 36007KT 1 3/4SM -SN BKN008 OVC034 M02/M04 A2993 RMK AO2 SNE0659B35 P0000",
+			raw:   "SNE0659B35",
+			want:  "snow ended at 06:59, began at :35",
+		},
+		{
+			metar: "KNEW 080753Z AUTO 01006KT 1/2SM FG VV007 18/17 A2990 RMK AO2 RAB0659E18B34E53 SLP118 P0002 T01830172",
+			raw:   "RAB0659E18B34E53",
+			want:  "rain began at 06:59, ended at :18, began at :34, ended at :53",
+		},
+		{
+			metar: "EQYR 080735Z AUTO 23021G30KT -RA FEW014 BKN046 OVC070 13/11 A2949 RMK AO2 PK WND 18052/0658 TSE07B19E34 P0008 T01330111 $",
+			raw:   "TSE07B19E34",
+			want:  "thunderstorm ended at :07, began at :19, ended at :34",
+		},
+		{
+			metar: "OTBH 080855Z 12010G17KT 9000 HZ FEW210 32/03 A2970 Q1006 RMK AO2A FZRAE0755 SLP058 T03150026 58019 FZRANO $",
+			raw:   "FZRAE0755",
+			want:  "freezing rain ended at 07:55",
+		},
+		{
+			metar: "NSTU 080650Z 03011KT 5SM SHRA SCT015TCU OVC040 26/25 A2987 RMK SHRAB30 TCU ALQDS SLP115 T02620248",
+			raw:   "SHRAB30",
+			want:  "rain shower began at :30",
+		},
+		{
+			metar: "KLBL 080856Z AUTO 06013KT 8SM -SN FEW017 OVC037 01/00 A3008 RMK AO2 RAE02SNB02 SLP188 P0000 T00110000 50003 FZRANO",
+			raw:   "RAE02SNB02",
+			want:  "rain ended at :02; snow began at :02",
+		},
+		{
+			metar: "KQBL 080744Z AUTO 00000KT 7SM -SHRA OVC014 19/17 A2993 RMK AO2 RAE0655RAB34E37DZB37E39SHRAB39 SLP148",
+			raw:   "RAE0655RAB34E37DZB37E39SHRAB39",
+			want:  "rain ended at 06:55; rain began at :34, ended at :37; drizzle began at :37, ended at :39; rain shower began at :39",
+		},
+		{
+			metar: "RJTY 080742Z 00000KT 2 1/2SM -SN BR OVC011 02/M00 A3032 RMK AO2A SNB52E0724RASNB25RASNE30SNB31 CIG 011V015 SLP276 $",
+			raw:   "SNB52E0724RASNB25RASNE30SNB31",
+			want:  "snow began at :52, ended at 07:24; rain snow began at :25; rain snow ended at :30; snow began at :31",
+		},
+		{
+			metar: "PAVC 080656Z AUTO 27015G20KT 8SM -SN FEW019 OVC025 00/M04 A2980 RMK AO2 PK WND 27028/0639 UPB12E25SNE12B25 SLP092 P0000 T00001039 FZRANO",
+			raw:   "UPB12E25SNE12B25",
+			want:  "unknown precipitation began at :12, ended at :25; snow ended at :12, began at :25",
+		},
+	})
+}
+
+func TestProcessRemarks_schedule(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KFRG 142351Z 19005KT 10SM CLR 08/M01 A3011 RMK AO2 LAST NO AMD AFT 0400 NEXT 151100",
+			raw:   "AFT 0400",
+			want:  "after 04:00 UTC",
+		},
+		{
+			metar: "KFRG 142351Z 19005KT 10SM CLR 08/M01 A3011 RMK AO2 LAST NO AMD AFT 0400 NEXT 151100",
+			raw:   "NEXT 151100",
+			want:  "next report day 15 at 11:00 UTC",
+		},
+		{
+			metar: "KFRG 142351Z 19005KT 10SM CLR 08/M01 A3011 RMK AO2 LAST NO AMD AFT 0400 NEXT 151100",
+			raw:   "NO AMD",
+			want:  "no amendments",
+		},
+		{
+			metar: "CYXY 081500Z 18005KT 15SM FEW040 M02/M08 A3001 RMK AFT 03Z/ SLP019",
+			raw:   "AFT 03Z/",
+			want:  "after 03:00 UTC",
+		},
+		{
+			metar: "CYXY 081500Z 18005KT 15SM FEW040 M02/M08 A3001 RMK AFT 0300 UTC/ SLP685",
+			raw:   "AFT 0300 UTC/",
+			want:  "after 03:00 UTC",
+		},
+		{
+			metar: "KFRG 142351Z 19005KT 10SM CLR 08/M01 A3011 RMK AO2 LAST NO AMD AFT 1430 NEXT 151100",
+			raw:   "AFT 1430",
+			want:  "after 14:30 UTC",
+		},
+		{
+			metar: "KBKV 082355Z 00000KT 10SM CLR 18/12 A3012 RMK LAST",
+			raw:   "LAST",
+			want:  "last observation before the station closes",
+		},
+	})
+}
+
+func TestProcessRemarks_dustAndSand(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KPHX 082351Z 24015G25KT 10SM FEW100 35/M02 A2978 RMK AO2 DD SE MOV NE SLP067",
+			raw:   "DD SE MOV NE",
+			want:  "dust whirls to the southeast, moving northeast",
+		},
+		{
+			metar: "KPHX 082351Z 24015G25KT 10SM FEW100 35/M02 A2978 RMK AO2 PO W",
+			raw:   "PO W",
+			want:  "dust whirls to the west",
+		},
+		{
+			metar: "OEKK 081500Z 32018G28KT 3000 BLDU NSC 38/05 Q1004 RMK BLDU",
+			raw:   "BLDU",
+			want:  "blowing widespread dust",
+		},
+		{
+			metar: "KIPL 082353Z 27025G35KT 3SM BLSA CLR 33/01 A2975 RMK AO2 BLOWING SAND",
+			raw:   "BLOWING SAND",
+			want:  "blowing sand",
+		},
+		{
+			metar: "KIPL 082353Z 27025G35KT 3SM BLSA CLR 33/01 A2975 RMK AO2 DUST DEVILS",
+			raw:   "DUST DEVILS",
+			want:  "dust devils",
+		},
+	})
+}
+
+func TestProcessRemarks_obscuration(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "PAKN 081553Z 36008KT 6SM HZ OVC050 08/01 A2990 RMK AO2 VA DSNT S SLP128",
+			raw:   "VA DSNT S",
+			want:  "volcanic ash distant to the south",
+		},
+		{
+			metar: "KMSO 081553Z 00000KT 3SM FU CLR 18/02 A3010 RMK AO2 FU OHD",
+			raw:   "FU OHD",
+			want:  "smoke overhead",
+		},
+		{
+			metar: "MSLP 081431Z 03003KT 320V130 9999 FEW050 31/22 Q1014 NOSIG RMK FU SW",
+			raw:   "FU SW",
+			want:  "smoke to the southwest",
+		},
+		{
+			metar: "PTRO 080750Z 06007G14KT 13SM SCT016TCU BKN120 BKN300 29/25 A2978 RMK HZ ALQDS TCU N-E",
+			raw:   "HZ ALQDS",
+			want:  "haze in all quadrants",
+		},
+		{
+			metar: "KMSO 081553Z 00000KT 3SM FU CLR 18/02 A3010 RMK AO2 FU DSNT NW MOV SE",
+			raw:   "FU DSNT NW MOV SE",
+			want:  "smoke distant to the northwest, moving southeast",
+		},
+	})
+}
+
+func TestProcessRemarks_cloudLayers(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "CYWG 080749Z 17005KT 150V220 15SM BKN028 BKN044 BKN065 M02/M04 A2970 RMK SC5SC1SC1 SLP072",
+			raw:   "SC5SC1SC1",
+			want:  "cloud layers: stratocumulus 5/8, stratocumulus 1/8, stratocumulus 1/8",
+		},
+		{
+			metar: "CYYZ 080800Z 27010KT 15SM FEW040 BKN120 BKN250 M01/M06 A2990 RMK ACC1AC4CI2 SLP130",
+			raw:   "ACC1AC4CI2",
+			want:  "cloud layers: altocumulus castellanus 1/8, altocumulus 4/8, cirrus 2/8",
+		},
+		{
+			metar: "CYGV 080750Z 13009KT 1SM -SN BR VV002 M01/M02 A2847 RMK SN8 SLP643",
+			raw:   "SN8",
+			want:  "cloud layers: snow 8/8",
+		},
+	})
+}
+
+func TestProcessRemarks_altimeter(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "RJFC 080743Z AUTO VRB06KT 7000 -RA SCT009 OVC010 12/11 Q1021 RMK A3018",
+			raw:   "A3018",
+			want:  "altimeter setting 30.18 inHg",
+		},
+		{
+			metar: "KXYZ 080756Z 18005KT 10SM CLR 12/01 A2998 RMK AO2 QNH1015",
+			raw:   "QNH1015",
+			want:  "altimeter setting 1015 hPa",
+		},
+		{
+			metar: "EXYZ 080750Z 18005KT 9999 FEW030 12/01 Q1015 RMK QNH2998INS",
+			raw:   "QNH2998INS",
+			want:  "altimeter setting 29.98 inHg",
+		},
+	})
+}
+
+func TestProcessRemarks_preciseTemperature(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KSFO 080556Z 29011KT 10SM CLR 10/08 A3022 RMK AO2 T01000078",
+			raw:   "T01000078",
+			want:  "temperature 10.0°C, dew point 7.8°C",
+		},
+		{
+			metar: "KCGF 080735Z AUTO 28009KT 7SM OVC004 M01/ A2978 RMK AO2 T1007////",
+			raw:   "T1007////",
+			want:  "temperature -0.7°C, dew point missing",
+		},
+	})
+
+	// A missing body temperature is taken from the remark, leaving the dew point missing
+	metar := DecodeMETAR("KXYZ 080735Z AUTO 28009KT 7SM OVC004 A2978 RMK AO2 T0217////")
+	require.NotNil(t, metar.Temperature)
+	assert.Equal(t, 22, *metar.Temperature)
+	assert.Nil(t, metar.DewPoint)
+}
+
+func TestProcessRemarks_freeText(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KRDU 080751Z 00000KT 10SM CLR 08/M03 A3034 RMK AO2 UPCOMING TAXIWY CLOSURES. CHECK NOTAMS",
+			raw:   "UPCOMING TAXIWY CLOSURES. CHECK NOTAMS",
+			want:  "forecaster note: UPCOMING TAXIWY CLOSURES. CHECK NOTAMS",
+		},
+		{
+			metar: "KRDU 080751Z 00000KT 10SM CLR 08/M03 A3034 RMK AO2 SLP275 Runway 5L closed for maintenance $",
+			raw:   "Runway 5L closed for maintenance",
+			want:  "forecaster note: Runway 5L closed for maintenance",
+		},
+		{
+			metar: "KRDU 080751Z 00000KT 10SM CLR 08/M03 A3034 RMK AO2 SLP275 Runway 5L closed for maintenance $",
+			raw:   "$",
+			want:  "weather observing equipment requires maintenance",
+		},
+		{
+			metar: "KRDU 080751Z 00000KT 10SM CLR 08/M03 A3034 RMK AO2 ; NEXT OBS AT 0900",
+			raw:   "; NEXT OBS AT 0900",
+			want:  "forecaster note: ; NEXT OBS AT 0900",
+		},
+		{
+			metar: "KRDU 080751Z 00000KT 10SM CLR 21/09 A3034 RMK AO2 LAST OBS. SLP182 T02110094",
+			raw:   "OBS.",
+			want:  "forecaster note: OBS.",
+		},
+		{
+			metar: "KRDU 080751Z 00000KT 10SM CLR 21/09 A3034 RMK AO2 LAST OBS. SLP182 T02110094",
+			raw:   "SLP182",
+			want:  "sea level pressure 1018.2 hPa",
+		},
+		{
+			metar: "KRDU 080751Z 00000KT 10SM CLR 21/09 A3034 RMK AO2 LAST OBS. SLP182 T02110094",
+			raw:   "T02110094",
+			want:  "temperature 21.1°C, dew point 9.4°C",
+		},
+	})
+}
+
+func TestProcessRemarks_colorState(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "EGQL 080650Z 06005KT 2400 BR OVC003 07/07 Q1009 RMK YLO2",
+			raw:   "YLO2",
+			want:  "color state yellow 2",
+		},
+		{
+			metar: "LXGB 080936Z 20010G27KT 4000 RA FEW030 OVC034 16/12 Q1002 RMK GRN TEMPO YLO1",
+			raw:   "GRN TEMPO YLO1",
+			want:  "color state green, temporarily yellow 1",
+		},
+		{
+			metar: "EGYD 080720Z 07004KT 7000 HZ SCT250 05/04 Q1010 RMK WHT BECMG BLU",
+			raw:   "WHT BECMG BLU",
+			want:  "color state white, becoming blue",
+		},
+		{
+			metar: "LKNA 080730Z 10008KT CAVOK 07/M03 Q1016 RMK BLACKBLU+",
+			raw:   "BLACKBLU+",
+			want:  "color state blue+, airfield unusable for reasons other than weather (black)",
+		},
+	})
+}
+
+func TestProcessRemarks_ceiling(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KBOS 081554Z 04012KT 3SM -RA BR BKN008 OVC015 08/07 A2990 RMK AO2 CIG BLW 010 SLP126",
+			raw:   "CIG BLW 010",
+			want:  "ceiling below 1,000 feet",
+		},
+		{
+			metar: "KBOS 081554Z 04012KT 10SM BKN045 08/02 A2990 RMK AO2 CIG ABV 050 SLP126",
+			raw:   "CIG ABV 050",
+			want:  "ceiling above 5,000 feet",
+		},
+		{
+			metar: "KBOS 081554Z 04012KT 3SM -RA BR BKN008 08/07 A2990 RMK AO2 CIG 006V010 SLP126",
+			raw:   "CIG 006V010",
+			want:  "variable ceiling between 600 and 1,000 feet",
+		},
+		{
+			metar: "KBOS 081554Z 04012KT 3SM -RA BR BKN008 08/07 A2990 RMK AO2 CIG 005 RWY22L SLP126",
+			raw:   "CIG 005 RWY22L",
+			want:  "ceiling 500 feet at runway 22L",
+		},
+		{
+			metar: "CYQQ 080916Z 14020G30KT 7SM -RA BKN012 BKN025 OVC050 07/05 A3002 RMK SC5SC2SC1 CIG VRB 9-15 SLP168",
+			raw:   "CIG VRB 9-15",
+			want:  "variable ceiling between 900 and 1,500 feet",
+		},
+		{
+			metar: "CYQK 081550Z 33010G17KT 15SM BKN008 M02/M04 A2974 RMK SC6 CIG RAG SLP099",
+			raw:   "CIG RAG",
+			want:  "ragged ceiling",
+		},
+		{
+			metar: "KBOS 081554Z 04012KT 3SM -RA BR BKN008 08/07 A2990 RMK AO2 CIG 007 SLP126",
+			raw:   "CIG 007",
+			want:  "variable ceiling height: 700 feet",
+		},
+	})
+}
+
+func TestProcessRemarks_hailSize(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KOKC 082153Z 24018G32KT 2SM +TSRAGR BKN030CB 22/18 A2972 RMK AO2 GR M1/4",
+			raw:   "GR M1/4",
+			want:  "largest hailstone less than ¼ inch",
+		},
+		{
+			metar: "KOKC 082153Z 24018G32KT 2SM +TSRAGR BKN030CB 22/18 A2972 RMK AO2 GR 1 3/4 SLP062",
+			raw:   "GR 1 3/4",
+			want:  "largest hailstone 1¾ inches",
+		},
+		{
+			metar: "KOKC 082153Z 24018G32KT 2SM +TSRAGR BKN030CB 22/18 A2972 RMK AO2 GR 2",
+			raw:   "GR 2",
+			want:  "largest hailstone 2 inches",
+		},
+		{
+			metar: "KOKC 082153Z 24018G32KT 2SM TSGS BKN030CB 22/18 A2972 RMK AO2 GS 1/4",
+			raw:   "GS 1/4",
+			want:  "largest small hailstone ¼ inch",
+		},
+	})
+}
+
+func TestProcessRemarks_trailingPunctuation(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KORD 081551Z 05012KT 10SM OVC009 M01/M02 A2990 RMK AO2 SLP182.",
+			raw:   "SLP182",
+			want:  "sea level pressure 1018.2 hPa",
+		},
+		{
+			metar: "KORD 081551Z 05012KT 10SM OVC009 M01/M02 A2990 RMK AO2, SLP131 T00061017.",
+			raw:   "T00061017",
+			want:  "temperature 0.6°C, dew point -1.7°C",
+		},
+		{
+			metar: "KORD 081551Z 05012KT 10SM OVC009 M01/M02 A2990 RMK AO2, SLP131",
+			raw:   "AO2",
+			want:  "automated station with precipitation sensor",
+		},
+	})
+
+	// Prose keeps its punctuation as part of a forecaster note
+	metar := DecodeMETAR("KORD 081551Z 05012KT 10SM OVC009 M01/M02 A2990 RMK AO2 SLP131 CHECK NOTAMS.")
+	assert.Equal(t, "CHECK NOTAMS.", metar.Remarks[len(metar.Remarks)-1].Raw)
+}
+
+func BenchmarkProcessRemarks(b *testing.B) {
+	// Collect the remark sections of the corpus up front so only their processing is timed
+	var sections [][]string
+	scanner := testdata.METAR(b)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if i := slices.Index(parts, "RMK"); i != -1 && i+1 < len(parts) {
+			sections = append(sections, parts[i+1:])
+		}
+	}
+	require.NotEmpty(b, sections)
+
+	for b.Loop() {
+		for _, section := range sections {
+			ProcessRemarks(section)
+		}
+	}
+}
+
+func TestProcessRemarks_convectiveClouds(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KMIA 081553Z 09012KT 10SM SCT030CB 27/22 A3001 RMK AO2 CB DSNT NW MOV E SLP162",
+			raw:   "CB DSNT NW MOV E",
+			want:  "cumulonimbus distant to the northwest, moving east",
+		},
+		{
+			metar: "KMIA 081553Z 09012KT 10SM FEW025TCU 27/22 A3001 RMK AO2 TCU W AND NE",
+			raw:   "TCU W AND NE",
+			want:  "towering cumulus to the west and northeast",
+		},
+		{
+			metar: "KMIA 081553Z 09012KT 10SM SCT030CB 27/22 A3001 RMK AO2 CBMAM OHD STNRY",
+			raw:   "CBMAM OHD STNRY",
+			want:  "cumulonimbus mammatus overhead, stationary",
+		},
+		{
+			metar: "VNKT 081230Z 21008KT 6000 FEW025CB 16/10 Q1014 RMK CB TO SE AND SW",
+			raw:   "CB TO SE AND SW",
+			want:  "cumulonimbus to the southeast and southwest",
+		},
+	})
+
+	metar := DecodeMETAR("KMIA 081553Z 09012KT 10SM SCT030CB 27/22 A3001 RMK AO2 CB DSNT N-E MOV E TCU ALQDS")
+	assert.Equal(t, []ConvectiveCloudRemark{
+		{Type: "CB", Qualifier: "DSNT", Location: []string{"N-E"}, Movement: "E"},
+		{Type: "TCU", Qualifier: "ALQDS"},
+	}, metar.ConvectiveClouds)
+}
+
+func TestProcessRemarks_duplicateSeaLevelPressure(t *testing.T) {
+	t.Parallel()
+
+	slpRemarks := func(raw string) []Remark {
+		var slp []Remark
+		for _, remark := range DecodeMETAR(raw).Remarks {
+			if strings.HasPrefix(remark.Raw, "SLP") {
+				slp = append(slp, remark)
+			}
+		}
+		return slp
+	}
+
+	// The first valid one is kept and later ones are ignored
+	assert.Equal(t, []Remark{{Raw: "SLP182", Description: "sea level pressure 1018.2 hPa"}},
+		slpRemarks("KORD 081551Z 05012KT 10SM OVC009 M01/M02 A2990 RMK AO2 SLP182 T00061017 SLP190"))
+	assert.Equal(t, []Remark{{Raw: "SLP182", Description: "sea level pressure 1018.2 hPa"}},
+		slpRemarks("KORD 081551Z 05012KT 10SM OVC009 M01/M02 A2990 RMK AO2 SLP182 SLPNO"))
+
+	// One that isn't valid doesn't stop a later valid one
+	assert.Equal(t, []Remark{
+		{Raw: "SLPNO", Description: "sea level pressure not available"},
+		{Raw: "SLP182", Description: "sea level pressure 1018.2 hPa"},
+	}, slpRemarks("KORD 081551Z 05012KT 10SM OVC009 M01/M02 A2990 RMK AO2 SLPNO SLP182"))
+}
+
+func TestProcessRemarks_windVariation(t *testing.T) {
+	t.Parallel()
+
+	runRemarkTests(t, []remarkTest{
+		{
+			metar: "KBIS 081552Z 03012KT 10SM FEW250 21/09 A3012 RMK AO2 WND 020V040 SLP182",
+			raw:   "WND 020V040",
+			want:  "wind direction variable between 020° and 040°",
+		},
+		{
+			metar: "KBIS 081552Z 03012KT 10SM FEW250 21/09 A3012 RMK AO2 WND 20V40",
+			raw:   "WND 20V40",
+			want:  "wind direction variable between 020° and 040°",
+		},
+	})
+
+	// Other wind remarks are left alone
+	metar := DecodeMETAR("KBIS 081552Z 03012KT 10SM FEW250 21/09 A3012 RMK AO2 WND 020V400")
+	for _, remark := range metar.Remarks {
+		assert.NotContains(t, remark.Description, "variable between")
+	}
+}
@@ -0,0 +1,298 @@
+package wx
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/utils/ptr"
+)
+
+// NATO color state minimums, best to worst: the state is the first one whose
+// visibility and cloud base minimums are both met
+var colorStateMinimums = []struct {
+	code      string
+	visMeters int
+	ceilingFt int
+}{
+	{code: "BLU", visMeters: 8000, ceilingFt: 2500},
+	{code: "WHT", visMeters: 5000, ceilingFt: 1500},
+	{code: "GRN", visMeters: 3700, ceilingFt: 700},
+	{code: "YLO", visMeters: 1600, ceilingFt: 300},
+	{code: "AMB", visMeters: 800, ceilingFt: 200},
+}
+
+// ColorState returns the NATO color state (BLU, WHT, GRN, YLO, AMB or RED) for the base
+// of the lowest cloud layer covering 3/8 or more of the sky, in feet, and the visibility
+// in meters. Pass math.MaxInt as ceilingFt when there is no such layer.
+func ColorState(ceilingFt int, visMeters int) string {
+	for _, state := range colorStateMinimums {
+		if visMeters >= state.visMeters && ceilingFt >= state.ceilingFt {
+			return state.code
+		}
+	}
+	return "RED"
+}
+
+// ColorState returns the reported NATO color state, or one derived from the visibility
+// and cloud layers when none was reported. It returns "" when the visibility is unknown.
+func (m METAR) ColorState() string {
+	if m.ColorStateCode != "" {
+		return m.ColorStateCode
+	}
+	return m.DerivedColorState()
+}
+
+// DerivedColorState returns the NATO color state for the report's visibility and cloud
+// layers, ignoring any reported one. It returns "" when the visibility is unknown.
+func (m METAR) DerivedColorState() string {
+	if m.Visibility.Unit == "" {
+		return ""
+	}
+	return ColorState(m.colorStateCloudBase(), m.Visibility.Meters)
+}
+
+// colorStateCloudBase returns the base in feet of the lowest layer covering 3/8 of the sky
+// or more (SCT, BKN, OVC or an obscured sky), or math.MaxInt when there is none
+func (m METAR) colorStateCloudBase() int {
+	base := math.MaxInt
+	if m.VertVis > 0 {
+		base = m.VertVis * 100
+	}
+	for _, cloud := range m.Clouds {
+		switch cloud.Coverage {
+		case "SCT", "BKN", "OVC":
+			base = min(base, cloud.Height)
+		}
+	}
+	return base
+}
+
+// Ceiling returns the height in feet of the lowest broken or overcast layer, or of the
+// vertical visibility into an obscured sky. It reports false when there is neither.
+func (m METAR) Ceiling() (int, bool) {
+	ceiling, ok := 0, false
+	if m.VertVis > 0 {
+		ceiling, ok = m.VertVis*100, true
+	}
+	for _, cloud := range m.Clouds {
+		if cloud.Coverage != "BKN" && cloud.Coverage != "OVC" {
+			continue
+		}
+		if !ok || cloud.Height < ceiling {
+			ceiling, ok = cloud.Height, true
+		}
+	}
+	return ceiling, ok
+}
+
+// FlightCategory returns the FAA flight category for the ceiling and visibility: "LIFR"
+// (ceiling below 500 feet or visibility below 1 mile), "IFR" (below 1,000 feet or 3 miles),
+// "MVFR" (up to 3,000 feet or 5 miles) or "VFR". It returns "" when the visibility is unknown.
+func (m METAR) FlightCategory() string {
+	category, _ := m.FlightCategoryDetail()
+	return category
+}
+
+// FlightCategoryDetail returns the flight category like FlightCategory, along with the
+// deciding factor, e.g. "ceiling 2500 ft" or "visibility 2 sm". When the ceiling and the
+// visibility both put the report in its category, the reason names both.
+func (m METAR) FlightCategoryDetail() (category, reason string) {
+	if m.Visibility.Unit == "" {
+		return "", ""
+	}
+
+	ceiling, hasCeiling := m.Ceiling()
+	ceilingCategory := "VFR"
+	if hasCeiling {
+		ceilingCategory = ceilingFlightCategory(ceiling)
+	}
+	visibilityCategory := visibilityFlightCategory(m.Visibility.StatuteMiles)
+
+	category = ceilingCategory
+	if flightCategoryRank[visibilityCategory] < flightCategoryRank[ceilingCategory] {
+		category = visibilityCategory
+	}
+
+	var reasons []string
+	if hasCeiling && ceilingCategory == category {
+		reasons = append(reasons, fmt.Sprintf("ceiling %d ft", ceiling))
+	}
+	if visibilityCategory == category {
+		if m.Visibility.Unit == "M" {
+			reasons = append(reasons, fmt.Sprintf("visibility %d m", m.Visibility.Meters))
+		} else {
+			reasons = append(reasons, "visibility "+strconv.FormatFloat(m.Visibility.StatuteMiles, 'f', -1, 64)+" sm")
+		}
+	}
+	return category, strings.Join(reasons, ", ")
+}
+
+// flightCategoryRank orders the flight categories from worst to best
+var flightCategoryRank = map[string]int{"LIFR": 0, "IFR": 1, "MVFR": 2, "VFR": 3}
+
+// ceilingFlightCategory returns the flight category a ceiling in feet alone allows
+func ceilingFlightCategory(ceiling int) string {
+	switch {
+	case ceiling < 500:
+		return "LIFR"
+	case ceiling < 1000:
+		return "IFR"
+	case ceiling <= 3000:
+		return "MVFR"
+	}
+	return "VFR"
+}
+
+// visibilityFlightCategory returns the flight category a visibility in statute miles alone allows
+func visibilityFlightCategory(miles float64) string {
+	switch {
+	case miles < 1:
+		return "LIFR"
+	case miles < 3:
+		return "IFR"
+	case miles <= 5:
+		return "MVFR"
+	}
+	return "VFR"
+}
+
+// WithRemarkTemperatures returns a copy of the METAR with the temperature and dew point
+// taken from the T group in remarks (e.g. T02110094), rounded to whole degrees, in place
+// of the body values. Values the T group doesn't give are left as they are.
+func (m METAR) WithRemarkTemperatures() METAR {
+	for _, rmk := range m.Remarks {
+		temp, dew, ok := parsePreciseTemperature(rmk.Raw)
+		if !ok {
+			continue
+		}
+
+		m.Temperature = ptr.To(int(math.Round(temp)))
+		if dew != nil {
+			m.DewPoint = ptr.To(int(math.Round(*dew)))
+		}
+		break
+	}
+	return m
+}
+
+// SpeedKnots returns the sustained wind speed in knots, converting from meters per second
+// or kilometers per hour if needed. It reports false when the speed is missing.
+func (w Wind) SpeedKnots() (int, bool) {
+	if w.Speed == nil {
+		return 0, false
+	}
+	return w.toKnots(*w.Speed), true
+}
+
+// WindComponents splits a wind into its headwind and crosswind components, in the wind's
+// own units, for a runway heading in degrees. The headwind is negative for a tailwind and
+// the crosswind is positive when the wind comes from the right. The reported direction is
+// compared with the heading as is, ignoring magnetic variation. A variable (VRB) or
+// missing wind has no components and gives 0, 0.
+func WindComponents(wind Wind, runwayHeadingDeg int) (head, cross float64) {
+	direction, err := strconv.Atoi(wind.Direction)
+	if err != nil || wind.Speed == nil {
+		return 0, 0
+	}
+
+	angle := float64(direction-runwayHeadingDeg) * math.Pi / 180
+	speed := float64(*wind.Speed)
+	return speed * math.Cos(angle), speed * math.Sin(angle)
+}
+
+// GustKnots returns the gust speed in knots, converting from meters per second or
+// kilometers per hour if needed
+func (w Wind) GustKnots() int {
+	return w.toKnots(w.Gust)
+}
+
+// toKnots converts a speed in the wind's unit to knots
+func (w Wind) toKnots(speed int) int {
+	switch w.Unit {
+	case "MPS":
+		return MpsToKnots(speed)
+	case "KMH":
+		return KmhToKnots(speed)
+	}
+	return speed
+}
+
+// ApparentTemperature returns the "feels like" temperature in Celsius: the wind chill when
+// it's 10°C or colder with wind above 5 knots, or the heat index when it's 27°C or warmer.
+// It reports false when neither applies or the temperature (or dew point) is missing.
+func (m METAR) ApparentTemperature() (float64, bool) {
+	if m.Temperature == nil {
+		return 0, false
+	}
+	temp := *m.Temperature
+
+	if speed, ok := m.Wind.SpeedKnots(); ok && temp <= windChillMaxTempC && speed > windChillMinWindKt {
+		return WindChill(temp, speed), true
+	}
+
+	if temp >= heatIndexMinTempC && m.DewPoint != nil {
+		return HeatIndex(temp, *m.DewPoint), true
+	}
+
+	return 0, false
+}
+
+// MaxGust returns the strongest gust in knots anywhere in the TAF, including
+// TEMPO and PROB groups, along with the forecast period it occurs in.
+// It returns 0 when no gusts are forecast.
+func (t TAF) MaxGust() (int, Forecast) {
+	maxGust := 0
+	var period Forecast
+
+	for _, forecast := range t.Forecasts {
+		if gust := forecast.Wind.GustKnots(); gust > maxGust {
+			maxGust = gust
+			period = forecast
+		}
+	}
+
+	return maxGust, period
+}
+
+// HasCoordinates reports whether the station coordinates are known
+func (s SiteInfo) HasCoordinates() bool {
+	return s.Latitude != 0 || s.Longitude != 0
+}
+
+// altimeterTolerance is how far apart (in inHg) the reported pressure and its repeat in
+// remarks may be and still agree. QNH is reported in whole hPa and some stations truncate
+// rather than round, so the two can be up to 1 hPa (about 0.03 inHg) apart.
+const altimeterTolerance = 0.035
+
+// PressureDiscrepancy returns how far the altimeter setting in remarks is from the
+// reported pressure in inHg, or 0 if they agree
+func (m METAR) PressureDiscrepancy() float64 {
+	diff := math.Abs(pressureInHg(m.RemarkPressure, m.RemarkPressureUnit) - pressureInHg(m.Pressure, m.PressureUnit))
+	if diff <= altimeterTolerance {
+		return 0
+	}
+	return diff
+}
+
+// UndecodedTokens returns the parts of the report the decoder didn't recognize: the
+// Unhandled body tokens followed by any unknown remarks, or nil if everything decoded
+func (m METAR) UndecodedTokens() []string {
+	tokens := slices.Clone(m.Unhandled)
+	for _, remark := range m.Remarks {
+		if remark.Description == UnknownRemarkDescription {
+			tokens = append(tokens, remark.Raw)
+		}
+	}
+	return tokens
+}
+
+// pressureInHg converts a pressure reported in unit ("inHg" or "hPa", inHg if empty) to inHg
+func pressureInHg(pressure float64, unit string) float64 {
+	if unit == "hPa" {
+		return MillibarsToInHg(pressure)
+	}
+	return pressure
+}
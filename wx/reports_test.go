@@ -0,0 +1,247 @@
+package wx
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/utils/ptr"
+)
+
+func TestWind_GustKnots(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 25, Wind{Gust: 25, Unit: "KT"}.GustKnots())
+	assert.Equal(t, 29, Wind{Gust: 15, Unit: "MPS"}.GustKnots())
+	assert.Equal(t, 27, Wind{Gust: 50, Unit: "KMH"}.GustKnots())
+	assert.Zero(t, Wind{Unit: "KT"}.GustKnots())
+}
+
+func TestTAF_MaxGust(t *testing.T) {
+	t.Parallel()
+
+	taf := DecodeTAF("TAF KDEN 081120Z 0812/0912 27015G25KT P6SM SCT080 TEMPO 0812/0814 28020G35KT FM082000 30010KT P6SM SKC")
+	gust, period := taf.MaxGust()
+	assert.Equal(t, 35, gust)
+	assert.Equal(t, "TEMPO", period.Type)
+
+	calm := DecodeTAF("TAF KDEN 081120Z 0812/0912 27010KT P6SM SCT080")
+	gust, _ = calm.MaxGust()
+	assert.Zero(t, gust)
+}
+
+func TestDecodeTAF_windVariation(t *testing.T) {
+	t.Parallel()
+
+	taf := DecodeTAF("TAF EGLL 081100Z 0812/0918 24012KT 200V280 9999 SCT030")
+	if assert.NotEmpty(t, taf.Forecasts) {
+		assert.Equal(t, "200V280", taf.Forecasts[0].WindVariation)
+	}
+}
+
+func TestMETAR_ColorState(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		// Reported in the body or remarks
+		"EHVL 080725Z AUTO 11008KT 9999 OVC160 07/05 Q1014 BLACKBLU": "BLACKBLU",
+		"EGQL 081150Z 13006KT 1400 BR BKN001 08/07 Q1010 RMK RED":    "RED",
+		"FHAW 080750Z 13010KT 9999 FEW018 26/22 Q1012 RMK BLU":       "BLU",
+		// Derived from the lowest layer of 3/8 or more and the visibility
+		"KORD 081551Z 27010KT 3SM BR BKN008 21/19 A3012":      "GRN",
+		"KORD 081551Z 27010KT 10SM FEW005 SCT030 21/19 A3012": "BLU",
+		"KORD 081551Z 27010KT 10SM FEW005 SCT020 21/19 A3012": "WHT",
+		"EGLL 081550Z 27010KT 0300 FG VV001 10/10 Q1012":      "RED",
+		"EGLL 081550Z 27010KT CAVOK 10/05 Q1012":              "BLU",
+		"EGLL 081550Z 27010KT 1200 BR SCT003 10/10 Q1012":     "AMB",
+	}
+
+	for raw, want := range tests {
+		t.Run(raw, func(t *testing.T) {
+			assert.Equal(t, want, DecodeMETAR(raw).ColorState())
+		})
+	}
+
+	assert.Empty(t, METAR{}.ColorState())
+}
+
+func TestColorState(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		ceilingFt int
+		visMeters int
+		want      string
+	}{
+		{ceilingFt: math.MaxInt, visMeters: 10000, want: "BLU"},
+		{ceilingFt: 2500, visMeters: 8000, want: "BLU"},
+		{ceilingFt: 2499, visMeters: 8000, want: "WHT"},
+		{ceilingFt: 2500, visMeters: 7999, want: "WHT"},
+		{ceilingFt: 1500, visMeters: 5000, want: "WHT"},
+		{ceilingFt: 1499, visMeters: 5000, want: "GRN"},
+		{ceilingFt: 1500, visMeters: 4999, want: "GRN"},
+		{ceilingFt: 700, visMeters: 3700, want: "GRN"},
+		{ceilingFt: 699, visMeters: 3700, want: "YLO"},
+		{ceilingFt: 700, visMeters: 3699, want: "YLO"},
+		{ceilingFt: 300, visMeters: 1600, want: "YLO"},
+		{ceilingFt: 299, visMeters: 1600, want: "AMB"},
+		{ceilingFt: 300, visMeters: 1599, want: "AMB"},
+		{ceilingFt: 200, visMeters: 800, want: "AMB"},
+		{ceilingFt: 199, visMeters: 800, want: "RED"},
+		{ceilingFt: 200, visMeters: 799, want: "RED"},
+		{ceilingFt: 0, visMeters: 0, want: "RED"},
+		// The worse of the two decides
+		{ceilingFt: math.MaxInt, visMeters: 1000, want: "AMB"},
+		{ceilingFt: 100, visMeters: 10000, want: "RED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d ft %d m", tt.ceilingFt, tt.visMeters), func(t *testing.T) {
+			assert.Equal(t, tt.want, ColorState(tt.ceilingFt, tt.visMeters))
+		})
+	}
+}
+
+func TestPressureDiscrepancy(t *testing.T) {
+	t.Parallel()
+
+	assert.Zero(t, DecodeMETAR("RJTE 080745Z 36010KT 8000 -RA BKN025 07/03 Q1025 RMK A3028").PressureDiscrepancy())
+	assert.Zero(t, DecodeMETAR("RJFC 080743Z AUTO VRB06KT 7000 -RA SCT009 OVC010 12/11 Q1021 RMK A3018").PressureDiscrepancy())
+	assert.InDelta(t, 0.10, DecodeMETAR("RJTE 080745Z 36010KT 8000 -RA BKN025 07/03 Q1025 RMK A3037").PressureDiscrepancy(), 0.01)
+}
+
+func TestMETAR_Ceiling(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw    string
+		want   int
+		wantOK bool
+	}{
+		{raw: "KBOS 110054Z 12015KT 3SM -RA BR OVC007 08/07 A2978", want: 700, wantOK: true},
+		{raw: "KORD 081551Z 27010KT 10SM FEW020 SCT250 21/09 A3012", wantOK: false},
+		{raw: "KORD 081551Z 27010KT 10SM FEW008 BKN035 OVC120 21/09 A3012", want: 3500, wantOK: true},
+		{raw: "KSFO 080556Z 00000KT 1/8SM FG VV002 10/10 A3022", want: 200, wantOK: true},
+		{raw: "EGLL 080550Z 24008KT 9999 SKC 10/08 Q1022", wantOK: false},
+		{raw: "KSFO 080556Z 29011KT 10SM CLR 10/08 A3022", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			ceiling, ok := DecodeMETAR(tt.raw).Ceiling()
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, ceiling)
+		})
+	}
+}
+
+func TestMETAR_FlightCategory(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{raw: "KSFO 080556Z 29011KT 10SM CLR 10/08 A3022", want: "VFR"},
+		{raw: "KORD 081551Z 27010KT 10SM FEW008 BKN035 21/09 A3012", want: "VFR"},
+		{raw: "KORD 081551Z 27010KT 10SM BKN030 21/09 A3012", want: "MVFR"},
+		{raw: "KORD 081551Z 27010KT 5SM HZ SCT020 21/09 A3012", want: "MVFR"},
+		{raw: "KBOS 110054Z 12015KT 3SM -RA BR OVC007 08/07 A2978", want: "IFR"},
+		{raw: "KSFO 080556Z 00000KT 1/8SM FG VV002 10/10 A3022", want: "LIFR"},
+		{raw: "EGLL 080550Z 24008KT 0800 FG BKN010 10/10 Q1022", want: "LIFR"},
+		{raw: "KORD 081551Z 27010KT BKN030 21/09 A3012", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			assert.Equal(t, tt.want, DecodeMETAR(tt.raw).FlightCategory())
+		})
+	}
+}
+
+func TestMETAR_FlightCategoryDetail(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw          string
+		wantCategory string
+		wantReason   string
+	}{
+		{raw: "KORD 081551Z 27010KT 10SM BKN025 21/09 A3012", wantCategory: "MVFR", wantReason: "ceiling 2500 ft"},
+		{raw: "KORD 081551Z 27010KT 2SM BR BKN040 21/09 A3012", wantCategory: "IFR", wantReason: "visibility 2 sm"},
+		{raw: "KBOS 110054Z 12015KT 2 1/2SM -RA BR OVC007 08/07 A2978", wantCategory: "IFR", wantReason: "ceiling 700 ft, visibility 2.5 sm"},
+		{raw: "EGLL 080550Z 24008KT 0800 FG BKN010 10/10 Q1022", wantCategory: "LIFR", wantReason: "visibility 800 m"},
+		{raw: "KSFO 080556Z 29011KT 10SM CLR 10/08 A3022", wantCategory: "VFR", wantReason: "visibility 10 sm"},
+		{raw: "KORD 081551Z 27010KT BKN030 21/09 A3012", wantCategory: "", wantReason: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			m := DecodeMETAR(tt.raw)
+			category, reason := m.FlightCategoryDetail()
+			assert.Equal(t, tt.wantCategory, category)
+			assert.Equal(t, tt.wantReason, reason)
+			assert.Equal(t, m.FlightCategory(), category)
+		})
+	}
+}
+
+func TestWindComponents(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		wind      Wind
+		heading   int
+		wantHead  float64
+		wantCross float64
+	}{
+		{name: "headwind only", wind: Wind{Direction: "270", Speed: ptr.To(15)}, heading: 270, wantHead: 15, wantCross: 0},
+		{name: "tailwind only", wind: Wind{Direction: "090", Speed: ptr.To(10)}, heading: 270, wantHead: -10, wantCross: 0},
+		{name: "pure crosswind from the right", wind: Wind{Direction: "360", Speed: ptr.To(12)}, heading: 270, wantHead: 0, wantCross: 12},
+		{name: "pure crosswind from the left", wind: Wind{Direction: "180", Speed: ptr.To(12)}, heading: 270, wantHead: 0, wantCross: -12},
+		{name: "quartering", wind: Wind{Direction: "300", Speed: ptr.To(20)}, heading: 270, wantHead: 17.3, wantCross: 10},
+		{name: "variable", wind: Wind{Direction: "VRB", Speed: ptr.To(5)}, heading: 270, wantHead: 0, wantCross: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			head, cross := WindComponents(tt.wind, tt.heading)
+			assert.InDelta(t, tt.wantHead, head, 0.1)
+			assert.InDelta(t, tt.wantCross, cross, 0.1)
+		})
+	}
+}
+
+func TestMETAR_WithRemarkTemperatures(t *testing.T) {
+	t.Parallel()
+
+	metar := DecodeMETAR("KSFO 081556Z 29011KT 10SM CLR 21/09 A3012 RMK AO2 T02160094")
+	precise := metar.WithRemarkTemperatures()
+	assert.Equal(t, ptr.To(22), precise.Temperature)
+	assert.Equal(t, ptr.To(9), precise.DewPoint)
+
+	// The body values are untouched
+	assert.Equal(t, ptr.To(21), metar.Temperature)
+
+	// Without a dew point in the T group, the body's is kept
+	metar = DecodeMETAR("KSFO 081556Z 29011KT 10SM CLR M01/M03 A3012 RMK AO2 T1006////")
+	precise = metar.WithRemarkTemperatures()
+	assert.Equal(t, ptr.To(-1), precise.Temperature)
+	assert.Equal(t, ptr.To(-3), precise.DewPoint)
+
+	// Without a T group, nothing changes
+	metar = DecodeMETAR("KSFO 081556Z 29011KT 10SM CLR 21/09 A3012 RMK AO2")
+	assert.Equal(t, metar, metar.WithRemarkTemperatures())
+}
+
+func TestMETAR_UndecodedTokens(t *testing.T) {
+	t.Parallel()
+
+	metar := DecodeMETAR("KSFO 081556Z 29011KT 10SM CLR 21/09 A3012 RMK AO2 T02160094")
+	assert.Empty(t, metar.UndecodedTokens())
+
+	metar = DecodeMETAR("KSFO 081556Z 29011KT 10SM CLR 21/09 A3012 XX9X RMK AO2 Z9Z9")
+	assert.Equal(t, []string{"XX9X", "Z9Z9"}, metar.UndecodedTokens())
+}
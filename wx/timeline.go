@@ -0,0 +1,92 @@
+package wx
+
+import "time"
+
+// TAFConditions is what a TAF forecasts for one moment: the prevailing conditions of the
+// base or FM period in effect (with any completed BECMG changes applied), and the TEMPO,
+// PROB and in-progress BECMG groups that may temporarily replace them
+type TAFConditions struct {
+	Prevailing Forecast
+	Temporary  []Forecast
+}
+
+// At returns the conditions the TAF forecasts at the given time. It reports false when the
+// time is outside the TAF's validity or the TAF has no forecast periods.
+//
+// The prevailing period is the base or FM period that most recently started, not the last
+// one listed: a TEMPO or PROB group that straddles an FM change is overlaid on the FM
+// period in effect at that time, so its base changes partway through it.
+func (t TAF) At(at time.Time) (TAFConditions, bool) {
+	if (!t.ValidFrom.IsZero() && at.Before(t.ValidFrom)) || (!t.ValidTo.IsZero() && !at.Before(t.ValidTo)) {
+		return TAFConditions{}, false
+	}
+
+	var conditions TAFConditions
+	found := false
+	for _, forecast := range t.Forecasts {
+		if forecast.Type != "BASE" && forecast.Type != "FM" {
+			continue
+		}
+		if forecast.From.After(at) {
+			continue
+		}
+		if !found || !forecast.From.Before(conditions.Prevailing.From) {
+			conditions.Prevailing = forecast
+			found = true
+		}
+	}
+	if !found {
+		return TAFConditions{}, false
+	}
+
+	for _, forecast := range t.Forecasts {
+		if forecast.Type == "BASE" || forecast.Type == "FM" || forecast.From.After(at) {
+			continue
+		}
+
+		if forecast.Type == "BECMG" {
+			// A change that began under an earlier base period was superseded by the FM
+			if forecast.From.Before(conditions.Prevailing.From) {
+				continue
+			}
+			// The change happens at some point during the transition, so until it ends
+			// either set of conditions may be present
+			if forecast.To.IsZero() || !at.Before(forecast.To) {
+				conditions.Prevailing = applyForecastChange(conditions.Prevailing, forecast)
+			} else {
+				conditions.Temporary = append(conditions.Temporary, forecast)
+			}
+			continue
+		}
+
+		// TEMPO, PROB and INTER groups apply only within their own period
+		if forecast.To.IsZero() || at.Before(forecast.To) {
+			conditions.Temporary = append(conditions.Temporary, forecast)
+		}
+	}
+
+	return conditions, true
+}
+
+// applyForecastChange returns the base forecast with the elements a BECMG group changes
+// replaced; elements the group doesn't mention carry over unchanged
+func applyForecastChange(base Forecast, change Forecast) Forecast {
+	if change.Wind.Unit != "" {
+		base.Wind = change.Wind
+		base.WindVariation = change.WindVariation
+	}
+	if len(change.WindShear) > 0 {
+		base.WindShear = change.WindShear
+	}
+	if change.Visibility.Unit != "" {
+		base.Visibility = change.Visibility
+	}
+	if len(change.Weather) > 0 {
+		base.Weather = change.Weather
+	}
+	if len(change.Clouds) > 0 || change.VertVis > 0 {
+		base.Clouds = change.Clouds
+		base.VertVis = change.VertVis
+	}
+	return base
+}
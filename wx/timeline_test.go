@@ -0,0 +1,84 @@
+package wx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTAFAt_tempoStraddlingFM(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.March, 8, 17, 30, 0, 0, time.UTC)
+	taf := DecodeTAFAt("TAF KORD 081720Z 0818/0924 27010KT P6SM SCT050 "+
+		"TEMPO 0820/0902 3SM -SHRA BKN025 "+
+		"FM082200 31015G25KT P6SM BKN035 "+
+		"FM090600 33008KT P6SM SKC", now)
+
+	at := func(day, hour int) TAFConditions {
+		t.Helper()
+		conditions, ok := taf.At(time.Date(2025, time.March, day, hour, 0, 0, 0, time.UTC))
+		require.True(t, ok)
+		return conditions
+	}
+
+	// Before the TEMPO starts
+	conditions := at(8, 19)
+	assert.Equal(t, "BASE", conditions.Prevailing.Type)
+	assert.Empty(t, conditions.Temporary)
+
+	// The TEMPO overlays the base period...
+	conditions = at(8, 21)
+	assert.Equal(t, "BASE", conditions.Prevailing.Type)
+	require.Len(t, conditions.Temporary, 1)
+	assert.Equal(t, "TEMPO", conditions.Temporary[0].Type)
+
+	// ...and then the FM period that takes over partway through it
+	conditions = at(9, 1)
+	assert.Equal(t, "FM", conditions.Prevailing.Type)
+	assert.Equal(t, "310", conditions.Prevailing.Wind.Direction)
+	require.Len(t, conditions.Temporary, 1)
+	assert.Equal(t, "TEMPO", conditions.Temporary[0].Type)
+
+	// After the TEMPO ends only the FM period remains
+	conditions = at(9, 3)
+	assert.Equal(t, "310", conditions.Prevailing.Wind.Direction)
+	assert.Empty(t, conditions.Temporary)
+
+	// The last FM period in the list isn't in effect until it starts
+	conditions = at(9, 7)
+	assert.Equal(t, "330", conditions.Prevailing.Wind.Direction)
+
+	_, ok := taf.At(time.Date(2025, time.March, 8, 17, 0, 0, 0, time.UTC))
+	assert.False(t, ok, "before the validity")
+	_, ok = taf.At(time.Date(2025, time.March, 10, 0, 0, 0, 0, time.UTC))
+	assert.False(t, ok, "at the end of the validity")
+}
+
+func TestTAFAt_becmg(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.March, 8, 17, 30, 0, 0, time.UTC)
+	taf := DecodeTAFAt("TAF EGLL 081720Z 0818/0924 27010KT 9999 SCT030 "+
+		"BECMG 0820/0822 5000 BKN012 "+
+		"TEMPO 0823/0903 2000 RA", now)
+
+	// During the transition the change is only a possibility
+	conditions, ok := taf.At(time.Date(2025, time.March, 8, 21, 0, 0, 0, time.UTC))
+	require.True(t, ok)
+	assert.Equal(t, 9999, conditions.Prevailing.Visibility.Meters)
+	require.Len(t, conditions.Temporary, 1)
+	assert.Equal(t, "BECMG", conditions.Temporary[0].Type)
+
+	// Once it's complete the changed elements replace the base ones, and the wind carries over
+	conditions, ok = taf.At(time.Date(2025, time.March, 9, 0, 0, 0, 0, time.UTC))
+	require.True(t, ok)
+	assert.Equal(t, 5000, conditions.Prevailing.Visibility.Meters)
+	require.Len(t, conditions.Prevailing.Clouds, 1)
+	assert.Equal(t, "BKN", conditions.Prevailing.Clouds[0].Coverage)
+	assert.Equal(t, "270", conditions.Prevailing.Wind.Direction)
+	require.Len(t, conditions.Temporary, 1)
+	assert.Equal(t, "TEMPO", conditions.Temporary[0].Type)
+}